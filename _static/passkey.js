@@ -0,0 +1,107 @@
+// passkey.js is the browser side of passkeys: it adds them from the admin passkeys page, and
+// signs in with them from the admin login page.
+//
+// The site sends options with binary values base64url encoded; they're decoded to the buffers
+// `navigator.credentials` takes, and the browser's response is encoded the same way to post back.
+(function () {
+    "use strict";
+
+    function decode(value) {
+        var base64 = value.replace(/-/g, "+").replace(/_/g, "/");
+        var binary = atob(base64 + "===".slice((base64.length + 3) % 4));
+        var bytes = new Uint8Array(binary.length);
+        for (var i = 0; i < binary.length; i++) {
+            bytes[i] = binary.charCodeAt(i);
+        }
+        return bytes.buffer;
+    }
+
+    function encode(buffer) {
+        var bytes = new Uint8Array(buffer);
+        var binary = "";
+        for (var i = 0; i < bytes.length; i++) {
+            binary += String.fromCharCode(bytes[i]);
+        }
+        return btoa(binary).replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
+    }
+
+    function descriptors(list) {
+        return (list || []).map(function (descriptor) {
+            return { type: descriptor.type, id: decode(descriptor.id) };
+        });
+    }
+
+    function post(url, body) {
+        return fetch(url, {
+            method: "POST",
+            credentials: "same-origin",
+            headers: { "Content-Type": "application/json" },
+            body: JSON.stringify(body || {})
+        }).then(function (res) {
+            return res.json().catch(function () { return {}; }).then(function (result) {
+                if (!res.ok) {
+                    throw new Error(result.error || "Something went wrong; please try again.");
+                }
+                return result;
+            });
+        });
+    }
+
+    function register(form) {
+        return post("/admin/passkeys/options").then(function (options) {
+            options.challenge = decode(options.challenge);
+            options.user.id = decode(options.user.id);
+            options.excludeCredentials = descriptors(options.excludeCredentials);
+            return navigator.credentials.create({ publicKey: options });
+        }).then(function (credential) {
+            return post("/admin/passkeys", {
+                name: form.elements.name.value,
+                credential: {
+                    id: credential.id,
+                    clientDataJSON: encode(credential.response.clientDataJSON),
+                    attestationObject: encode(credential.response.attestationObject)
+                }
+            });
+        });
+    }
+
+    function login(form) {
+        return post("/admin/login/passkey/options").then(function (options) {
+            options.challenge = decode(options.challenge);
+            options.allowCredentials = descriptors(options.allowCredentials);
+            return navigator.credentials.get({ publicKey: options });
+        }).then(function (credential) {
+            return post("/admin/login/passkey", {
+                next: form.elements.next.value,
+                credential: {
+                    id: credential.id,
+                    clientDataJSON: encode(credential.response.clientDataJSON),
+                    authenticatorData: encode(credential.response.authenticatorData),
+                    signature: encode(credential.response.signature),
+                    userHandle: credential.response.userHandle ? encode(credential.response.userHandle) : ""
+                }
+            });
+        });
+    }
+
+    var ceremonies = { register: register, login: login };
+
+    document.querySelectorAll("form[data-passkey]").forEach(function (form) {
+        var error = form.querySelector("[data-passkey-error]");
+        if (!window.PublicKeyCredential) {
+            error.textContent = "This browser doesn't support passkeys.";
+            form.querySelector("button").disabled = true;
+            return;
+        }
+        form.addEventListener("submit", function (event) {
+            event.preventDefault();
+            error.textContent = "";
+            ceremonies[form.getAttribute("data-passkey")](form).then(function (result) {
+                window.location.assign(result.redirect);
+            }).catch(function (err) {
+                // the browser rejects with NotAllowedError when the prompt is dismissed.
+                error.textContent = err.name === "NotAllowedError" ? "The passkey prompt was closed." : err.message;
+            });
+        });
+    });
+})();
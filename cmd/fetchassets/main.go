@@ -0,0 +1,68 @@
+// fetchassets downloads the third party assets listed in the vendored asset manifest,
+// verifies them against their integrity hashes, and writes them under `_static/vendor`.
+//
+// Usage:
+//	go run cmd/fetchassets/main.go [manifest path]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/request"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/assets"
+)
+
+func main() {
+	flag.Parse()
+	manifestPath := assets.DefaultManifestPath
+	if flag.NArg() > 0 {
+		manifestPath = flag.Arg(0)
+	}
+
+	manifest, err := assets.ReadManifest(manifestPath)
+	if err != nil {
+		fatal(err)
+	}
+
+	for _, asset := range manifest.Assets {
+		path := manifest.FilePath(asset)
+		if contents, err := ioutil.ReadFile(path); err == nil {
+			if assets.VerifyIntegrity(asset.Integrity, contents) == nil {
+				fmt.Printf("%s@%s: up to date\n", asset.Package, asset.Version)
+				continue
+			}
+		}
+		if err := fetch(asset, path); err != nil {
+			fatal(err)
+		}
+		fmt.Printf("%s@%s: fetched %s\n", asset.Package, asset.Version, asset.Path)
+	}
+}
+
+func fetch(asset assets.Asset, path string) error {
+	contents, meta, err := request.Get(asset.Source).BytesWithMeta()
+	if err != nil {
+		return err
+	}
+	if meta.StatusCode != 200 {
+		return exception.New("non-200 fetching asset").WithMessagef("source: %s, status: %d", asset.Source, meta.StatusCode)
+	}
+	if err := assets.VerifyIntegrity(asset.Integrity, contents); err != nil {
+		return exception.New(err).WithMessagef("source: %s", asset.Source)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return exception.New(err)
+	}
+	return exception.New(ioutil.WriteFile(path, contents, 0644))
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "%+v\n", err)
+	os.Exit(1)
+}
@@ -0,0 +1,66 @@
+// rotatekeys re-encrypts the encrypted columns of every registered type with the primary key,
+// in batches, so old keys can be removed from the config once it finishes.
+//
+// It reads the same config as the site (`db` and `pii`), so add the new key, make it the
+// primary key and deploy before running it. Types are registered with `pii.Register` by the
+// packages that define them, which need to be imported here.
+//
+// Usage:
+//	go run cmd/rotatekeys/main.go [-batch-size 500]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/blend/go-sdk/configutil"
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+
+	// the sqlite driver, used when the db dialect is `sqlite`.
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/config"
+	"github.com/wcharczuk/katwillmarry.com/pkg/pii"
+)
+
+func main() {
+	batchSize := flag.Int("batch-size", pii.DefaultBatchSize, "the number of rows to re-encrypt per transaction")
+	flag.Parse()
+
+	var cfg config.Config
+	if err := configutil.Read(&cfg); !configutil.IsIgnored(err) {
+		fatal(err)
+	}
+	if cfg.PII.IsZero() {
+		fatal(exception.New("no encryption keys are configured (`PII_KEYS`)"))
+	}
+	keyring, err := pii.NewKeyringFromConfig(&cfg.PII)
+	if err != nil {
+		fatal(err)
+	}
+
+	conn := db.NewFromConfig(&cfg.DB).WithEncryptor(keyring)
+	if _, err := conn.Open(); err != nil {
+		fatal(err)
+	}
+	defer conn.Close()
+
+	if len(pii.Registered()) == 0 {
+		fmt.Println("no types with encrypted columns are registered")
+		return
+	}
+	rotated, err := pii.RotateAll(conn, keyring, *batchSize)
+	for table, count := range rotated {
+		fmt.Printf("%s: re-encrypted %d rows with %s\n", table, count, keyring.Primary())
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "%+v\n", err)
+	os.Exit(1)
+}
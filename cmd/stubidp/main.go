@@ -0,0 +1,38 @@
+// stubidp runs a stub openid connect provider for logging in to the admin site locally, without
+// a real provider. It signs in anyone who's sent to it, as `admin@example.com` by default.
+//
+// Point the site at it with:
+//
+//	OAUTH_ISSUER=http://localhost:5556 OAUTH_CLIENT_ID=local OAUTH_CLIENT_SECRET=local
+//
+// and give the user a role, ex. `ADMIN_OWNERS=admin@example.com`.
+//
+// Usage:
+//
+//	go run cmd/stubidp/main.go [-bind-addr :5556] [-email admin@example.com]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/blend/go-sdk/oauth"
+)
+
+func main() {
+	bindAddr := flag.String("bind-addr", ":5556", "the address to listen on")
+	email := flag.String("email", "admin@example.com", "the email of the identity to sign in as")
+	flag.Parse()
+
+	provider := oauth.NewStubProvider().WithClaims(oauth.Values{
+		oauth.ClaimSubject: *email,
+		oauth.ClaimEmail:   *email,
+	})
+	fmt.Printf("stub openid connect provider listening on %s, signing in as %s\n", *bindAddr, *email)
+	if err := http.ListenAndServe(*bindAddr, provider); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
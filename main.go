@@ -1,15 +1,48 @@
+// The third party front-end assets aren't committed; `go generate` fetches them into
+// `_static/vendor` before a build.
+//go:generate go run cmd/fetchassets/main.go
+
 package main
 
 import (
+	"context"
+	"net/http"
 	"os"
-	"os/signal"
+	"strings"
+	"time"
 
 	"github.com/blend/go-sdk/configutil"
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
 	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/oauth"
+	"github.com/blend/go-sdk/util"
 	"github.com/blend/go-sdk/web"
 
+	// the sqlite driver, used when the db dialect is `sqlite`.
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/api"
+	"github.com/wcharczuk/katwillmarry.com/pkg/assets"
+	"github.com/wcharczuk/katwillmarry.com/pkg/audit"
 	"github.com/wcharczuk/katwillmarry.com/pkg/config"
 	"github.com/wcharczuk/katwillmarry.com/pkg/controller"
+	"github.com/wcharczuk/katwillmarry.com/pkg/gate"
+	"github.com/wcharczuk/katwillmarry.com/pkg/health"
+	"github.com/wcharczuk/katwillmarry.com/pkg/job"
+	"github.com/wcharczuk/katwillmarry.com/pkg/lifecycle"
+	"github.com/wcharczuk/katwillmarry.com/pkg/magiclink"
+	"github.com/wcharczuk/katwillmarry.com/pkg/metrics"
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/notify"
+	"github.com/wcharczuk/katwillmarry.com/pkg/passkey"
+	"github.com/wcharczuk/katwillmarry.com/pkg/pii"
+	"github.com/wcharczuk/katwillmarry.com/pkg/rbac"
+	"github.com/wcharczuk/katwillmarry.com/pkg/reminder"
+	"github.com/wcharczuk/katwillmarry.com/pkg/schema"
+	"github.com/wcharczuk/katwillmarry.com/pkg/security"
+	"github.com/wcharczuk/katwillmarry.com/pkg/session"
+	"github.com/wcharczuk/katwillmarry.com/pkg/softdelete"
 )
 
 func main() {
@@ -20,32 +53,170 @@ func main() {
 
 	log := logger.NewFromConfig(&cfg.Logger)
 
-	/*
-		// uncomment when we need oauth ...
-		auth, err := oauth.NewFromConfig(&cfg.OAuth)
-		if err != nil {
+	collector := metrics.New()
+	collector.Listen(log)
+
+	// admins log in with an openid connect provider once one is configured; `cmd/stubidp` runs
+	// one locally. the login state is signed with the auth secret unless oauth has its own.
+	var auth *oauth.Manager
+	if !cfg.OAuth.IsZero() {
+		var err error
+		if auth, err = oauth.NewFromConfig(&cfg.OAuth); err != nil {
+			logger.FatalExit(err)
+		}
+		if len(auth.Secret()) == 0 {
+			secret := cfg.Web.GetAuthSecret()
+			if len(secret) == 0 {
+				secret = util.Crypto.MustCreateKey(64)
+			}
+			auth.WithSecret(secret)
+		}
+		if len(auth.RedirectURI()) == 0 {
+			auth.WithRedirectURI(strings.TrimSuffix(cfg.Web.GetBaseURL(), "/") + controller.OAuthCallbackPath)
+		}
+	}
+
+	manifest, err := assets.ReadManifest(assets.DefaultManifestPath)
+	if err != nil {
+		logger.FatalExit(err)
+	}
+	// pages are unstyled and their scripts are blocked without the vendored assets, so don't serve them.
+	if err := manifest.Verify(); err != nil {
+		logger.FatalExit(err)
+	}
+
+	// the connection is opened lazily on first use.
+	// guests' contact details are encrypted, so they can't be written without keys.
+	if cfg.PII.IsZero() {
+		logger.FatalExit(exception.New(pii.ErrNoPrimaryKey).WithMessagef("no encryption keys are configured (`PII_KEYS`)"))
+	}
+	keyring, err := pii.NewKeyringFromConfig(&cfg.PII)
+	if err != nil {
+		logger.FatalExit(err)
+	}
+	conn := db.NewFromConfig(&cfg.DB).WithLogger(log).WithTracker(audit.Tracker{}).WithEncryptor(keyring)
+
+	reminders, err := reminder.NewSchedulerFromConfig(conn, &cfg.Reminder)
+	if err != nil {
+		logger.FatalExit(err)
+	}
+	reminders.WithLogger(log)
+
+	// sessions are kept in the database so logins survive restarts and are shared between replicas.
+	sessions := session.NewStoreFromConfig(conn, &cfg.Session).WithLogger(log)
+	// admin roles are read as each session is verified, so changes apply on the next request.
+	roles := rbac.NewStoreFromConfig(conn, &cfg.RBAC)
+	// admins can also log in with passkeys once the site's url is configured, so the admin site
+	// works when the openid connect provider doesn't, ex. at the venue.
+	var passkeys *passkey.Manager
+	if len(cfg.Passkey.GetOrigin(cfg.Web.GetBaseURL())) > 0 {
+		if passkeys, err = passkey.NewManagerFromConfig(conn, &cfg.Passkey, cfg.Web.GetBaseURL()); err != nil {
 			logger.FatalExit(err)
 		}
-	*/
+	}
+
+	// the password gate is off until a passphrase is configured; default middleware listed later
+	// runs first, so the security headers are still set on the gate's redirects, and posts from
+	// other sites are refused before they reach the gate or the forms.
+	siteGate := gate.NewFromConfig(&cfg.Gate, cfg.Web.GetAuthSecret())
 
 	app := web.NewFromConfig(&cfg.Web)
 	app.WithLogger(log)
+	app.WithDefaultMiddleware(siteGate.Middleware, security.SameOrigin(cfg.Web.GetBaseURL()), security.Headers(&cfg.Security))
+	sessions.Attach(app.Auth())
+	roles.Attach(app.Auth())
+	manifest.AddViewFuncs(app.Views())
 	app.Register(&controller.Index{Log: log})
+	app.Register(&controller.Login{Log: log, OAuth: auth, Passkeys: passkeys != nil})
+	app.Register(&controller.Passkeys{Log: log, Passkeys: passkeys})
+	app.Register(&controller.Gate{Log: log, Gate: siteGate})
+	app.Register(&controller.Sessions{Log: log, Store: sessions})
+	app.Register(&controller.Jobs{Log: log, DB: conn})
+	app.Register(&controller.Reminders{Log: log, DB: conn, Scheduler: reminders})
+	app.Register(&controller.Tokens{Log: log, DB: conn})
+	app.Register(&controller.Roles{Log: log, Store: roles})
+	app.Register(&controller.Reports{Log: log, DB: conn})
+	app.Register(&controller.Trash{Log: log, DB: conn})
+	app.Register(&controller.History{Log: log, DB: conn})
+	app.Register(&api.API{Log: log, DB: conn})
+
+	// email is written to the log until there's an email provider; texts go out once twilio is configured.
+	channels := notify.NewRouter().WithChannel(notify.ChannelEmail, notify.NewLog(log))
+	if !cfg.Notify.Twilio.IsZero() {
+		twilio := notify.NewTwilioFromConfig(&cfg.Notify.Twilio)
+		if baseURL := cfg.Web.GetBaseURL(); len(baseURL) > 0 {
+			twilio.WithWebhookURL(strings.TrimSuffix(baseURL, "/") + "/webhooks/sms")
+		}
+		channels.WithChannel(notify.ChannelSMS, notify.NewSMS(twilio))
+		app.Register(&controller.SMS{Log: log, DB: conn, Receiver: twilio})
+	}
 
-	done := make(chan bool)
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt)
+	// login links are signed with the auth secret; without one they stop working on restart.
+	links := magiclink.NewManagerFromConfig(conn, &cfg.MagicLink, cfg.Web.GetAuthSecret())
+	app.Register(&controller.RSVP{Log: log, DB: conn, Links: links, Gate: siteGate})
 
-	go func() {
-		log.SyncFatalExit(app.Start())
-	}()
+	lc := lifecycle.NewFromConfig(&cfg.Lifecycle).WithLogger(log)
+
+	hz := web.NewHealthzFromConfig(app, &cfg.Healthz).WithLogger(log)
+	readiness := health.NewReadiness(
+		health.DB(conn),
+		health.Migrations(conn),
+		health.Directory("static", "_static"),
+		health.Directory("views", "_views"),
+	).WithDraining(lc.Draining)
 
+	queue := job.NewQueueFromConfig(conn, &cfg.Job).WithLogger(log).
+		WithHandler(reminder.Kind, reminder.Handler(conn, channels)).
+		// the emailed links start with the configured site url rather than the request's host,
+		// since anyone can set that and send a guest a link to another site.
+		WithHandler(magiclink.Kind, magiclink.Handler(links, channels, cfg.Web.GetBaseURL()))
+	purger := softdelete.NewPurgerFromConfig(conn, &cfg.SoftDelete).WithLogger(log).WithTypes(model.Types()...)
+
+	lc.OnShutdown("web", app.ShutdownContext)
+	lc.OnShutdown("healthz", hz.ShutdownContext)
+	lc.OnShutdown("reminders", reminders.Stop)
+	lc.OnShutdown("jobs", queue.Stop)
+	lc.OnShutdown("purger", purger.Stop)
+	lc.OnShutdown("sessions", sessions.Stop)
+	lc.OnShutdown("db", func(_ context.Context) error {
+		if conn.Connection() == nil {
+			return nil
+		}
+		return conn.Close()
+	})
+	lc.OnShutdown("logger", func(_ context.Context) error {
+		return log.Drain()
+	})
+
+	go applyMigrations(conn, lc)
+	queue.Start()
+	reminders.Start()
+	purger.Start()
+	sessions.Start()
 	go func() {
-		<-quit
-		if err := app.Shutdown(); err != nil {
-			log.SyncFatal(err)
+		if err := health.Host(app, hz, map[string]http.Handler{
+			health.RouteReadiness: readiness,
+			metrics.RouteMetrics:  collector,
+		}); err != nil && !exception.Is(err, http.ErrServerClosed) {
+			log.SyncFatalExit(err)
 		}
-		close(done)
 	}()
-	<-done
+
+	if err := lc.Wait(); err != nil {
+		log.SyncError(err)
+		os.Exit(1)
+	}
+}
+
+// applyMigrations applies schema migrations, retrying until the database is reachable.
+// Readiness reports unavailable until this succeeds.
+func applyMigrations(conn *db.Connection, lc *lifecycle.Manager) {
+	for !lc.Draining() {
+		err := schema.Apply(conn)
+		if err == nil {
+			return
+		}
+		conn.Logger().Warning(err)
+		time.Sleep(5 * time.Second)
+	}
 }
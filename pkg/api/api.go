@@ -0,0 +1,327 @@
+// Package api is the json api, served under `/api/v1`.
+//
+// Reads of the schedule are public, though they're behind the site gate when it's on; everything
+// else needs an api token, sent as `Authorization: Bearer <token>`, with the `read` scope for reads
+// and `write` for changes. Errors are returned as `ErrorResponse`, and lists are paginated by id
+// (see `page`).
+package api
+
+import (
+	"net/http"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/openapi"
+)
+
+const (
+	// Prefix is the path the api is served under.
+	Prefix = "/api/v1"
+	// Version is the api version.
+	Version = "v1"
+	// DocumentPath is the path the OpenAPI document is served at.
+	DocumentPath = "/api/openapi.json"
+)
+
+// API is the json api controller.
+type API struct {
+	Log *logger.Logger
+	DB  *db.Connection
+}
+
+// route is an api route and its documentation.
+type route struct {
+	method string
+	path   string
+	meta   web.RouteMeta
+	action web.Action
+}
+
+// routes returns the route table; paths are relative to `Prefix`.
+func (a API) routes() []route {
+	return []route{
+		{
+			method: "GET",
+			path:   "/events",
+			meta: web.RouteMeta{
+				Summary:  "List the events on the schedule",
+				Tags:     []string{"events"},
+				Response: EventList{},
+			},
+			action: a.listEvents,
+		},
+		{
+			method: "GET",
+			path:   "/events/:id",
+			meta: web.RouteMeta{
+				Summary:  "Get an event",
+				Tags:     []string{"events"},
+				Response: model.Event{},
+			},
+			action: a.getEvent,
+		},
+		{
+			method: "POST",
+			path:   "/events",
+			meta: web.RouteMeta{
+				Summary:  "Create an event",
+				Tags:     []string{"events"},
+				Secured:  true,
+				Request:  EventInput{},
+				Response: model.Event{},
+				Status:   http.StatusCreated,
+			},
+			action: a.createEvent,
+		},
+		{
+			method: "PUT",
+			path:   "/events/:id",
+			meta: web.RouteMeta{
+				Summary:  "Update an event",
+				Tags:     []string{"events"},
+				Secured:  true,
+				Request:  EventInput{},
+				Response: model.Event{},
+			},
+			action: a.updateEvent,
+		},
+		{
+			method: "DELETE",
+			path:   "/events/:id",
+			meta: web.RouteMeta{
+				Summary: "Delete an event",
+				Tags:    []string{"events"},
+				Secured: true,
+				Status:  http.StatusNoContent,
+			},
+			action: a.deleteEvent,
+		},
+
+		{
+			method: "GET",
+			path:   "/households",
+			meta: web.RouteMeta{
+				Summary:  "List households",
+				Tags:     []string{"households"},
+				Secured:  true,
+				Query:    pageParams,
+				Response: HouseholdPage{},
+			},
+			action: a.listHouseholds,
+		},
+		{
+			method: "GET",
+			path:   "/households/:id",
+			meta: web.RouteMeta{
+				Summary:  "Get a household",
+				Tags:     []string{"households"},
+				Secured:  true,
+				Response: model.Household{},
+			},
+			action: a.getHousehold,
+		},
+		{
+			method: "GET",
+			path:   "/households/:id/guests",
+			meta: web.RouteMeta{
+				Summary:  "List the guests in a household",
+				Tags:     []string{"households"},
+				Secured:  true,
+				Query:    pageParams,
+				Response: GuestPage{},
+			},
+			action: a.listHouseholdGuests,
+		},
+		{
+			method: "POST",
+			path:   "/households",
+			meta: web.RouteMeta{
+				Summary:  "Create a household",
+				Tags:     []string{"households"},
+				Secured:  true,
+				Request:  HouseholdInput{},
+				Response: model.Household{},
+				Status:   http.StatusCreated,
+			},
+			action: a.createHousehold,
+		},
+		{
+			method: "PUT",
+			path:   "/households/:id",
+			meta: web.RouteMeta{
+				Summary:  "Update a household",
+				Tags:     []string{"households"},
+				Secured:  true,
+				Request:  HouseholdInput{},
+				Response: model.Household{},
+			},
+			action: a.updateHousehold,
+		},
+		{
+			method: "DELETE",
+			path:   "/households/:id",
+			meta: web.RouteMeta{
+				Summary: "Delete a household",
+				Tags:    []string{"households"},
+				Secured: true,
+				Status:  http.StatusNoContent,
+			},
+			action: a.deleteHousehold,
+		},
+
+		{
+			method: "GET",
+			path:   "/guests",
+			meta: web.RouteMeta{
+				Summary:  "List guests",
+				Tags:     []string{"guests"},
+				Secured:  true,
+				Query:    append([]web.QueryParam{householdParam}, pageParams...),
+				Response: GuestPage{},
+			},
+			action: a.listGuests,
+		},
+		{
+			method: "GET",
+			path:   "/guests/:id",
+			meta: web.RouteMeta{
+				Summary:  "Get a guest",
+				Tags:     []string{"guests"},
+				Secured:  true,
+				Response: model.Guest{},
+			},
+			action: a.getGuest,
+		},
+		{
+			method: "POST",
+			path:   "/guests",
+			meta: web.RouteMeta{
+				Summary:  "Create a guest",
+				Tags:     []string{"guests"},
+				Secured:  true,
+				Request:  GuestInput{},
+				Response: model.Guest{},
+				Status:   http.StatusCreated,
+			},
+			action: a.createGuest,
+		},
+		{
+			method: "PUT",
+			path:   "/guests/:id",
+			meta: web.RouteMeta{
+				Summary:  "Update a guest",
+				Tags:     []string{"guests"},
+				Secured:  true,
+				Request:  GuestInput{},
+				Response: model.Guest{},
+			},
+			action: a.updateGuest,
+		},
+		{
+			method: "DELETE",
+			path:   "/guests/:id",
+			meta: web.RouteMeta{
+				Summary: "Delete a guest",
+				Tags:    []string{"guests"},
+				Secured: true,
+				Status:  http.StatusNoContent,
+			},
+			action: a.deleteGuest,
+		},
+
+		{
+			method: "GET",
+			path:   "/rsvps",
+			meta: web.RouteMeta{
+				Summary:  "List rsvps",
+				Tags:     []string{"rsvps"},
+				Secured:  true,
+				Query:    append([]web.QueryParam{householdParam, attendingParam}, pageParams...),
+				Response: RSVPPage{},
+			},
+			action: a.listRSVPs,
+		},
+		{
+			method: "GET",
+			path:   "/guests/:id/rsvp",
+			meta: web.RouteMeta{
+				Summary:  "Get a guest's rsvp",
+				Tags:     []string{"rsvps"},
+				Secured:  true,
+				Response: model.RSVP{},
+			},
+			action: a.getRSVP,
+		},
+		{
+			method: "PUT",
+			path:   "/guests/:id/rsvp",
+			meta: web.RouteMeta{
+				Summary:  "Set a guest's rsvp",
+				Tags:     []string{"rsvps"},
+				Secured:  true,
+				Request:  RSVPInput{},
+				Response: model.RSVP{},
+			},
+			action: a.setRSVP,
+		},
+		{
+			method: "DELETE",
+			path:   "/guests/:id/rsvp",
+			meta: web.RouteMeta{
+				Summary: "Clear a guest's rsvp",
+				Tags:    []string{"rsvps"},
+				Secured: true,
+				Status:  http.StatusNoContent,
+			},
+			action: a.deleteRSVP,
+		},
+	}
+}
+
+var (
+	householdParam = web.QueryParam{Name: "household_id", Type: "integer", Description: "Only items in the household."}
+	attendingParam = web.QueryParam{Name: "attending", Type: "boolean", Description: "Only rsvps that are (or aren't) attending."}
+)
+
+// Register adds routes for the controller.
+func (a API) Register(app *web.App) {
+	for _, r := range a.routes() {
+		var middleware []web.Middleware
+		if r.meta.Secured {
+			middleware = append(middleware, TokenRequired(a.DB, scopeFor(r.method)))
+		}
+		handle(app, r.method, Prefix+r.path, r.action, middleware...)
+		app.Describe(r.method, Prefix+r.path, r.meta)
+	}
+	app.GET(DocumentPath, func(ctx *web.Ctx) web.Result {
+		return ctx.JSON().Result(Document(app))
+	})
+}
+
+// Document returns the OpenAPI document for the described routes of an app.
+func Document(app *web.App) *openapi.Document {
+	return openapi.New("Kat Will Marry", Version).
+		WithErrorType(ErrorResponse{}).
+		Generate(app)
+}
+
+// handle registers an action for a method.
+func handle(app *web.App, method, path string, action web.Action, middleware ...web.Middleware) {
+	switch method {
+	case "GET":
+		app.GET(path, action, middleware...)
+	case "POST":
+		app.POST(path, action, middleware...)
+	case "PUT":
+		app.PUT(path, action, middleware...)
+	case "PATCH":
+		app.PATCH(path, action, middleware...)
+	case "DELETE":
+		app.DELETE(path, action, middleware...)
+	default:
+		panic("api: unsupported method " + method)
+	}
+}
@@ -0,0 +1,208 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/api"
+	"github.com/wcharczuk/katwillmarry.com/pkg/apitoken"
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/openapi"
+	"github.com/wcharczuk/katwillmarry.com/pkg/schema/schematest"
+)
+
+func TestTokenScopes(t *testing.T) {
+	conn := schematest.Open(t)
+	app := web.New()
+	app.Register(api.API{DB: conn})
+
+	read, _, err := apitoken.Create(conn, "owner@example.com", "reader", apitoken.ScopeRead)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	write, _, err := apitoken.Create(conn, "owner@example.com", "writer", apitoken.ScopeWrite)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if _, _, err := apitoken.Create(conn, "owner@example.com", "admin", "admin"); err == nil {
+		t.Fatal("creating a token with an unknown scope should fail")
+	}
+
+	testCases := []struct {
+		name     string
+		secret   string
+		method   string
+		expected int
+	}{
+		{name: "no token", method: "GET", expected: http.StatusUnauthorized},
+		{name: "read token reads", secret: read, method: "GET", expected: http.StatusOK},
+		{name: "read token writes", secret: read, method: "POST", expected: http.StatusForbidden},
+		{name: "write token reads", secret: write, method: "GET", expected: http.StatusOK},
+		{name: "write token writes", secret: write, method: "POST", expected: http.StatusCreated},
+	}
+	for _, tc := range testCases {
+		req := web.NewMockRequestBuilder(app).WithVerb(tc.method).WithPathf(api.Prefix + "/households")
+		if tc.method == "POST" {
+			req = req.WithPostBodyAsJSON(api.HouseholdInput{Name: "The Smiths"})
+		}
+		if len(tc.secret) > 0 {
+			req = req.WithHeader("Authorization", "Bearer "+tc.secret)
+		}
+		var response interface{}
+		meta, err := req.JSONWithMeta(&response)
+		if err != nil {
+			t.Fatalf("%s: %+v", tc.name, err)
+		}
+		if meta.StatusCode != tc.expected {
+			t.Fatalf("%s: expected status %d, got %d: %v", tc.name, tc.expected, meta.StatusCode, response)
+		}
+	}
+}
+
+func TestListHouseholdGuestsPages(t *testing.T) {
+	conn := schematest.Open(t)
+	app := web.New()
+	app.Register(api.API{DB: conn})
+	secret, _, err := apitoken.Create(conn, "owner@example.com", "reader", apitoken.ScopeRead)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	now := time.Now().UTC()
+	household := model.Household{Name: "The Smiths", CreatedUTC: now, UpdatedUTC: now}
+	if err := conn.Invoke().Create(&household); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		if err := conn.Invoke().Create(&model.Guest{HouseholdID: household.ID, Name: name, CreatedUTC: now, UpdatedUTC: now}); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+
+	var names []string
+	var after *int64
+	for pages := 0; pages < 3; pages++ {
+		req := web.NewMockRequestBuilder(app).
+			Get("%s/households/%d/guests", api.Prefix, household.ID).
+			WithQueryString("limit", "2").
+			WithHeader("Authorization", "Bearer "+secret)
+		if after != nil {
+			req = req.WithQueryString("after", fmt.Sprint(*after))
+		}
+		var page api.GuestPage
+		if err := req.JSON(&page); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if len(page.Items) > 2 {
+			t.Fatalf("a page should have at most the limit, got %d", len(page.Items))
+		}
+		for _, guest := range page.Items {
+			names = append(names, guest.Name)
+		}
+		if after = page.Next; after == nil {
+			break
+		}
+	}
+	if fmt.Sprint(names) != "[Alice Bob Carol]" {
+		t.Fatalf("paging through the guests returned %v", names)
+	}
+}
+
+func TestDocument(t *testing.T) {
+	app := web.New()
+	app.Register(api.API{})
+
+	var doc openapi.Document
+	if err := web.NewMockRequestBuilder(app).Get(api.DocumentPath).JSON(&doc); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if doc.OpenAPI != openapi.Version || doc.Info.Version != api.Version {
+		t.Fatalf("unexpected document header %q %+v", doc.OpenAPI, doc.Info)
+	}
+
+	expected := map[string][]string{
+		"/events":                 {"get", "post"},
+		"/events/{id}":            {"get", "put", "delete"},
+		"/households":             {"get", "post"},
+		"/households/{id}":        {"get", "put", "delete"},
+		"/households/{id}/guests": {"get"},
+		"/guests":                 {"get", "post"},
+		"/guests/{id}":            {"get", "put", "delete"},
+		"/guests/{id}/rsvp":       {"get", "put", "delete"},
+		"/rsvps":                  {"get"},
+	}
+	if len(doc.Paths) != len(expected) {
+		t.Fatalf("want %d documented paths, got %d", len(expected), len(doc.Paths))
+	}
+	for path, methods := range expected {
+		operations := doc.Paths[api.Prefix+path]
+		if len(operations) != len(methods) {
+			t.Fatalf("%s: want %v, got %v", path, methods, operations)
+		}
+		for _, method := range methods {
+			op := operations[method]
+			if op == nil {
+				t.Fatalf("%s %s isn't documented", method, path)
+			}
+			// only reading the schedule is public.
+			public := strings.HasPrefix(path, "/events") && method == "get"
+			if secured := len(op.Security) == 1 && op.Security[0][openapi.SecuritySchemeToken] != nil; secured == public {
+				t.Fatalf("%s %s: secured should be %v, got %+v", method, path, !public, op.Security)
+			}
+			if strings.Contains(path, "{id}") {
+				if len(op.Parameters) == 0 || op.Parameters[0].Name != "id" || op.Parameters[0].In != "path" || !op.Parameters[0].Required || op.Parameters[0].Schema.Type != "integer" {
+					t.Fatalf("%s %s: want a required integer id path parameter, got %+v", method, path, op.Parameters)
+				}
+			}
+			if op.Responses["default"] == nil || op.Responses["default"].Content["application/json"].Schema.Ref != "#/components/schemas/ErrorResponse" {
+				t.Fatalf("%s %s: errors should be documented as ErrorResponse, got %+v", method, path, op.Responses["default"])
+			}
+		}
+	}
+	if scheme := doc.Components.SecuritySchemes[openapi.SecuritySchemeToken]; scheme == nil || scheme.Type != "http" || scheme.Scheme != "bearer" {
+		t.Fatalf("want a bearer token security scheme, got %+v", scheme)
+	}
+
+	guests := doc.Paths[api.Prefix+"/guests"]["get"]
+	var query []string
+	for _, param := range guests.Parameters {
+		if param.In == "query" {
+			query = append(query, param.Name+":"+param.Schema.Type)
+		}
+	}
+	if fmt.Sprint(query) != "[household_id:integer limit:integer after:integer]" {
+		t.Fatalf("unexpected guest list query parameters %v", query)
+	}
+
+	create := doc.Paths[api.Prefix+"/households"]["post"]
+	if create.RequestBody == nil || !create.RequestBody.Required || create.RequestBody.Content["application/json"].Schema.Ref != "#/components/schemas/HouseholdInput" {
+		t.Fatalf("creating a household should take a HouseholdInput, got %+v", create.RequestBody)
+	}
+	if created := create.Responses["201"]; created == nil || created.Content["application/json"].Schema.Ref != "#/components/schemas/Household" {
+		t.Fatalf("creating a household should return a Household with 201, got %+v", create.Responses)
+	}
+	if deleted := doc.Paths[api.Prefix+"/households/{id}"]["delete"].Responses["204"]; deleted == nil || len(deleted.Content) > 0 {
+		t.Fatalf("deleting a household should return 204 without a body, got %+v", deleted)
+	}
+
+	input := doc.Components.Schemas["HouseholdInput"]
+	if input == nil || fmt.Sprint(input.Required) != "[name]" || input.Properties["name"].Type != "string" {
+		t.Fatalf("unexpected HouseholdInput schema %+v", input)
+	}
+	if version := input.Properties["version"]; version == nil || version.Type != "integer" || version.Format != "int64" || !version.Nullable {
+		t.Fatalf("the input version should be a nullable int64, got %+v", version)
+	}
+	page := doc.Components.Schemas["HouseholdPage"]
+	if page == nil || page.Properties["items"].Type != "array" || page.Properties["items"].Items.Ref != "#/components/schemas/Household" {
+		t.Fatalf("unexpected HouseholdPage schema %+v", page)
+	}
+	household := doc.Components.Schemas["Household"]
+	if household == nil || household.Properties["createdUTC"].Format != "date-time" || household.Properties["id"].Format != "int64" {
+		t.Fatalf("unexpected Household schema %+v", household)
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/apitoken"
+)
+
+const (
+	// stateKeyToken is the ctx state key for the request's api token.
+	stateKeyToken = "api_token"
+)
+
+// TokenRequired returns middleware that requires an `Authorization: Bearer <token>` header with a valid api token
+// whose scope allows the request, ex. `apitoken.ScopeWrite` for changes.
+func TokenRequired(conn *db.Connection, scope string) web.Middleware {
+	return func(action web.Action) web.Action {
+		return func(ctx *web.Ctx) web.Result {
+			header := ctx.Request().Header.Get("Authorization")
+			if !strings.HasPrefix(header, "Bearer ") {
+				return unauthorized()
+			}
+			token, err := apitoken.Authenticate(conn, strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")), web.Tx(ctx))
+			if exception.Is(err, apitoken.ErrInvalidToken) {
+				return unauthorized()
+			}
+			if err != nil {
+				return internalError(ctx, err)
+			}
+			if !token.Allows(scope) {
+				return forbidden(scope)
+			}
+			ctx.WithStateValue(stateKeyToken, token)
+			return action(ctx)
+		}
+	}
+}
+
+// scopeFor returns the token scope a request method needs: reads need `read`, everything else `write`.
+func scopeFor(method string) string {
+	if method == "GET" {
+		return apitoken.ScopeRead
+	}
+	return apitoken.ScopeWrite
+}
+
+// principal returns the audit principal for the request's api token.
+func principal(ctx *web.Ctx) string {
+	if token, ok := ctx.StateValue(stateKeyToken).(*apitoken.Token); ok {
+		return token.Principal()
+	}
+	return ""
+}
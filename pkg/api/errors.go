@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/web"
+)
+
+const (
+	// CodeBadRequest is the error code for a request that couldn't be read, ex. malformed json.
+	CodeBadRequest = "bad_request"
+	// CodeInvalid is the error code for a request with invalid fields.
+	CodeInvalid = "invalid"
+	// CodeUnauthorized is the error code for a missing or invalid api token.
+	CodeUnauthorized = "unauthorized"
+	// CodeForbidden is the error code for an api token whose scope doesn't allow the request.
+	CodeForbidden = "forbidden"
+	// CodeNotFound is the error code for a resource that doesn't exist.
+	CodeNotFound = "not_found"
+	// CodeConflict is the error code for an update to a stale version.
+	CodeConflict = "conflict"
+	// CodeInternal is the error code for everything else.
+	CodeInternal = "internal"
+)
+
+// ErrorResponse is the body of every error response.
+type ErrorResponse struct {
+	Error Error `json:"error"`
+}
+
+// Error is an api error.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Fields are the invalid fields and what's wrong with them, for `invalid` errors.
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// errorResult returns an error response.
+func errorResult(statusCode int, code, message string) web.Result {
+	return &web.JSONResult{
+		StatusCode: statusCode,
+		Response:   ErrorResponse{Error: Error{Code: code, Message: message}},
+	}
+}
+
+// badRequest returns an error response for a body that couldn't be read.
+func badRequest(err error) web.Result {
+	return errorResult(http.StatusBadRequest, CodeBadRequest, "the body must be a json object: "+err.Error())
+}
+
+// invalid returns an error response for invalid fields.
+func invalid(fields map[string]string) web.Result {
+	return &web.JSONResult{
+		StatusCode: http.StatusUnprocessableEntity,
+		Response:   ErrorResponse{Error: Error{Code: CodeInvalid, Message: "the request has invalid fields", Fields: fields}},
+	}
+}
+
+// unauthorized returns an error response for a missing or invalid api token.
+func unauthorized() web.Result {
+	return errorResult(http.StatusUnauthorized, CodeUnauthorized, "a valid api token is required")
+}
+
+// forbidden returns an error response for an api token without the scope a request needs.
+func forbidden(scope string) web.Result {
+	return errorResult(http.StatusForbidden, CodeForbidden, "the api token needs the `"+scope+"` scope")
+}
+
+// notFound returns an error response for a resource that doesn't exist.
+func notFound() web.Result {
+	return errorResult(http.StatusNotFound, CodeNotFound, "not found")
+}
+
+// internalError logs an error and returns a response that doesn't leak it; version conflicts are reported as such.
+func internalError(ctx *web.Ctx, err error) web.Result {
+	if db.IsVersionConflict(err) {
+		return errorResult(http.StatusConflict, CodeConflict, "the resource was changed by someone else; reload it, merge your changes and try again")
+	}
+	if log := ctx.Logger(); log != nil {
+		log.Error(err)
+	}
+	return errorResult(http.StatusInternalServerError, CodeInternal, "internal server error")
+}
+
+// created returns a 201 response.
+func created(response interface{}) web.Result {
+	return &web.JSONResult{StatusCode: http.StatusCreated, Response: response}
+}
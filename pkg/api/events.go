@@ -0,0 +1,130 @@
+package api
+
+import (
+	"strings"
+	"time"
+
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/audit"
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/query"
+)
+
+// EventList is the schedule of events. It isn't paginated; there are only ever a handful.
+type EventList struct {
+	Items []model.Event `json:"items"`
+}
+
+// EventInput is the body for creating or updating an event.
+type EventInput struct {
+	Name        string     `json:"name" validate:"required,max=255"`
+	Description string     `json:"description,omitempty"`
+	Location    string     `json:"location,omitempty"`
+	StartsUTC   time.Time  `json:"startsUTC" validate:"required"`
+	EndsUTC     *time.Time `json:"endsUTC,omitempty"`
+	// Version is the version the update was made against; if it's set and the event
+	// has changed since, the update fails with a conflict.
+	Version *int64 `json:"version,omitempty"`
+}
+
+func (ei *EventInput) check(fields web.FieldErrors) {
+	if ei.EndsUTC != nil && ei.EndsUTC.Before(ei.StartsUTC) {
+		fields.Add("endsUTC", "must be after startsUTC")
+	}
+}
+
+func (ei EventInput) apply(event *model.Event) {
+	event.Name = strings.TrimSpace(ei.Name)
+	event.Description = ei.Description
+	event.Location = ei.Location
+	event.StartsUTC = ei.StartsUTC.UTC()
+	event.EndsUTC = nil
+	if ei.EndsUTC != nil {
+		ends := ei.EndsUTC.UTC()
+		event.EndsUTC = &ends
+	}
+	if ei.Version != nil {
+		event.Version = *ei.Version
+	}
+}
+
+// listEvents handles `GET /api/v1/events`
+func (a API) listEvents(ctx *web.Ctx) web.Result {
+	events := []model.Event{}
+	if err := query.Select(model.Event{}).OrderBy(query.Asc("starts_utc"), query.Asc("id")).OutMany(a.DB, &events, web.Tx(ctx)); err != nil {
+		return internalError(ctx, err)
+	}
+	return ctx.JSON().Result(EventList{Items: events})
+}
+
+// getEvent handles `GET /api/v1/events/:id`
+func (a API) getEvent(ctx *web.Ctx) web.Result {
+	event, result := a.event(ctx)
+	if result != nil {
+		return result
+	}
+	return ctx.JSON().Result(event)
+}
+
+// createEvent handles `POST /api/v1/events`
+func (a API) createEvent(ctx *web.Ctx) web.Result {
+	var input EventInput
+	if result := bind(ctx, &input); result != nil {
+		return result
+	}
+	now := time.Now().UTC()
+	event := model.Event{CreatedUTC: now, UpdatedUTC: now}
+	input.apply(&event)
+	event.Version = 0
+	if err := audit.Invoke(a.DB, principal(ctx), web.Tx(ctx)).Create(&event); err != nil {
+		return internalError(ctx, err)
+	}
+	return created(event)
+}
+
+// updateEvent handles `PUT /api/v1/events/:id`
+func (a API) updateEvent(ctx *web.Ctx) web.Result {
+	event, result := a.event(ctx)
+	if result != nil {
+		return result
+	}
+	var input EventInput
+	if result := bind(ctx, &input); result != nil {
+		return result
+	}
+	input.apply(event)
+	event.UpdatedUTC = time.Now().UTC()
+	if err := audit.Invoke(a.DB, principal(ctx), web.Tx(ctx)).Update(event); err != nil {
+		return internalError(ctx, err)
+	}
+	return ctx.JSON().Result(event)
+}
+
+// deleteEvent handles `DELETE /api/v1/events/:id`
+func (a API) deleteEvent(ctx *web.Ctx) web.Result {
+	event, result := a.event(ctx)
+	if result != nil {
+		return result
+	}
+	if err := audit.Invoke(a.DB, principal(ctx), web.Tx(ctx)).Delete(event); err != nil {
+		return internalError(ctx, err)
+	}
+	return ctx.NoContent()
+}
+
+// event reads the event in the route, or returns the error result.
+func (a API) event(ctx *web.Ctx) (*model.Event, web.Result) {
+	id, err := ctx.RouteParamInt64("id")
+	if err != nil {
+		return nil, notFound()
+	}
+	var event model.Event
+	if err := a.DB.Invoke(web.Tx(ctx)).Get(&event, id); err != nil {
+		return nil, internalError(ctx, err)
+	}
+	if event.ID == 0 {
+		return nil, notFound()
+	}
+	return &event, nil
+}
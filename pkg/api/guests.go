@@ -0,0 +1,163 @@
+package api
+
+import (
+	"strings"
+	"time"
+
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/audit"
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/query"
+)
+
+// GuestPage is a page of guests.
+type GuestPage struct {
+	Items []model.Guest `json:"items"`
+	Next  *int64        `json:"next,omitempty"`
+}
+
+// GuestInput is the body for creating or updating a guest.
+type GuestInput struct {
+	HouseholdID  int64  `json:"householdID" validate:"required"`
+	Name         string `json:"name" validate:"required,max=255"`
+	Email        string `json:"email,omitempty" validate:"email,max=255"`
+	Phone        string `json:"phone,omitempty"`
+	DietaryNotes string `json:"dietaryNotes,omitempty"`
+	// NotifyBy is `email` (the default), `sms` or `both`.
+	NotifyBy string `json:"notifyBy,omitempty" validate:"oneof=email sms both"`
+	// Version is the version the update was made against; if it's set and the guest
+	// has changed since, the update fails with a conflict.
+	Version *int64 `json:"version,omitempty"`
+}
+
+func (gi GuestInput) apply(guest *model.Guest) {
+	guest.HouseholdID = gi.HouseholdID
+	guest.Name = strings.TrimSpace(gi.Name)
+	guest.Email = strings.TrimSpace(gi.Email)
+	guest.Phone = gi.Phone
+	guest.DietaryNotes = gi.DietaryNotes
+	guest.NotifyBy = gi.NotifyBy
+	if gi.Version != nil {
+		guest.Version = *gi.Version
+	}
+}
+
+// guestFilter is the query of `GET /api/v1/guests`.
+type guestFilter struct {
+	HouseholdID *int64 `query:"household_id"`
+}
+
+// listGuests handles `GET /api/v1/guests?household_id=`
+func (a API) listGuests(ctx *web.Ctx) web.Result {
+	var filter guestFilter
+	if result := bind(ctx, &filter); result != nil {
+		return result
+	}
+	p, fields := readPage(ctx)
+	if fields != nil {
+		return invalid(fields)
+	}
+	b := query.Select(model.Guest{})
+	if filter.HouseholdID != nil {
+		b = b.Where(query.Eq("household_id", *filter.HouseholdID))
+	}
+	guests := []model.Guest{}
+	if err := p.query(b, "id").OutMany(a.DB, &guests, web.Tx(ctx)); err != nil {
+		return internalError(ctx, err)
+	}
+	count, next := p.next(len(guests), func(index int) int64 { return guests[index].ID })
+	return ctx.JSON().Result(GuestPage{Items: guests[:count], Next: next})
+}
+
+// getGuest handles `GET /api/v1/guests/:id`
+func (a API) getGuest(ctx *web.Ctx) web.Result {
+	guest, result := a.guest(ctx)
+	if result != nil {
+		return result
+	}
+	return ctx.JSON().Result(guest)
+}
+
+// createGuest handles `POST /api/v1/guests`
+func (a API) createGuest(ctx *web.Ctx) web.Result {
+	var input GuestInput
+	if result := a.bindGuest(ctx, &input); result != nil {
+		return result
+	}
+	now := time.Now().UTC()
+	guest := model.Guest{CreatedUTC: now, UpdatedUTC: now}
+	input.apply(&guest)
+	guest.Version = 0
+	if err := audit.Invoke(a.DB, principal(ctx), web.Tx(ctx)).Create(&guest); err != nil {
+		return internalError(ctx, err)
+	}
+	return created(guest)
+}
+
+// updateGuest handles `PUT /api/v1/guests/:id`
+func (a API) updateGuest(ctx *web.Ctx) web.Result {
+	guest, result := a.guest(ctx)
+	if result != nil {
+		return result
+	}
+	var input GuestInput
+	if result := a.bindGuest(ctx, &input); result != nil {
+		return result
+	}
+	input.apply(guest)
+	guest.UpdatedUTC = time.Now().UTC()
+	if err := audit.Invoke(a.DB, principal(ctx), web.Tx(ctx)).Update(guest); err != nil {
+		return internalError(ctx, err)
+	}
+	return ctx.JSON().Result(guest)
+}
+
+// deleteGuest handles `DELETE /api/v1/guests/:id`
+func (a API) deleteGuest(ctx *web.Ctx) web.Result {
+	guest, result := a.guest(ctx)
+	if result != nil {
+		return result
+	}
+	if err := audit.Invoke(a.DB, principal(ctx), web.Tx(ctx)).Delete(guest); err != nil {
+		return internalError(ctx, err)
+	}
+	return ctx.NoContent()
+}
+
+// bindGuest reads and checks a guest body, including that its household exists.
+func (a API) bindGuest(ctx *web.Ctx, input *GuestInput) web.Result {
+	fields, result := bindFields(ctx, input)
+	if result != nil {
+		return result
+	}
+	if _, ok := fields["householdID"]; !ok {
+		var household model.Household
+		if err := a.DB.Invoke(web.Tx(ctx)).Get(&household, input.HouseholdID); err != nil {
+			return internalError(ctx, err)
+		}
+		if household.ID == 0 {
+			fields.Add("householdID", "must be an existing household")
+		}
+	}
+	if len(fields) > 0 {
+		return invalid(fields)
+	}
+	return nil
+}
+
+// guest reads the guest in the route, or returns the error result.
+func (a API) guest(ctx *web.Ctx) (*model.Guest, web.Result) {
+	id, err := ctx.RouteParamInt64("id")
+	if err != nil {
+		return nil, notFound()
+	}
+	var guest model.Guest
+	if err := a.DB.Invoke(web.Tx(ctx)).Get(&guest, id); err != nil {
+		return nil, internalError(ctx, err)
+	}
+	if guest.ID == 0 {
+		return nil, notFound()
+	}
+	return &guest, nil
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"strings"
+	"time"
+
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/audit"
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/query"
+)
+
+// HouseholdPage is a page of households.
+type HouseholdPage struct {
+	Items []model.Household `json:"items"`
+	Next  *int64            `json:"next,omitempty"`
+}
+
+// HouseholdInput is the body for creating or updating a household.
+type HouseholdInput struct {
+	Name    string `json:"name" validate:"required,max=255"`
+	Address string `json:"address,omitempty"`
+	// Version is the version the update was made against; if it's set and the household
+	// has changed since, the update fails with a conflict.
+	Version *int64 `json:"version,omitempty"`
+}
+
+func (hi HouseholdInput) apply(household *model.Household) {
+	household.Name = strings.TrimSpace(hi.Name)
+	household.Address = hi.Address
+	if hi.Version != nil {
+		household.Version = *hi.Version
+	}
+}
+
+// listHouseholds handles `GET /api/v1/households`
+func (a API) listHouseholds(ctx *web.Ctx) web.Result {
+	p, fields := readPage(ctx)
+	if fields != nil {
+		return invalid(fields)
+	}
+	households := []model.Household{}
+	if err := p.query(query.Select(model.Household{}), "id").OutMany(a.DB, &households, web.Tx(ctx)); err != nil {
+		return internalError(ctx, err)
+	}
+	count, next := p.next(len(households), func(index int) int64 { return households[index].ID })
+	return ctx.JSON().Result(HouseholdPage{Items: households[:count], Next: next})
+}
+
+// getHousehold handles `GET /api/v1/households/:id`
+func (a API) getHousehold(ctx *web.Ctx) web.Result {
+	household, result := a.household(ctx)
+	if result != nil {
+		return result
+	}
+	return ctx.JSON().Result(household)
+}
+
+// createHousehold handles `POST /api/v1/households`
+func (a API) createHousehold(ctx *web.Ctx) web.Result {
+	var input HouseholdInput
+	if result := bind(ctx, &input); result != nil {
+		return result
+	}
+	now := time.Now().UTC()
+	household := model.Household{CreatedUTC: now, UpdatedUTC: now}
+	input.apply(&household)
+	household.Version = 0
+	if err := audit.Invoke(a.DB, principal(ctx), web.Tx(ctx)).Create(&household); err != nil {
+		return internalError(ctx, err)
+	}
+	return created(household)
+}
+
+// updateHousehold handles `PUT /api/v1/households/:id`
+func (a API) updateHousehold(ctx *web.Ctx) web.Result {
+	household, result := a.household(ctx)
+	if result != nil {
+		return result
+	}
+	var input HouseholdInput
+	if result := bind(ctx, &input); result != nil {
+		return result
+	}
+	input.apply(household)
+	household.UpdatedUTC = time.Now().UTC()
+	if err := audit.Invoke(a.DB, principal(ctx), web.Tx(ctx)).Update(household); err != nil {
+		return internalError(ctx, err)
+	}
+	return ctx.JSON().Result(household)
+}
+
+// deleteHousehold handles `DELETE /api/v1/households/:id`
+func (a API) deleteHousehold(ctx *web.Ctx) web.Result {
+	household, result := a.household(ctx)
+	if result != nil {
+		return result
+	}
+	if err := audit.Invoke(a.DB, principal(ctx), web.Tx(ctx)).Delete(household); err != nil {
+		return internalError(ctx, err)
+	}
+	return ctx.NoContent()
+}
+
+// listHouseholdGuests handles `GET /api/v1/households/:id/guests`
+func (a API) listHouseholdGuests(ctx *web.Ctx) web.Result {
+	household, result := a.household(ctx)
+	if result != nil {
+		return result
+	}
+	p, fields := readPage(ctx)
+	if fields != nil {
+		return invalid(fields)
+	}
+	guests := []model.Guest{}
+	if err := p.query(query.Select(model.Guest{}).Where(query.Eq("household_id", household.ID)), "id").OutMany(a.DB, &guests, web.Tx(ctx)); err != nil {
+		return internalError(ctx, err)
+	}
+	count, next := p.next(len(guests), func(index int) int64 { return guests[index].ID })
+	return ctx.JSON().Result(GuestPage{Items: guests[:count], Next: next})
+}
+
+// household reads the household in the route, or returns the error result.
+func (a API) household(ctx *web.Ctx) (*model.Household, web.Result) {
+	id, err := ctx.RouteParamInt64("id")
+	if err != nil {
+		return nil, notFound()
+	}
+	var household model.Household
+	if err := a.DB.Invoke(web.Tx(ctx)).Get(&household, id); err != nil {
+		return nil, internalError(ctx, err)
+	}
+	if household.ID == 0 {
+		return nil, notFound()
+	}
+	return &household, nil
+}
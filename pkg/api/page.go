@@ -0,0 +1,75 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/query"
+)
+
+const (
+	// DefaultPageSize is the number of items in a page if the request doesn't say.
+	DefaultPageSize = 50
+	// MaxPageSize is the most items a page can have.
+	MaxPageSize = 200
+)
+
+// pageParams are the query parameters of paginated routes.
+var pageParams = []web.QueryParam{
+	{Name: "limit", Type: "integer", Description: "The number of items in the page, up to " + strconv.Itoa(MaxPageSize) + "."},
+	{Name: "after", Type: "integer", Description: "The `next` value from the previous page."},
+}
+
+// page is where a paginated request starts and how many items it wants.
+//
+// Pages are keyed by id rather than offset, so rows added or deleted between requests
+// don't shift items between pages.
+type page struct {
+	limit int
+	after int64
+}
+
+// readPage reads the page query parameters, returning the invalid ones.
+func readPage(ctx *web.Ctx) (page, map[string]string) {
+	p := page{limit: DefaultPageSize}
+	fields := map[string]string{}
+	if value, _ := ctx.QueryParam("limit"); len(value) > 0 {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxPageSize {
+			fields["limit"] = "must be between 1 and " + strconv.Itoa(MaxPageSize)
+		}
+		p.limit = limit
+	}
+	if value, _ := ctx.QueryParam("after"); len(value) > 0 {
+		after, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			fields["after"] = "must be an id"
+		}
+		p.after = after
+	}
+	if len(fields) > 0 {
+		return p, fields
+	}
+	return p, nil
+}
+
+// query applies the page to a query ordered by a unique integer column, fetching one extra row
+// to tell if there's a next page.
+func (p page) query(b *query.Builder, column string) *query.Builder {
+	b = b.OrderBy(query.Asc(column)).Limit(p.limit + 1)
+	if p.after > 0 {
+		b = b.After(p.after)
+	}
+	return b
+}
+
+// next returns the number of rows to keep of those fetched with `query`, and the cursor of the
+// next page (nil if this is the last page) given the ids of the rows.
+func (p page) next(count int, id func(index int) int64) (int, *int64) {
+	if count <= p.limit {
+		return count, nil
+	}
+	next := id(p.limit - 1)
+	return p.limit, &next
+}
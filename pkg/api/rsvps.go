@@ -0,0 +1,146 @@
+package api
+
+import (
+	"time"
+
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/audit"
+	"github.com/wcharczuk/katwillmarry.com/pkg/metrics"
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/query"
+)
+
+// RSVPPage is a page of rsvps.
+type RSVPPage struct {
+	Items []model.RSVP `json:"items"`
+	Next  *int64       `json:"next,omitempty"`
+}
+
+// RSVPInput is the body for setting a guest's rsvp.
+type RSVPInput struct {
+	Attending bool   `json:"attending"`
+	Message   string `json:"message,omitempty"`
+	// Version is the version the update was made against; if it's set and the rsvp
+	// has changed since, the update fails with a conflict.
+	Version *int64 `json:"version,omitempty"`
+}
+
+// rsvpFilter is the query of `GET /api/v1/rsvps`.
+type rsvpFilter struct {
+	HouseholdID *int64 `query:"household_id"`
+	Attending   *bool  `query:"attending"`
+}
+
+// listRSVPs handles `GET /api/v1/rsvps?household_id=&attending=`
+func (a API) listRSVPs(ctx *web.Ctx) web.Result {
+	var filter rsvpFilter
+	if result := bind(ctx, &filter); result != nil {
+		return result
+	}
+	p, fields := readPage(ctx)
+	if fields != nil {
+		return invalid(fields)
+	}
+	b := query.Select(model.RSVP{})
+	if filter.HouseholdID != nil {
+		b = b.And(query.Eq("household_id", *filter.HouseholdID))
+	}
+	if filter.Attending != nil {
+		b = b.And(query.Eq("attending", *filter.Attending))
+	}
+	rsvps := []model.RSVP{}
+	if err := p.query(b, "guest_id").OutMany(a.DB, &rsvps, web.Tx(ctx)); err != nil {
+		return internalError(ctx, err)
+	}
+	count, next := p.next(len(rsvps), func(index int) int64 { return rsvps[index].GuestID })
+	return ctx.JSON().Result(RSVPPage{Items: rsvps[:count], Next: next})
+}
+
+// getRSVP handles `GET /api/v1/guests/:id/rsvp`
+func (a API) getRSVP(ctx *web.Ctx) web.Result {
+	guest, result := a.guest(ctx)
+	if result != nil {
+		return result
+	}
+	rsvp, err := a.rsvp(ctx, guest.ID)
+	if err != nil {
+		return internalError(ctx, err)
+	}
+	if rsvp == nil {
+		return notFound()
+	}
+	return ctx.JSON().Result(rsvp)
+}
+
+// setRSVP handles `PUT /api/v1/guests/:id/rsvp`
+func (a API) setRSVP(ctx *web.Ctx) web.Result {
+	guest, result := a.guest(ctx)
+	if result != nil {
+		return result
+	}
+	var input RSVPInput
+	if result := bind(ctx, &input); result != nil {
+		return result
+	}
+	existing, err := a.rsvp(ctx, guest.ID)
+	if err != nil {
+		return internalError(ctx, err)
+	}
+
+	rsvp := model.RSVP{GuestID: guest.ID, HouseholdID: guest.HouseholdID}
+	if existing != nil {
+		rsvp = *existing
+		if input.Version != nil {
+			rsvp.Version = *input.Version
+		}
+	}
+	rsvp.Attending = input.Attending
+	rsvp.Message = input.Message
+	rsvp.RespondedUTC = time.Now().UTC()
+
+	invocation := audit.Invoke(a.DB, principal(ctx), web.Tx(ctx))
+	if existing == nil {
+		err = invocation.Create(&rsvp)
+	} else {
+		err = invocation.Update(&rsvp)
+	}
+	if err != nil {
+		return internalError(ctx, err)
+	}
+	if log := ctx.Logger(); log != nil {
+		log.Trigger(metrics.RSVPEvent(principal(ctx), rsvp.Attending))
+	}
+	return ctx.JSON().Result(rsvp)
+}
+
+// deleteRSVP handles `DELETE /api/v1/guests/:id/rsvp`
+func (a API) deleteRSVP(ctx *web.Ctx) web.Result {
+	guest, result := a.guest(ctx)
+	if result != nil {
+		return result
+	}
+	rsvp, err := a.rsvp(ctx, guest.ID)
+	if err != nil {
+		return internalError(ctx, err)
+	}
+	if rsvp == nil {
+		return notFound()
+	}
+	if err := audit.Invoke(a.DB, principal(ctx), web.Tx(ctx)).Delete(rsvp); err != nil {
+		return internalError(ctx, err)
+	}
+	return ctx.NoContent()
+}
+
+// rsvp returns a guest's rsvp, or nil if they haven't responded.
+func (a API) rsvp(ctx *web.Ctx, guestID int64) (*model.RSVP, error) {
+	var rsvp model.RSVP
+	if err := a.DB.Invoke(web.Tx(ctx)).Get(&rsvp, guestID); err != nil {
+		return nil, err
+	}
+	if rsvp.GuestID == 0 {
+		return nil, nil
+	}
+	return &rsvp, nil
+}
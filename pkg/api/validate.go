@@ -0,0 +1,39 @@
+package api
+
+import (
+	"github.com/blend/go-sdk/web"
+)
+
+// checker is an input with checks that can't be written as `validate` tags, ex. between fields.
+type checker interface {
+	check(fields web.FieldErrors)
+}
+
+// bind reads a request into an input and validates it, returning the error response if the
+// request is malformed or has invalid fields.
+func bind(ctx *web.Ctx, input interface{}) web.Result {
+	fields, result := bindFields(ctx, input)
+	if result != nil {
+		return result
+	}
+	if len(fields) > 0 {
+		return invalid(fields)
+	}
+	return nil
+}
+
+// bindFields is `bind` that returns the invalid fields rather than a response, so callers can add
+// checks that need the database.
+func bindFields(ctx *web.Ctx, input interface{}) (web.FieldErrors, web.Result) {
+	fields, err := ctx.Bind(input)
+	if err != nil {
+		return nil, badRequest(err)
+	}
+	if fields == nil {
+		fields = web.FieldErrors{}
+	}
+	if c, ok := input.(checker); ok {
+		c.check(fields)
+	}
+	return fields, nil
+}
@@ -0,0 +1,157 @@
+// Package apitoken issues and checks the bearer tokens scripts use to call the admin api.
+package apitoken
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/util"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/audit"
+	"github.com/wcharczuk/katwillmarry.com/pkg/query"
+)
+
+const (
+	// ErrInvalidToken is returned for a token that doesn't exist or was revoked.
+	ErrInvalidToken exception.Class = "apitoken: invalid token"
+	// ErrTokenNotFound is returned when revoking a token that doesn't exist.
+	ErrTokenNotFound exception.Class = "apitoken: not found"
+	// ErrUnknownScope is returned when creating a token with a scope that isn't one of `Scopes`.
+	ErrUnknownScope exception.Class = "apitoken: unknown scope"
+
+	// ScopeRead lets a token read the guest list.
+	ScopeRead = "read"
+	// ScopeWrite lets a token read and change the guest list.
+	ScopeWrite = "write"
+
+	// Prefix starts every token, so they're easy to spot (ex. by secret scanners).
+	Prefix = "kwm_"
+
+	// secretSize is the number of random bytes in a token.
+	secretSize = 32
+	// lastUsedResolution is how stale last used can get before it's written again,
+	// so a busy script doesn't write on every request.
+	lastUsedResolution = time.Minute
+)
+
+// Scopes are the token scopes, least to most access.
+var Scopes = []string{ScopeRead, ScopeWrite}
+
+// IsScope returns if a value is one of `Scopes`.
+func IsScope(value string) bool {
+	for _, scope := range Scopes {
+		if scope == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Token is an api token. Only the hash of the secret is stored; the secret is shown once, when it's created.
+type Token struct {
+	ID   int64  `db:"id,pk,serial" json:"id"`
+	Name string `db:"name" json:"name"`
+	Hash string `db:"hash" json:"-"`
+	// Scope is what the token is allowed to do, ex. `read`.
+	Scope       string     `db:"scope" json:"scope"`
+	CreatedBy   string     `db:"created_by" json:"createdBy"`
+	CreatedUTC  time.Time  `db:"created_utc" json:"createdUTC"`
+	LastUsedUTC *time.Time `db:"last_used_utc" json:"lastUsedUTC,omitempty"`
+}
+
+// TableName returns the mapped table name.
+func (t Token) TableName() string {
+	return "api_token"
+}
+
+func init() {
+	audit.Register(Token{})
+}
+
+// Principal returns the audit principal for changes made with the token.
+func (t Token) Principal() string {
+	return "token:" + t.Name
+}
+
+// Allows returns if the token's scope allows requests that need a scope; write tokens can also read.
+func (t Token) Allows(scope string) bool {
+	return t.Scope == scope || t.Scope == ScopeWrite
+}
+
+// Hash returns the stored hash of a token secret.
+// Secrets are random, so a plain sha256 is enough to keep a database leak from being a credential leak.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Create issues a token with a scope on behalf of a principal, returning the secret.
+func Create(conn *db.Connection, principal, name, scope string, txs ...*sql.Tx) (secret string, token *Token, err error) {
+	if !IsScope(scope) {
+		return "", nil, exception.New(ErrUnknownScope).WithMessagef("scope: %s", scope)
+	}
+	random, err := util.Crypto.SecureRandomBytes(secretSize)
+	if err != nil {
+		return "", nil, exception.New(err)
+	}
+	secret = Prefix + hex.EncodeToString(random)
+	token = &Token{
+		Name:       strings.TrimSpace(name),
+		Hash:       Hash(secret),
+		Scope:      scope,
+		CreatedBy:  principal,
+		CreatedUTC: time.Now().UTC(),
+	}
+	if err = audit.Invoke(conn, principal, txs...).Create(token); err != nil {
+		return "", nil, err
+	}
+	return secret, token, nil
+}
+
+// Authenticate returns the token for a secret, recording that it was used.
+func Authenticate(conn *db.Connection, secret string, txs ...*sql.Tx) (*Token, error) {
+	if !strings.HasPrefix(secret, Prefix) {
+		return nil, exception.New(ErrInvalidToken)
+	}
+	var token Token
+	if err := query.Select(Token{}).Where(query.Eq("hash", Hash(secret))).Out(conn, &token, txs...); err != nil {
+		return nil, err
+	}
+	if token.ID == 0 {
+		return nil, exception.New(ErrInvalidToken)
+	}
+
+	now := time.Now().UTC()
+	if token.LastUsedUTC == nil || now.Sub(*token.LastUsedUTC) > lastUsedResolution {
+		statement := "UPDATE api_token SET last_used_utc = " + conn.Dialect().Placeholder(1) + " WHERE id = " + conn.Dialect().Placeholder(2)
+		if err := conn.Invoke(txs...).WithLabel("api_token_used").Exec(statement, now, token.ID); err != nil {
+			return nil, err
+		}
+		token.LastUsedUTC = &now
+	}
+	return &token, nil
+}
+
+// All returns the tokens, newest first.
+func All(conn *db.Connection, txs ...*sql.Tx) ([]Token, error) {
+	var tokens []Token
+	err := query.Select(Token{}).OrderBy(query.Desc("id")).OutMany(conn, &tokens, txs...)
+	return tokens, err
+}
+
+// Revoke deletes a token on behalf of a principal.
+func Revoke(conn *db.Connection, principal string, id int64, txs ...*sql.Tx) error {
+	var token Token
+	if err := conn.Invoke().Get(&token, id); err != nil {
+		return err
+	}
+	if token.ID == 0 {
+		return exception.New(ErrTokenNotFound).WithMessagef("id: %d", id)
+	}
+	return audit.Invoke(conn, principal, txs...).Delete(&token)
+}
@@ -0,0 +1,55 @@
+package assets
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"hash"
+	"strings"
+
+	"github.com/blend/go-sdk/exception"
+)
+
+const (
+	// ErrUnknownIntegrityAlgorithm is returned for integrity strings that aren't sha256, sha384 or sha512.
+	ErrUnknownIntegrityAlgorithm exception.Class = "unknown integrity algorithm"
+)
+
+// Integrity returns a subresource integrity string for the contents, ex: `sha384-<base64>`.
+func Integrity(algorithm string, contents []byte) (string, error) {
+	h, err := integrityHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+	h.Write(contents)
+	return algorithm + "-" + base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
+
+// VerifyIntegrity checks the contents against a subresource integrity string.
+func VerifyIntegrity(integrity string, contents []byte) error {
+	pieces := strings.SplitN(integrity, "-", 2)
+	if len(pieces) != 2 {
+		return exception.New(ErrUnknownIntegrityAlgorithm).WithMessagef("integrity: %s", integrity)
+	}
+	actual, err := Integrity(pieces[0], contents)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(actual), []byte(integrity)) != 1 {
+		return exception.New(ErrIntegrityMismatch).WithMessagef("expected: %s, actual: %s", integrity, actual)
+	}
+	return nil
+}
+
+func integrityHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case "sha256":
+		return sha256.New(), nil
+	case "sha384":
+		return sha512.New384(), nil
+	case "sha512":
+		return sha512.New(), nil
+	}
+	return nil, exception.New(ErrUnknownIntegrityAlgorithm).WithMessagef("algorithm: %s", algorithm)
+}
@@ -0,0 +1,28 @@
+package assets_test
+
+import (
+	"testing"
+
+	"github.com/blend/go-sdk/exception"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/assets"
+)
+
+func TestVerifyIntegrity(t *testing.T) {
+	contents := []byte("console.log('hi')")
+	for _, algorithm := range []string{"sha256", "sha384", "sha512"} {
+		integrity, err := assets.Integrity(algorithm, contents)
+		if err != nil {
+			t.Fatalf("%s: %+v", algorithm, err)
+		}
+		if err := assets.VerifyIntegrity(integrity, contents); err != nil {
+			t.Fatalf("%s: %+v", algorithm, err)
+		}
+	}
+	if err := assets.VerifyIntegrity("md5-AAAA", contents); !exception.Is(err, assets.ErrUnknownIntegrityAlgorithm) {
+		t.Fatalf("an unknown algorithm should fail, got %v", err)
+	}
+	if err := assets.VerifyIntegrity("sha384", contents); !exception.Is(err, assets.ErrUnknownIntegrityAlgorithm) {
+		t.Fatalf("an integrity string without a hash should fail, got %v", err)
+	}
+}
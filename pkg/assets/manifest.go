@@ -0,0 +1,118 @@
+package assets
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blend/go-sdk/exception"
+)
+
+const (
+	// DefaultManifestPath is the default path to the vendored asset manifest.
+	DefaultManifestPath = "_static/vendor/manifest.json"
+
+	// KindScript is an asset that is emitted as a `<script>` tag.
+	KindScript = "script"
+	// KindStyle is an asset that is emitted as a `<link rel="stylesheet">` tag.
+	KindStyle = "style"
+
+	// ErrAssetNotFound is returned when an asset name isn't in the manifest.
+	ErrAssetNotFound exception.Class = "asset not found in manifest"
+	// ErrIntegrityMismatch is returned when an asset on disk doesn't match its manifest hash.
+	ErrIntegrityMismatch exception.Class = "asset integrity mismatch"
+)
+
+// ReadManifest reads a manifest from a given path.
+// Asset paths are resolved relative to the directory holding the manifest.
+func ReadManifest(path string) (*Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, exception.New(err)
+	}
+	defer f.Close()
+
+	var manifest Manifest
+	if err := json.NewDecoder(f).Decode(&manifest); err != nil {
+		return nil, exception.New(err).WithMessagef("path: %s", path)
+	}
+	manifest.Root = filepath.Dir(path)
+	return &manifest, nil
+}
+
+// Manifest is the list of self hosted third party assets.
+type Manifest struct {
+	// Root is the directory on disk the asset paths are relative to.
+	Root string `json:"-"`
+	// MountPath is the url path the root is served from.
+	MountPath string `json:"mountPath"`
+	// Assets are the assets in the order they should be emitted.
+	Assets []Asset `json:"assets"`
+}
+
+// Asset is a single vendored file.
+type Asset struct {
+	Name      string `json:"name"`
+	Package   string `json:"package"`
+	Version   string `json:"version"`
+	Kind      string `json:"kind"`
+	Path      string `json:"path"`
+	Source    string `json:"source"`
+	Integrity string `json:"integrity"`
+}
+
+// Lookup returns an asset by name.
+func (m *Manifest) Lookup(name string) (*Asset, error) {
+	for index := range m.Assets {
+		if m.Assets[index].Name == name {
+			return &m.Assets[index], nil
+		}
+	}
+	return nil, exception.New(ErrAssetNotFound).WithMessagef("name: %s", name)
+}
+
+// Scripts returns the script assets.
+func (m *Manifest) Scripts() []Asset {
+	return m.byKind(KindScript)
+}
+
+// Styles returns the stylesheet assets.
+func (m *Manifest) Styles() []Asset {
+	return m.byKind(KindStyle)
+}
+
+// URL returns the url path an asset is served from.
+func (m *Manifest) URL(asset Asset) string {
+	return strings.TrimSuffix(m.MountPath, "/") + "/" + strings.TrimPrefix(asset.Path, "/")
+}
+
+// FilePath returns the path on disk for an asset.
+func (m *Manifest) FilePath(asset Asset) string {
+	return filepath.Join(m.Root, filepath.FromSlash(asset.Path))
+}
+
+// Verify checks that every asset exists on disk and matches its integrity hash;
+// `cmd/fetchassets` fetches them.
+func (m *Manifest) Verify() error {
+	for _, asset := range m.Assets {
+		contents, err := ioutil.ReadFile(m.FilePath(asset))
+		if err != nil {
+			return exception.New(err).WithMessagef("asset: %s; run `go generate` to fetch it", asset.Name)
+		}
+		if err := VerifyIntegrity(asset.Integrity, contents); err != nil {
+			return exception.New(err).WithMessagef("asset: %s; run `go generate` to fetch it", asset.Name)
+		}
+	}
+	return nil
+}
+
+func (m *Manifest) byKind(kind string) (output []Asset) {
+	for _, asset := range m.Assets {
+		if asset.Kind == kind {
+			output = append(output, asset)
+		}
+	}
+	return
+}
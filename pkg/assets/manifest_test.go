@@ -0,0 +1,71 @@
+package assets_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blend/go-sdk/exception"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/assets"
+)
+
+// newManifest returns a manifest rooted in a temp directory with a stylesheet and two scripts.
+func newManifest(t *testing.T) *assets.Manifest {
+	t.Helper()
+	manifest := &assets.Manifest{
+		Root:      t.TempDir(),
+		MountPath: "/static/vendor/",
+		Assets: []assets.Asset{
+			{Name: "bootstrap.css", Kind: assets.KindStyle, Path: "bootstrap/css/bootstrap.min.css"},
+			{Name: "jquery.js", Kind: assets.KindScript, Path: "jquery/jquery.min.js"},
+			{Name: "bootstrap.js", Kind: assets.KindScript, Path: "/bootstrap/js/bootstrap.min.js"},
+		},
+	}
+	for index, asset := range manifest.Assets {
+		contents := []byte("/* " + asset.Name + " */")
+		integrity, err := assets.Integrity("sha384", contents)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		manifest.Assets[index].Integrity = integrity
+		path := manifest.FilePath(asset)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, contents, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return manifest
+}
+
+func TestManifestVerify(t *testing.T) {
+	manifest := newManifest(t)
+	if err := manifest.Verify(); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	jquery, err := manifest.Lookup("jquery.js")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := ioutil.WriteFile(manifest.FilePath(*jquery), []byte("/* jquery.js, changed */"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := manifest.Verify(); !exception.Is(err, assets.ErrIntegrityMismatch) {
+		t.Fatalf("an asset that doesn't match its integrity hash should fail, got %v", err)
+	}
+
+	if err := os.Remove(manifest.FilePath(*jquery)); err != nil {
+		t.Fatal(err)
+	}
+	if err := manifest.Verify(); err == nil {
+		t.Fatal("a missing asset should fail")
+	}
+
+	if _, err := manifest.Lookup("vue.js"); !exception.Is(err, assets.ErrAssetNotFound) {
+		t.Fatalf("an asset that isn't in the manifest should be not found, got %v", err)
+	}
+}
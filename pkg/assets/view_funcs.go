@@ -0,0 +1,58 @@
+package assets
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/blend/go-sdk/web"
+)
+
+// AddViewFuncs adds the asset helpers to a view cache's func map.
+// It must be called before the view cache is initialized.
+//
+// The helpers are:
+//
+//	{{ vendorStyles }} - `<link>` tags for every stylesheet in the manifest.
+//	{{ vendorScripts }} - `<script>` tags for every script in the manifest.
+//	{{ vendorAsset "name" }} - the tag for a single asset by name.
+func (m *Manifest) AddViewFuncs(vc *web.ViewCache) {
+	funcs := vc.FuncMap()
+	funcs["vendorStyles"] = func() template.HTML {
+		return m.Tags(m.Styles()...)
+	}
+	funcs["vendorScripts"] = func() template.HTML {
+		return m.Tags(m.Scripts()...)
+	}
+	funcs["vendorAsset"] = func(name string) (template.HTML, error) {
+		asset, err := m.Lookup(name)
+		if err != nil {
+			return "", err
+		}
+		return m.Tags(*asset), nil
+	}
+}
+
+// Tags returns the html tags for a given set of assets.
+func (m *Manifest) Tags(assets ...Asset) template.HTML {
+	buffer := new(bytes.Buffer)
+	for _, asset := range assets {
+		switch asset.Kind {
+		case KindStyle:
+			buffer.WriteString(`<link rel="stylesheet" href="`)
+			buffer.WriteString(template.HTMLEscapeString(m.URL(asset)))
+			buffer.WriteString(`" integrity="`)
+			buffer.WriteString(template.HTMLEscapeString(asset.Integrity))
+			buffer.WriteString(`">`)
+		case KindScript:
+			buffer.WriteString(`<script src="`)
+			buffer.WriteString(template.HTMLEscapeString(m.URL(asset)))
+			buffer.WriteString(`" integrity="`)
+			buffer.WriteString(template.HTMLEscapeString(asset.Integrity))
+			buffer.WriteString(`"></script>`)
+		default:
+			continue
+		}
+		buffer.WriteString("\n")
+	}
+	return template.HTML(buffer.String())
+}
@@ -0,0 +1,54 @@
+package assets_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/blend/go-sdk/web"
+)
+
+func TestViewFuncs(t *testing.T) {
+	manifest := newManifest(t)
+	vc := web.NewViewCache()
+	manifest.AddViewFuncs(vc)
+	vc.AddLiterals(`{{ define "styles" }}{{ vendorStyles }}{{ end }}`,
+		`{{ define "scripts" }}{{ vendorScripts }}{{ end }}`,
+		`{{ define "asset" }}{{ vendorAsset "bootstrap.css" }}{{ end }}`,
+		`{{ define "unknown" }}{{ vendorAsset "vue.js" }}{{ end }}`)
+	if err := vc.Initialize(); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	render := func(name string) (string, error) {
+		view, err := vc.Lookup(name)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		buffer := new(bytes.Buffer)
+		err = view.Execute(buffer, nil)
+		return buffer.String(), err
+	}
+
+	styles := `<link rel="stylesheet" href="/static/vendor/bootstrap/css/bootstrap.min.css" integrity="` + manifest.Assets[0].Integrity + `">` + "\n"
+	scripts := `<script src="/static/vendor/jquery/jquery.min.js" integrity="` + manifest.Assets[1].Integrity + `"></script>` + "\n" +
+		`<script src="/static/vendor/bootstrap/js/bootstrap.min.js" integrity="` + manifest.Assets[2].Integrity + `"></script>` + "\n"
+	testCases := []struct {
+		view     string
+		expected string
+	}{
+		{view: "styles", expected: styles},
+		{view: "scripts", expected: scripts},
+		{view: "asset", expected: styles},
+	}
+	for _, tc := range testCases {
+		output, err := render(tc.view)
+		if err != nil {
+			t.Fatalf("%s: %+v", tc.view, err)
+		}
+		if output != tc.expected {
+			t.Fatalf("%s: expected %q, got %q", tc.view, tc.expected, output)
+		}
+	}
+	if _, err := render("unknown"); err == nil {
+		t.Fatal("an asset that isn't in the manifest should fail to render")
+	}
+}
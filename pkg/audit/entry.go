@@ -0,0 +1,171 @@
+package audit
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/query"
+)
+
+const (
+	// VerbCreate is the verb for inserts.
+	VerbCreate = db.VerbCreate
+	// VerbUpdate is the verb for updates.
+	VerbUpdate = db.VerbUpdate
+	// VerbUpsert is the verb for upserts.
+	VerbUpsert = db.VerbUpsert
+	// VerbDelete is the verb for deletes.
+	VerbDelete = db.VerbDelete
+	// VerbRestore is the verb for restoring soft deleted rows.
+	VerbRestore = db.VerbRestore
+	// VerbPurge is the verb for permanently deleting soft deleted rows.
+	VerbPurge = db.VerbPurge
+	// VerbRevert is the verb for changes made by reverting an entry.
+	VerbRevert = "revert"
+)
+
+// Entry is the record of a single change to a row.
+type Entry struct {
+	ID         int64     `db:"id,pk,serial" json:"id"`
+	CreatedUTC time.Time `db:"created_utc" json:"createdUTC"`
+	Principal  string    `db:"principal" json:"principal"`
+	Verb       string    `db:"verb" json:"verb"`
+	// Entity is the table the row is in.
+	Entity string `db:"entity" json:"entity"`
+	// EntityKey is the primary key of the row, comma separated if there's more than one column.
+	EntityKey string `db:"entity_key" json:"entityKey"`
+	// Scope groups related entries, ex. `household:12` for the guests and rsvps of a household.
+	Scope string `db:"scope" json:"scope,omitempty"`
+	// Changes are the columns that changed.
+	Changes []Change `db:"changes,json" json:"changes"`
+	// Before is the row before the change; it is empty if the change created the row.
+	Before Snapshot `db:"before_state,json" json:"before,omitempty"`
+}
+
+// TableName returns the mapped table name.
+func (e Entry) TableName() string {
+	return "audit_entry"
+}
+
+// Columns returns the names of the columns that changed.
+func (e Entry) Columns() []string {
+	names := make([]string, len(e.Changes))
+	for index, change := range e.Changes {
+		names[index] = change.Column
+	}
+	return names
+}
+
+// Change is the before and after value of a column, json encoded.
+// A missing value (the row didn't exist) is `null`.
+type Change struct {
+	Column string          `json:"column"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// Snapshot is the json encoded value of each column of a row.
+type Snapshot map[string]json.RawMessage
+
+// TakeSnapshot returns the snapshot of a database mapped object.
+func TakeSnapshot(object db.DatabaseMapped) (Snapshot, error) {
+	snapshot := Snapshot{}
+	for _, col := range db.Columns(object).Columns() {
+		contents, err := json.Marshal(col.GetValue(object))
+		if err != nil {
+			return nil, exception.New(err).WithMessagef("column: %s", col.ColumnName)
+		}
+		snapshot[col.ColumnName] = contents
+	}
+	return snapshot, nil
+}
+
+// Restore sets the given columns of a database mapped object (which must be a pointer) from the snapshot.
+// If no columns are given, every column in the snapshot is restored.
+func (s Snapshot) Restore(object db.DatabaseMapped, columns ...string) error {
+	if len(columns) == 0 {
+		for column := range s {
+			columns = append(columns, column)
+		}
+	}
+	value := reflect.ValueOf(object)
+	if value.Kind() != reflect.Ptr {
+		return exception.New("audit: restore target must be a pointer")
+	}
+	value = value.Elem()
+	lookup := db.Columns(object).Lookup()
+	for _, column := range columns {
+		col, ok := lookup[column]
+		if !ok {
+			continue
+		}
+		contents, ok := s[column]
+		if !ok {
+			continue
+		}
+		field := value.Field(col.Index)
+		target := reflect.New(field.Type())
+		if err := json.Unmarshal(contents, target.Interface()); err != nil {
+			return exception.New(err).WithMessagef("column: %s", column)
+		}
+		field.Set(target.Elem())
+	}
+	return nil
+}
+
+// Diff returns the columns that differ between two snapshots, in column order.
+// Either snapshot can be nil, for rows that were created or deleted.
+func Diff(columns []string, before, after Snapshot) []Change {
+	var changes []Change
+	for _, column := range columns {
+		beforeValue, afterValue := valueOrNull(before, column), valueOrNull(after, column)
+		if !bytes.Equal(beforeValue, afterValue) {
+			changes = append(changes, Change{Column: column, Before: beforeValue, After: afterValue})
+		}
+	}
+	return changes
+}
+
+// Key returns the entity key for a database mapped object.
+func Key(object db.DatabaseMapped) string {
+	pks := db.Columns(object).PrimaryKeys().Columns()
+	values := make([]string, len(pks))
+	for index, pk := range pks {
+		values[index] = fmt.Sprint(pk.GetValue(object))
+	}
+	return strings.Join(values, ",")
+}
+
+// History returns the entries that match a filter, most recent first.
+func History(conn *db.Connection, filter query.Condition, txs ...*sql.Tx) ([]Entry, error) {
+	var entries []Entry
+	err := query.Select(Entry{}).Where(filter).OrderBy(query.Desc("id")).OutMany(conn, &entries, txs...)
+	return entries, err
+}
+
+// HistoryForScope returns the entries for a scope, most recent first.
+func HistoryForScope(conn *db.Connection, scope string, txs ...*sql.Tx) ([]Entry, error) {
+	return History(conn, query.Eq("scope", scope), txs...)
+}
+
+// HistoryForEntity returns the entries for a single row, most recent first.
+func HistoryForEntity(conn *db.Connection, object db.DatabaseMapped, txs ...*sql.Tx) ([]Entry, error) {
+	return History(conn, query.And(query.Eq("entity", db.TableName(object)), query.Eq("entity_key", Key(object))), txs...)
+}
+
+var null = json.RawMessage("null")
+
+func valueOrNull(snapshot Snapshot, column string) json.RawMessage {
+	if value, ok := snapshot[column]; ok {
+		return value
+	}
+	return null
+}
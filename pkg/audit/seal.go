@@ -0,0 +1,144 @@
+package audit
+
+import (
+	"encoding/json"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+)
+
+// encryptedColumns returns the names of the columns of an object that are stored encrypted.
+func encryptedColumns(object db.DatabaseMapped) map[string]bool {
+	names := map[string]bool{}
+	for _, col := range db.Columns(object).Encrypted() {
+		names[col.ColumnName] = true
+	}
+	return names
+}
+
+// encryptValues replaces the values of encrypted columns, as returned by `ColumnValues` for an object,
+// with their ciphertext; null and empty values are left as is.
+func encryptValues(conn *db.Connection, object db.DatabaseMapped, cols *db.ColumnCollection, values []interface{}) error {
+	var pks []interface{}
+	for index, col := range cols.Columns() {
+		if !col.IsEncrypted {
+			continue
+		}
+		var plainText string
+		switch typed := values[index].(type) {
+		case string:
+			plainText = typed
+		case *string:
+			if typed != nil {
+				plainText = *typed
+			}
+		}
+		if len(plainText) == 0 {
+			continue
+		}
+		if conn.Encryptor() == nil {
+			return exception.New(db.ErrEncryptorUnset).WithMessagef("column: %s", col.ColumnName)
+		}
+		if pks == nil {
+			pks = primaryKeyValues(object)
+		}
+		cipherText, err := conn.Encryptor().Encrypt(plainText, db.EncryptionContext(db.TableName(object), col.ColumnName, pks...))
+		if err != nil {
+			return exception.New(err).WithMessagef("column: %s", col.ColumnName)
+		}
+		values[index] = cipherText
+	}
+	return nil
+}
+
+// seal encrypts the values of encrypted columns in an entry with the connection's encryptor,
+// so the history doesn't hold them in the clear.
+func seal(conn *db.Connection, object db.DatabaseMapped, entry *Entry) (err error) {
+	encrypted := encryptedColumns(object)
+	if len(encrypted) == 0 {
+		return nil
+	}
+	for index, change := range entry.Changes {
+		if !encrypted[change.Column] {
+			continue
+		}
+		if entry.Changes[index].Before, err = sealValue(conn, entry.sealContext(change.Column), change.Before); err != nil {
+			return
+		}
+		if entry.Changes[index].After, err = sealValue(conn, entry.sealContext(change.Column), change.After); err != nil {
+			return
+		}
+	}
+	for column := range encrypted {
+		if value, ok := entry.Before[column]; ok {
+			if entry.Before[column], err = sealValue(conn, entry.sealContext(column), value); err != nil {
+				return
+			}
+		}
+	}
+	return nil
+}
+
+// unseal returns a copy of a snapshot from an entry with the values of encrypted columns decrypted.
+func unseal(conn *db.Connection, object db.DatabaseMapped, entry Entry, snapshot Snapshot) (Snapshot, error) {
+	encrypted := encryptedColumns(object)
+	if len(encrypted) == 0 {
+		return snapshot, nil
+	}
+	output := Snapshot{}
+	for column, value := range snapshot {
+		if encrypted[column] {
+			var err error
+			if value, err = unsealValue(conn, entry.sealContext(column), value); err != nil {
+				return nil, err
+			}
+		}
+		output[column] = value
+	}
+	return output, nil
+}
+
+// sealValue encrypts a json value, returning the ciphertext as a json string; null and empty strings
+// are left as is, as the connection stores them.
+func sealValue(conn *db.Connection, context []byte, value json.RawMessage) (json.RawMessage, error) {
+	if isNullOrEmpty(value) {
+		return value, nil
+	}
+	if conn.Encryptor() == nil {
+		return nil, exception.New(db.ErrEncryptorUnset)
+	}
+	cipherText, err := conn.Encryptor().Encrypt(string(value), context)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(cipherText)
+}
+
+// unsealValue reverses `sealValue`.
+func unsealValue(conn *db.Connection, context []byte, value json.RawMessage) (json.RawMessage, error) {
+	if isNullOrEmpty(value) {
+		return value, nil
+	}
+	if conn.Encryptor() == nil {
+		return nil, exception.New(db.ErrEncryptorUnset)
+	}
+	var cipherText string
+	if err := json.Unmarshal(value, &cipherText); err != nil {
+		return nil, exception.New(err)
+	}
+	plainText, err := conn.Encryptor().Decrypt(cipherText, context)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(plainText), nil
+}
+
+func isNullOrEmpty(value json.RawMessage) bool {
+	return string(value) == string(null) || string(value) == `""`
+}
+
+// sealContext returns the encryption context for the values of a column in an entry, which binds them to
+// the history of the row they're from.
+func (e Entry) sealContext(column string) []byte {
+	return db.EncryptionContext(e.TableName(), e.Entity+"."+column, e.EntityKey)
+}
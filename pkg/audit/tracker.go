@@ -0,0 +1,286 @@
+// Package audit records who changed what in the guest list, and reverts changes.
+//
+// `Tracker` is attached to the connection, so every `Create`, `Update`, `Upsert`, `Delete`,
+// `Restore` and `Purge` of a registered type is recorded as an `Entry`, whichever invocation it's
+// made with.
+// Use `Invoke` (or `db.Invocation.WithPrincipal`) to record who made the change.
+package audit
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/logger"
+)
+
+const (
+	// ErrNotRegistered is returned when reverting an entry for a type that wasn't registered.
+	ErrNotRegistered exception.Class = "audit: entity type not registered"
+	// ErrEntryNotFound is returned when reverting an entry that doesn't exist.
+	ErrEntryNotFound exception.Class = "audit: entry not found"
+)
+
+// Scoper is implemented by types whose changes should be grouped, ex. guests by household.
+type Scoper interface {
+	AuditScope() string
+}
+
+var (
+	registryLock sync.Mutex
+	registry     = map[string]reflect.Type{}
+)
+
+// Register registers the types whose changes are tracked, and can be reverted.
+func Register(objects ...db.DatabaseMapped) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	for _, object := range objects {
+		registry[db.TableName(object)] = indirectType(object)
+	}
+}
+
+func isRegistered(object db.DatabaseMapped) bool {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	t, ok := registry[db.TableName(object)]
+	return ok && t == indirectType(object)
+}
+
+func newRegistered(entity string) (db.DatabaseMapped, error) {
+	registryLock.Lock()
+	t, ok := registry[entity]
+	registryLock.Unlock()
+	if !ok {
+		return nil, exception.New(ErrNotRegistered).WithMessagef("entity: %s", entity)
+	}
+	return reflect.New(t).Interface().(db.DatabaseMapped), nil
+}
+
+func indirectType(object db.DatabaseMapped) reflect.Type {
+	t := reflect.TypeOf(object)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// Invoke returns an invocation whose changes are recorded as made on behalf of a principal.
+func Invoke(conn *db.Connection, principal string, txs ...*sql.Tx) *db.Invocation {
+	return conn.Invoke(txs...).WithPrincipal(principal)
+}
+
+// Tracker is a `db.Tracker` that records an `Entry` with the changed columns for each write
+// to a registered type, and triggers a `logger.Audit` event.
+type Tracker struct{}
+
+// Tracks returns if an object's type was registered.
+func (t Tracker) Tracks(object db.DatabaseMapped) bool {
+	return isRegistered(object)
+}
+
+// Track snapshots the row before and after a write and records the difference.
+func (t Tracker) Track(invocation *db.Invocation, verb string, object db.DatabaseMapped, write func() error) error {
+	conn, tx := invocation.Connection(), invocation.Tx()
+	before, err := snapshot(conn, tx, object)
+	if err != nil {
+		return err
+	}
+	if err := write(); err != nil {
+		return err
+	}
+	after, err := snapshot(conn, tx, object)
+	if err != nil {
+		return err
+	}
+
+	changes := Diff(db.Columns(object).ColumnNames(), before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	entry := Entry{
+		CreatedUTC: time.Now().UTC(),
+		Principal:  invocation.Principal(),
+		Verb:       verb,
+		Entity:     db.TableName(object),
+		EntityKey:  Key(object),
+		Scope:      scopeFor(object),
+		Changes:    changes,
+		Before:     before,
+	}
+	if err := seal(conn, object, &entry); err != nil {
+		return err
+	}
+	if err := invocation.Create(&entry); err != nil {
+		return err
+	}
+	trigger(conn, entry)
+	return nil
+}
+
+// Revert undoes the change recorded by an entry, on behalf of a principal.
+//
+// Changed columns are set back to their previous values (leaving any other columns alone),
+// deleted rows are re-inserted with their original keys, and created rows are deleted.
+// The revert is itself recorded. The entity type must have been registered with `Register`.
+func Revert(conn *db.Connection, principal string, entryID int64, txs ...*sql.Tx) error {
+	return inTx(conn, db.OptionalTx(txs...), func(tx *sql.Tx) error {
+		var entry Entry
+		if err := conn.Invoke(tx).Get(&entry, entryID); err != nil {
+			return err
+		}
+		if entry.ID == 0 {
+			return exception.New(ErrEntryNotFound).WithMessagef("entry: %d", entryID)
+		}
+
+		object, err := newRegistered(entry.Entity)
+		if err != nil {
+			return err
+		}
+		// the reverting writes are recorded as a revert, rather than by the tracker as whatever they are.
+		revert := func(write func(*db.Invocation) error) error {
+			return Tracker{}.Track(conn.Invoke(tx).WithPrincipal(principal).Untracked(), VerbRevert, object, func() error {
+				return write(conn.Invoke(tx).Untracked())
+			})
+		}
+
+		// the row didn't exist before, so reverting is deleting it.
+		if len(entry.Before) == 0 {
+			after, err := unseal(conn, object, entry, entry.afterSnapshot())
+			if err != nil {
+				return err
+			}
+			if err := after.Restore(object); err != nil {
+				return err
+			}
+			return revert(func(invocation *db.Invocation) error {
+				return invocation.Delete(object)
+			})
+		}
+
+		before, err := unseal(conn, object, entry, entry.Before)
+		if err != nil {
+			return err
+		}
+		if err := before.Restore(object); err != nil {
+			return err
+		}
+		exists, err := conn.Invoke(tx).WithDeleted().Exists(object)
+		if err != nil {
+			return err
+		}
+
+		// the row was deleted, so reverting is putting it back as it was.
+		if !exists {
+			return revert(func(invocation *db.Invocation) error {
+				return insert(conn, tx, object)
+			})
+		}
+
+		// otherwise only put back the columns that changed.
+		if err := conn.Invoke(tx).WithDeleted().Get(object, primaryKeyValues(object)...); err != nil {
+			return err
+		}
+		// the version column is left alone so the revert is checked against the current version.
+		var columns []string
+		version := db.Columns(object).Version()
+		for _, column := range entry.Columns() {
+			if version == nil || column != version.ColumnName {
+				columns = append(columns, column)
+			}
+		}
+		if err := before.Restore(object, columns...); err != nil {
+			return err
+		}
+		return revert(func(invocation *db.Invocation) error {
+			return invocation.Update(object)
+		})
+	})
+}
+
+// snapshot returns the current state of the row for an object, or nil if it doesn't exist.
+func snapshot(conn *db.Connection, tx *sql.Tx, object db.DatabaseMapped) (Snapshot, error) {
+	exists, err := conn.Invoke(tx).WithDeleted().Exists(object)
+	if err != nil || !exists {
+		return nil, err
+	}
+	current := reflect.New(indirectType(object)).Interface().(db.DatabaseMapped)
+	if err := conn.Invoke(tx).WithDeleted().Get(current, primaryKeyValues(object)...); err != nil {
+		return nil, err
+	}
+	return TakeSnapshot(current)
+}
+
+func scopeFor(object db.DatabaseMapped) string {
+	if typed, ok := object.(Scoper); ok {
+		return typed.AuditScope()
+	}
+	return ""
+}
+
+func trigger(conn *db.Connection, entry Entry) {
+	log := conn.Logger()
+	if log == nil {
+		return
+	}
+	log.Trigger(logger.NewAuditEvent(entry.Principal, entry.Verb).
+		WithNoun(entry.Entity).
+		WithSubject(entry.EntityKey).
+		WithExtra(map[string]string{
+			"scope":   entry.Scope,
+			"columns": strings.Join(entry.Columns(), ","),
+		}))
+}
+
+// inTx runs an action in a transaction, or a new one if it's nil.
+func inTx(conn *db.Connection, tx *sql.Tx, action func(*sql.Tx) error) (err error) {
+	if tx != nil {
+		return action(tx)
+	}
+	tx, err = conn.Begin()
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = exception.New(tx.Commit())
+	}()
+	err = action(tx)
+	return
+}
+
+// afterSnapshot returns the row as the entry left it, from the recorded changes.
+func (e Entry) afterSnapshot() Snapshot {
+	snapshot := Snapshot{}
+	for _, change := range e.Changes {
+		snapshot[change.Column] = change.After
+	}
+	return snapshot
+}
+
+func primaryKeyValues(object db.DatabaseMapped) []interface{} {
+	return db.Columns(object).PrimaryKeys().ColumnValues(object)
+}
+
+// insert inserts every column of an object, including serial columns, so a deleted row comes back with its original key.
+// Encrypted columns are encrypted as the connection would have written them.
+func insert(conn *db.Connection, tx *sql.Tx, object db.DatabaseMapped) error {
+	dialect := conn.Dialect()
+	cols := db.Columns(object).NotReadOnly()
+	values := cols.ColumnValues(object)
+	if err := encryptValues(conn, object, cols, values); err != nil {
+		return err
+	}
+	statement := "INSERT INTO " + dialect.QuoteIdentifier(db.TableName(object)) +
+		" (" + strings.Join(db.QuoteIdentifiers(dialect, cols.ColumnNames()), ",") + ")" +
+		" VALUES (" + db.ParamTokensFor(dialect, 1, cols.Len()) + ")"
+	return conn.Invoke(tx).Exec(statement, values...)
+}
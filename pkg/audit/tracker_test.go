@@ -0,0 +1,138 @@
+package audit_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/audit"
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/pii"
+	"github.com/wcharczuk/katwillmarry.com/pkg/schema/schematest"
+)
+
+func TestTrackerRecordsInvocationWrites(t *testing.T) {
+	conn := schematest.Open(t)
+	now := time.Now().UTC()
+
+	household := model.Household{Name: "The Smiths", CreatedUTC: now, UpdatedUTC: now}
+	if err := audit.Invoke(conn, "planner@example.com").Create(&household); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	// a write straight through the connection is recorded too, without a principal.
+	household.Name = "The Smith-Joneses"
+	if err := conn.Invoke().Update(&household); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := conn.Invoke().Delete(&household); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	entries, err := audit.HistoryForScope(conn, model.HouseholdScope(household.ID))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("want 3 entries, got %+v", entries)
+	}
+	if entries[2].Verb != audit.VerbCreate || entries[2].Principal != "planner@example.com" {
+		t.Fatalf("create entry: %+v", entries[2])
+	}
+	if entries[1].Verb != audit.VerbUpdate || entries[1].Principal != "" {
+		t.Fatalf("update entry: %+v", entries[1])
+	}
+	if entries[0].Verb != audit.VerbDelete {
+		t.Fatalf("delete entry: %+v", entries[0])
+	}
+}
+
+func TestRevert(t *testing.T) {
+	conn := schematest.Open(t)
+	now := time.Now().UTC()
+
+	household := model.Household{Name: "The Smiths", CreatedUTC: now, UpdatedUTC: now}
+	if err := conn.Invoke().Create(&household); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	household.Name = "The Smith-Joneses"
+	if err := conn.Invoke().Update(&household); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := conn.Invoke().Delete(&household); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	entries, err := audit.HistoryForScope(conn, model.HouseholdScope(household.ID))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	// revert the delete, then the rename.
+	if err := audit.Revert(conn, "owner@example.com", entries[0].ID); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := audit.Revert(conn, "owner@example.com", entries[1].ID); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	var reverted model.Household
+	if err := conn.Invoke().Get(&reverted, household.ID); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if reverted.ID == 0 || reverted.Name != "The Smiths" {
+		t.Fatalf("revert should restore and rename the household, got %+v", reverted)
+	}
+
+	entries, err = audit.HistoryForScope(conn, model.HouseholdScope(household.ID))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(entries) != 5 || entries[0].Verb != audit.VerbRevert || entries[0].Principal != "owner@example.com" {
+		t.Fatalf("reverts should be recorded, got %+v", entries)
+	}
+}
+
+func TestRevertPurgedDeleteEncrypts(t *testing.T) {
+	conn := schematest.Open(t)
+	keyring := pii.NewKeyring()
+	if err := keyring.AddKey("k1", bytes.Repeat([]byte{1}, 32)); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := keyring.SetPrimary("k1"); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	conn.WithEncryptor(keyring)
+	now := time.Now().UTC()
+
+	household := model.Household{Name: "The Smiths", Address: "1 Main St", CreatedUTC: now, UpdatedUTC: now}
+	if err := conn.Invoke().Create(&household); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := conn.Invoke().Delete(&household); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	// the soft deleted row is purged, so reverting the delete has to insert it again.
+	if err := conn.Invoke().Exec(`DELETE FROM household WHERE id = ?1`, household.ID); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	entries, err := audit.HistoryForScope(conn, model.HouseholdScope(household.ID))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := audit.Revert(conn, "owner@example.com", entries[0].ID); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	var stored string
+	if err := conn.Invoke().Query(`SELECT address FROM household WHERE id = ?1`, household.ID).Scan(&stored); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(stored) == 0 || stored == household.Address {
+		t.Fatalf("the restored address should be stored encrypted, got %q", stored)
+	}
+	var reverted model.Household
+	if err := conn.Invoke().Get(&reverted, household.ID); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if reverted.ID != household.ID || reverted.Address != household.Address || reverted.DeletedUTC != nil {
+		t.Fatalf("revert should restore the household as it was, got %+v", reverted)
+	}
+}
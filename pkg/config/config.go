@@ -5,11 +5,37 @@ import (
 	"github.com/blend/go-sdk/logger"
 	"github.com/blend/go-sdk/oauth"
 	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/gate"
+	"github.com/wcharczuk/katwillmarry.com/pkg/job"
+	"github.com/wcharczuk/katwillmarry.com/pkg/lifecycle"
+	"github.com/wcharczuk/katwillmarry.com/pkg/magiclink"
+	"github.com/wcharczuk/katwillmarry.com/pkg/notify"
+	"github.com/wcharczuk/katwillmarry.com/pkg/passkey"
+	"github.com/wcharczuk/katwillmarry.com/pkg/pii"
+	"github.com/wcharczuk/katwillmarry.com/pkg/rbac"
+	"github.com/wcharczuk/katwillmarry.com/pkg/reminder"
+	"github.com/wcharczuk/katwillmarry.com/pkg/security"
+	"github.com/wcharczuk/katwillmarry.com/pkg/session"
+	"github.com/wcharczuk/katwillmarry.com/pkg/softdelete"
 )
 
 type Config struct {
-	Web    web.Config    `yaml:"web"`
-	DB     db.Config     `yaml:"db"`
-	OAuth  oauth.Config  `yaml:"oauth"`
-	Logger logger.Config `yaml:"logger"`
+	Web        web.Config        `yaml:"web"`
+	Healthz    web.HealthzConfig `yaml:"healthz"`
+	Security   security.Config   `yaml:"security"`
+	Gate       gate.Config       `yaml:"gate"`
+	Lifecycle  lifecycle.Config  `yaml:"lifecycle"`
+	DB         db.Config         `yaml:"db"`
+	PII        pii.Config        `yaml:"pii"`
+	SoftDelete softdelete.Config `yaml:"softDelete"`
+	Session    session.Config    `yaml:"session"`
+	Job        job.Config        `yaml:"job"`
+	Reminder   reminder.Config   `yaml:"reminder"`
+	Notify     notify.Config     `yaml:"notify"`
+	MagicLink  magiclink.Config  `yaml:"magicLink"`
+	RBAC       rbac.Config       `yaml:"rbac"`
+	OAuth      oauth.Config      `yaml:"oauth"`
+	Passkey    passkey.Config    `yaml:"passkey"`
+	Logger     logger.Config     `yaml:"logger"`
 }
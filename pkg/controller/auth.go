@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/rbac"
+)
+
+// AdminRequired is middleware for admin pages anyone with an admin role can see; pages for only
+// some roles use `rbac.RequireRole`. Guests logged in to their household (see
+// `model.HouseholdUserID`) don't have roles, so they're not authorized.
+func AdminRequired(action web.Action) web.Action {
+	return rbac.RequireRole(rbac.Roles...)(action)
+}
+
+// HouseholdRequired is middleware for guest pages. It requires a guest logged in to their household,
+// sending anyone else to log in.
+func HouseholdRequired(action web.Action) web.Action {
+	return web.SessionAware(func(ctx *web.Ctx) web.Result {
+		if ctx.Session() == nil {
+			return ctx.RedirectWithMethodf("GET", "/rsvp/login")
+		}
+		if _, isGuest := model.ParseHouseholdUserID(ctx.Session().UserID); !isGuest {
+			return ctx.RedirectWithMethodf("GET", "/rsvp/login")
+		}
+		return action(ctx)
+	})
+}
+
+// householdID returns the household of the guest logged in; it's only set behind `HouseholdRequired`.
+func householdID(ctx *web.Ctx) int64 {
+	id, _ := model.ParseHouseholdUserID(ctx.Session().UserID)
+	return id
+}
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/gate"
+)
+
+// Gate is the passphrase page of the site password gate.
+// It handles:
+// - /gate
+type Gate struct {
+	Log  *logger.Logger
+	Gate *gate.Gate
+}
+
+// Register adds routes for the controller.
+func (g Gate) Register(app *web.App) {
+	app.Views().AddPaths(
+		"_views/rsvp/layout.html",
+		"_views/gate.html",
+	)
+
+	app.GET(gate.Path, g.show)
+	app.POST(gate.Path, g.enter)
+}
+
+// gateForm is the passphrase form.
+type gateForm struct {
+	Passphrase string `form:"passphrase" validate:"required"`
+}
+
+// show handles `GET /gate`
+func (g Gate) show(ctx *web.Ctx) web.Result {
+	if !g.Gate.Enabled() || g.Gate.Admitted(ctx) {
+		return ctx.RedirectWithMethodf("GET", "%s", gate.Next(ctx))
+	}
+	return g.view(ctx, nil)
+}
+
+// enter handles `POST /gate`
+func (g Gate) enter(ctx *web.Ctx) web.Result {
+	var form gateForm
+	fieldErrors, err := ctx.Bind(&form)
+	if err != nil {
+		return ctx.View().BadRequest(err)
+	}
+	if fieldErrors == nil && !g.Gate.Check(form.Passphrase) {
+		fieldErrors = web.FieldErrors{"passphrase": "isn't right"}
+	}
+	if fieldErrors != nil {
+		return g.view(ctx, fieldErrors)
+	}
+	g.Gate.Admit(ctx)
+	return ctx.RedirectWithMethodf("GET", "%s", gate.Next(ctx))
+}
+
+func (g Gate) view(ctx *web.Ctx, fieldErrors web.FieldErrors) web.Result {
+	return ctx.View().View("gate", struct {
+		Next   string
+		Errors web.FieldErrors
+	}{
+		Next:   gate.Next(ctx),
+		Errors: fieldErrors,
+	})
+}
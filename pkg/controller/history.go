@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/audit"
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/rbac"
+)
+
+// History is the admin page for the changes made to a household, its guests and their rsvps.
+// It handles:
+// - /admin/households/:id/history
+// - /admin/households/:id/history/:entry/revert
+type History struct {
+	Log *logger.Logger
+	DB  *db.Connection
+}
+
+// Register adds routes for the controller.
+func (h History) Register(app *web.App) {
+	app.Views().AddPaths("_views/flashes.html", "_views/admin/layout.html", "_views/admin/history.html")
+
+	app.GET("/admin/households/:id/history", h.list, rbac.RequireRole(rbac.Owner, rbac.Planner))
+	app.POST("/admin/households/:id/history/:entry/revert", h.revert, rbac.RequireRole(rbac.Owner, rbac.Planner))
+}
+
+// list handles `GET /admin/households/:id/history`
+func (h History) list(ctx *web.Ctx) web.Result {
+	householdID, err := ctx.RouteParamInt64("id")
+	if err != nil {
+		return ctx.View().BadRequest(err)
+	}
+	var household model.Household
+	if err := h.DB.Invoke(web.Tx(ctx)).WithDeleted().Get(&household, householdID); err != nil {
+		return ctx.View().InternalError(err)
+	}
+	if household.ID == 0 {
+		return ctx.View().NotFound()
+	}
+	entries, err := audit.HistoryForScope(h.DB, model.HouseholdScope(householdID), web.Tx(ctx))
+	if err != nil {
+		return ctx.View().InternalError(err)
+	}
+	return ctx.View().View("admin_history", struct {
+		Household model.Household
+		Entries   []audit.Entry
+	}{
+		Household: household,
+		Entries:   entries,
+	})
+}
+
+// revert handles `POST /admin/households/:id/history/:entry/revert`
+func (h History) revert(ctx *web.Ctx) web.Result {
+	householdID, err := ctx.RouteParamInt64("id")
+	if err != nil {
+		return ctx.View().BadRequest(err)
+	}
+	entryID, err := ctx.RouteParamInt64("entry")
+	if err != nil {
+		return ctx.View().BadRequest(err)
+	}
+	// the entry has to be one of the household's, so the page it's reverted from is the one it's listed on.
+	var entry audit.Entry
+	if err := h.DB.Invoke(web.Tx(ctx)).Get(&entry, entryID); err != nil {
+		return ctx.View().InternalError(err)
+	}
+	if entry.ID == 0 || entry.Scope != model.HouseholdScope(householdID) {
+		return ctx.View().NotFound()
+	}
+
+	historyPath := fmt.Sprintf("/admin/households/%d/history", householdID)
+	if err := audit.Revert(h.DB, ctx.Session().UserID, entryID, web.Tx(ctx)); err != nil {
+		if db.IsVersionConflict(err) {
+			ctx.AddFlash(web.FlashError, "Someone else edited this "+entry.Entity+" while it was being reverted; reload the history and try again.")
+			return ctx.RedirectWithMethodf("GET", "%s", historyPath)
+		}
+		if exception.Is(err, audit.ErrEntryNotFound) {
+			return ctx.View().NotFound()
+		}
+		return ctx.View().InternalError(err)
+	}
+	ctx.AddFlash(web.FlashSuccess, fmt.Sprintf("Change %d was reverted.", entryID))
+	return ctx.RedirectWithMethodf("GET", "%s", historyPath)
+}
@@ -15,11 +15,13 @@ type Index struct {
 
 // Register adds routes for the controller.
 func (i Index) Register(app *web.App) {
+	app.Views().AddPaths("_views/index.html")
+
 	app.ServeStatic("/static", "_static")
 	app.GET("/", i.index)
 }
 
 // index handles `/`
 func (i Index) index(ctx *web.Ctx) web.Result {
-	return ctx.Static("_static/index.html")
+	return ctx.View().View("index", nil)
 }
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/job"
+	"github.com/wcharczuk/katwillmarry.com/pkg/query"
+	"github.com/wcharczuk/katwillmarry.com/pkg/rbac"
+)
+
+// jobsPageSize is the number of jobs listed on the admin page.
+const jobsPageSize = 100
+
+// Jobs is the admin page for the background job queue.
+// It handles:
+// - /admin/jobs?status=
+// - /admin/jobs/:id/retry
+// - /admin/jobs/:id/cancel
+type Jobs struct {
+	Log *logger.Logger
+	DB  *db.Connection
+}
+
+// Register adds routes for the controller.
+func (j Jobs) Register(app *web.App) {
+	app.Views().AddPaths("_views/flashes.html", "_views/admin/layout.html", "_views/admin/jobs.html")
+
+	app.GET("/admin/jobs", j.list, rbac.RequireRole(rbac.Owner, rbac.Planner))
+	app.POST("/admin/jobs/:id/retry", j.retry, rbac.RequireRole(rbac.Owner, rbac.Planner))
+	app.POST("/admin/jobs/:id/cancel", j.cancel, rbac.RequireRole(rbac.Owner, rbac.Planner))
+}
+
+// list handles `GET /admin/jobs`
+// It shows jobs that haven't succeeded, or the jobs in a given status.
+func (j Jobs) list(ctx *web.Ctx) web.Result {
+	status, _ := ctx.QueryParam("status")
+	filter := query.In("status", job.StatusQueued, job.StatusRunning, job.StatusRetrying, job.StatusDead)
+	if len(status) > 0 {
+		filter = query.Eq("status", status)
+	}
+
+	var jobs []job.Job
+	if err := query.Select(job.Job{}).Where(filter).OrderBy(query.Desc("id")).Limit(jobsPageSize).OutMany(j.DB, &jobs, web.Tx(ctx)); err != nil {
+		return ctx.View().InternalError(err)
+	}
+	counts, err := job.Counts(j.DB, web.Tx(ctx))
+	if err != nil {
+		return ctx.View().InternalError(err)
+	}
+	return ctx.View().View("admin_jobs", struct {
+		Status   string
+		Statuses []string
+		Counts   map[string]int
+		Jobs     []job.Job
+	}{
+		Status:   status,
+		Statuses: []string{job.StatusQueued, job.StatusRunning, job.StatusRetrying, job.StatusDead, job.StatusSucceeded},
+		Counts:   counts,
+		Jobs:     jobs,
+	})
+}
+
+// retry handles `POST /admin/jobs/:id/retry`
+func (j Jobs) retry(ctx *web.Ctx) web.Result {
+	id, err := ctx.RouteParamInt64("id")
+	if err != nil {
+		return ctx.View().BadRequest(err)
+	}
+	if err := job.Retry(j.DB, id, web.Tx(ctx)); err != nil {
+		return ctx.View().InternalError(err)
+	}
+	ctx.AddFlash(web.FlashSuccess, fmt.Sprintf("Job %d will be retried.", id))
+	return ctx.RedirectWithMethodf("GET", "/admin/jobs")
+}
+
+// cancel handles `POST /admin/jobs/:id/cancel`
+func (j Jobs) cancel(ctx *web.Ctx) web.Result {
+	id, err := ctx.RouteParamInt64("id")
+	if err != nil {
+		return ctx.View().BadRequest(err)
+	}
+	if err := job.Cancel(j.DB, id, web.Tx(ctx)); err != nil {
+		return ctx.View().InternalError(err)
+	}
+	ctx.AddFlash(web.FlashSuccess, fmt.Sprintf("Job %d was cancelled.", id))
+	return ctx.RedirectWithMethodf("GET", "/admin/jobs")
+}
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/oauth"
+	"github.com/blend/go-sdk/web"
+)
+
+const (
+	// LoginPath is the admin login page; logged out admins are sent here.
+	LoginPath = "/admin/login"
+	// OAuthCallbackPath is where the openid connect provider sends admins back to.
+	OAuthCallbackPath = "/admin/login/oauth/callback"
+
+	// defaultAdminPath is where admins go after logging in if they weren't going anywhere.
+	defaultAdminPath = "/admin/reports"
+)
+
+// Login is the admin login, with an openid connect provider; passkey login is in `Passkeys`.
+// It handles:
+// - /admin/login
+// - /admin/login/oauth
+// - /admin/login/oauth/callback
+// - /admin/logout
+type Login struct {
+	Log *logger.Logger
+	// OAuth is the openid connect login; it's nil if it isn't configured.
+	OAuth *oauth.Manager
+	// Passkeys is if passkey login is configured, to offer it on the login page.
+	Passkeys bool
+}
+
+// Register adds routes for the controller.
+func (l Login) Register(app *web.App) {
+	app.Views().AddPaths("_views/flashes.html", "_views/admin/layout.html", "_views/admin/login.html")
+
+	app.Auth().WithLoginRedirectHandler(LoginRedirect)
+	app.GET(LoginPath, l.login)
+	app.GET("/admin/login/oauth", l.startOAuth)
+	app.GET(OAuthCallbackPath, l.finishOAuth)
+	app.POST("/admin/logout", l.logout, web.SessionAware)
+}
+
+// LoginRedirect sends logged out admins to the login page, and back to where they were going
+// once they log in; it's the auth manager's login redirect handler.
+func LoginRedirect(ctx *web.Ctx) *url.URL {
+	return &url.URL{Path: LoginPath, RawQuery: url.Values{"next": {ctx.Request().URL.RequestURI()}}.Encode()}
+}
+
+// login handles `GET /admin/login`
+func (l Login) login(ctx *web.Ctx) web.Result {
+	return ctx.View().View("admin_login", struct {
+		Next     string
+		OAuth    bool
+		Passkeys bool
+	}{
+		Next:     localPath(ctx.Request().URL.Query().Get("next")),
+		OAuth:    l.OAuth != nil,
+		Passkeys: l.Passkeys,
+	})
+}
+
+// startOAuth handles `GET /admin/login/oauth`
+func (l Login) startOAuth(ctx *web.Ctx) web.Result {
+	if l.OAuth == nil {
+		return ctx.View().NotFound()
+	}
+	authURL, err := l.OAuth.OAuthURL(ctx.Response(), localPath(ctx.Request().URL.Query().Get("next")))
+	if err != nil {
+		return ctx.View().InternalError(err)
+	}
+	return ctx.Redirectf("%s", authURL)
+}
+
+// finishOAuth handles `GET /admin/login/oauth/callback`
+// Admins are logged in by their email, which is what roles are given to, so the provider must
+// have verified it.
+func (l Login) finishOAuth(ctx *web.Ctx) web.Result {
+	if l.OAuth == nil {
+		return ctx.View().NotFound()
+	}
+	result, err := l.OAuth.Finish(ctx.Response(), ctx.Request())
+	if err != nil {
+		if l.Log != nil {
+			l.Log.Error(err)
+		}
+		ctx.AddFlash(web.FlashError, "We couldn't sign you in; please try again.")
+		return ctx.RedirectWithMethodf("GET", LoginPath)
+	}
+	if err := l.OAuth.ValidateProfile(result.Profile); err != nil {
+		return ctx.View().NotAuthorized()
+	}
+	if len(result.Profile.Email) == 0 || !result.Profile.VerifiedEmail {
+		return ctx.View().NotAuthorized()
+	}
+	if _, err := ctx.Auth().Login(strings.ToLower(result.Profile.Email), ctx); err != nil {
+		return ctx.View().InternalError(err)
+	}
+	var next string
+	if result.State != nil {
+		next = result.State.RedirectURL
+	}
+	return ctx.RedirectWithMethodf("GET", "%s", localPath(next))
+}
+
+// logout handles `POST /admin/logout`
+func (l Login) logout(ctx *web.Ctx) web.Result {
+	if ctx.Session() != nil {
+		if err := ctx.Auth().Logout(ctx); err != nil {
+			return ctx.View().InternalError(err)
+		}
+	}
+	ctx.AddFlash(web.FlashInfo, "You're signed out.")
+	return ctx.RedirectWithMethodf("GET", LoginPath)
+}
+
+// localPath returns a path if it's on this site, or the default admin page, so the login
+// can't be used to send someone somewhere else.
+func localPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return defaultAdminPath
+	}
+	return path
+}
@@ -0,0 +1,210 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/passkey"
+)
+
+// Passkeys is admin login with passkeys, and the admin page for adding them.
+// The browser side is `_static/passkey.js`; the json endpoints are for it.
+// It handles:
+// - /admin/passkeys
+// - /admin/passkeys/options
+// - /admin/passkeys/remove
+// - /admin/login/passkey/options
+// - /admin/login/passkey
+type Passkeys struct {
+	Log *logger.Logger
+	// Passkeys registers passkeys and logs in with them; it's nil if the site's origin isn't configured.
+	Passkeys *passkey.Manager
+}
+
+// Register adds routes for the controller.
+func (p Passkeys) Register(app *web.App) {
+	app.Views().AddPaths("_views/flashes.html", "_views/admin/layout.html", "_views/admin/passkeys.html")
+
+	app.GET("/admin/passkeys", p.list, AdminRequired)
+	app.POST("/admin/passkeys", p.register, AdminRequired)
+	app.POST("/admin/passkeys/options", p.registrationOptions, AdminRequired)
+	app.POST("/admin/passkeys/remove", p.remove, AdminRequired)
+	app.POST("/admin/login/passkey/options", p.loginOptions)
+	app.POST("/admin/login/passkey", p.login)
+}
+
+// passkeyRegistration is the body the script posts to register a passkey.
+type passkeyRegistration struct {
+	Name       string                       `json:"name" validate:"required,max=255"`
+	Credential passkey.RegistrationResponse `json:"credential"`
+}
+
+// passkeyRemoval is the form for removing a passkey.
+type passkeyRemoval struct {
+	ID int64 `form:"id" validate:"required"`
+}
+
+// passkeyLogin is the body the script posts to log in.
+type passkeyLogin struct {
+	Next       string                    `json:"next"`
+	Credential passkey.AssertionResponse `json:"credential"`
+}
+
+// passkeyResult is the response to the script: where to go next, or what went wrong.
+type passkeyResult struct {
+	Redirect string          `json:"redirect,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	Fields   web.FieldErrors `json:"fields,omitempty"`
+}
+
+// list handles `GET /admin/passkeys`
+func (p Passkeys) list(ctx *web.Ctx) web.Result {
+	var credentials []passkey.Credential
+	if p.Passkeys != nil {
+		var err error
+		if credentials, err = p.Passkeys.Credentials(ctx.Session().UserID, web.Tx(ctx)); err != nil {
+			return ctx.View().InternalError(err)
+		}
+	}
+	return ctx.View().View("admin_passkeys", struct {
+		Enabled     bool
+		Credentials []passkey.Credential
+	}{
+		Enabled:     p.Passkeys != nil,
+		Credentials: credentials,
+	})
+}
+
+// registrationOptions handles `POST /admin/passkeys/options`
+func (p Passkeys) registrationOptions(ctx *web.Ctx) web.Result {
+	if p.Passkeys == nil {
+		return ctx.JSON().NotFound()
+	}
+	options, err := p.Passkeys.BeginRegistration(ctx.Session().UserID, web.Tx(ctx))
+	if err != nil {
+		return passkeyInternalError(ctx, err)
+	}
+	return ctx.JSON().Result(options)
+}
+
+// register handles `POST /admin/passkeys`
+func (p Passkeys) register(ctx *web.Ctx) web.Result {
+	if p.Passkeys == nil {
+		return ctx.JSON().NotFound()
+	}
+	var body passkeyRegistration
+	fieldErrors, err := ctx.Bind(&body)
+	if err != nil {
+		return passkeyError(http.StatusBadRequest, "The request couldn't be read.")
+	}
+	if fieldErrors != nil {
+		return &web.JSONResult{StatusCode: http.StatusBadRequest, Response: passkeyResult{Error: "The name " + fieldErrors.Get("name") + ".", Fields: fieldErrors}}
+	}
+	credential, err := p.Passkeys.FinishRegistration(ctx.Session().UserID, body.Name, body.Credential, web.Tx(ctx))
+	if err != nil {
+		if exception.Is(err, passkey.ErrCredentialExists) {
+			return passkeyError(http.StatusConflict, "That passkey is already added.")
+		}
+		if p.isRejected(err) {
+			return passkeyError(http.StatusBadRequest, "The passkey couldn't be added; please try again.")
+		}
+		return passkeyInternalError(ctx, err)
+	}
+	ctx.AddFlash(web.FlashSuccess, "The passkey \""+credential.Name+"\" was added.")
+	return ctx.JSON().Result(passkeyResult{Redirect: "/admin/passkeys"})
+}
+
+// remove handles `POST /admin/passkeys/remove`
+func (p Passkeys) remove(ctx *web.Ctx) web.Result {
+	if p.Passkeys == nil {
+		return ctx.View().NotFound()
+	}
+	var form passkeyRemoval
+	fieldErrors, err := ctx.Bind(&form)
+	if err != nil {
+		return ctx.View().BadRequest(err)
+	}
+	if fieldErrors != nil {
+		return ctx.View().NotFound()
+	}
+	if err := p.Passkeys.Remove(ctx.Session().UserID, form.ID, web.Tx(ctx)); err != nil {
+		if exception.Is(err, passkey.ErrCredentialNotFound) {
+			return ctx.View().NotFound()
+		}
+		return ctx.View().InternalError(err)
+	}
+	ctx.AddFlash(web.FlashSuccess, "The passkey was removed.")
+	return ctx.RedirectWithMethodf("GET", "/admin/passkeys")
+}
+
+// loginOptions handles `POST /admin/login/passkey/options`
+func (p Passkeys) loginOptions(ctx *web.Ctx) web.Result {
+	if p.Passkeys == nil {
+		return ctx.JSON().NotFound()
+	}
+	options, err := p.Passkeys.BeginLogin(web.Tx(ctx))
+	if err != nil {
+		return passkeyInternalError(ctx, err)
+	}
+	return ctx.JSON().Result(options)
+}
+
+// login handles `POST /admin/login/passkey`
+// The passkey's user is logged in like one from the openid connect provider; their roles are
+// checked on each request as usual.
+func (p Passkeys) login(ctx *web.Ctx) web.Result {
+	if p.Passkeys == nil {
+		return ctx.JSON().NotFound()
+	}
+	var body passkeyLogin
+	if _, err := ctx.Bind(&body); err != nil {
+		return passkeyError(http.StatusBadRequest, "The request couldn't be read.")
+	}
+	credential, err := p.Passkeys.FinishLogin(body.Credential, web.Tx(ctx))
+	if err != nil {
+		if p.isRejected(err) {
+			return passkeyError(http.StatusForbidden, "That passkey couldn't be used to sign in.")
+		}
+		return passkeyInternalError(ctx, err)
+	}
+	if _, err := ctx.Auth().Login(credential.UserID, ctx); err != nil {
+		return passkeyInternalError(ctx, err)
+	}
+	return ctx.JSON().Result(passkeyResult{Redirect: localPath(body.Next)})
+}
+
+// isRejected returns if an error is a response that was turned down, rather than a failure;
+// it's logged, since the reason isn't shown.
+func (p Passkeys) isRejected(err error) bool {
+	for _, class := range []exception.Class{
+		passkey.ErrInvalidResponse,
+		passkey.ErrInvalidChallenge,
+		passkey.ErrUnsupportedKey,
+		passkey.ErrUnknownCredential,
+		passkey.ErrSignCount,
+	} {
+		if exception.Is(err, class) {
+			if p.Log != nil {
+				p.Log.Warning(err)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// passkeyError returns an error response for the script to show.
+func passkeyError(statusCode int, message string) web.Result {
+	return &web.JSONResult{StatusCode: statusCode, Response: passkeyResult{Error: message}}
+}
+
+// passkeyInternalError logs an error and returns a response that doesn't leak it.
+func passkeyInternalError(ctx *web.Ctx, err error) web.Result {
+	if log := ctx.Logger(); log != nil {
+		log.Error(err)
+	}
+	return passkeyError(http.StatusInternalServerError, "Something went wrong; please try again.")
+}
@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/rbac"
+	"github.com/wcharczuk/katwillmarry.com/pkg/reminder"
+)
+
+// remindersSentPageSize is the number of sent reminders listed on the admin page.
+const remindersSentPageSize = 100
+
+// Reminders is the admin page for rsvp reminders.
+// It handles:
+// - /admin/reminders
+// - /admin/reminders/run
+type Reminders struct {
+	Log       *logger.Logger
+	DB        *db.Connection
+	Scheduler *reminder.Scheduler
+}
+
+// Register adds routes for the controller.
+func (r Reminders) Register(app *web.App) {
+	app.Views().AddPaths("_views/flashes.html", "_views/admin/layout.html", "_views/admin/reminders.html")
+
+	app.GET("/admin/reminders", r.preview, rbac.RequireRole(rbac.Owner, rbac.Planner))
+	app.POST("/admin/reminders/run", r.run, rbac.RequireRole(rbac.Owner, rbac.Planner))
+}
+
+// preview handles `GET /admin/reminders`
+// It shows who each rule's reminder would go to, and the reminders already queued.
+func (r Reminders) preview(ctx *web.Ctx) web.Result {
+	planned, err := r.Scheduler.Preview(time.Now().UTC(), web.Tx(ctx))
+	if err != nil {
+		return ctx.View().InternalError(err)
+	}
+	sent, err := reminder.Sends(r.DB, remindersSentPageSize, web.Tx(ctx))
+	if err != nil {
+		return ctx.View().InternalError(err)
+	}
+	return ctx.View().View("admin_reminders", struct {
+		Deadline time.Time
+		Planned  []reminder.Planned
+		Sent     []reminder.Send
+	}{
+		Deadline: r.Scheduler.Deadline(),
+		Planned:  planned,
+		Sent:     sent,
+	})
+}
+
+// run handles `POST /admin/reminders/run`
+// It queues the due reminders now rather than waiting for the scheduler.
+func (r Reminders) run(ctx *web.Ctx) web.Result {
+	queued, err := r.Scheduler.Run(time.Now().UTC())
+	if err != nil {
+		return ctx.View().InternalError(err)
+	}
+	if r.Log != nil {
+		r.Log.Infof("%s queued %d rsvp reminders", ctx.Session().UserID, queued)
+	}
+	if queued == 0 {
+		ctx.AddFlash(web.FlashInfo, "No reminders are due.")
+	} else {
+		ctx.AddFlash(web.FlashSuccess, fmt.Sprintf("Queued %d reminders.", queued))
+	}
+	return ctx.RedirectWithMethodf("GET", "/admin/reminders")
+}
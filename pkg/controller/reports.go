@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/report"
+)
+
+// Reports is the admin page for the guest list reports; each report is limited to its roles.
+// It handles:
+// - /admin/reports
+// - /admin/reports/:name
+type Reports struct {
+	Log *logger.Logger
+	DB  *db.Connection
+}
+
+// Register adds routes for the controller.
+func (r Reports) Register(app *web.App) {
+	app.Views().AddPaths("_views/flashes.html", "_views/admin/layout.html", "_views/admin/reports.html")
+
+	app.GET("/admin/reports", r.list, AdminRequired)
+	app.GET("/admin/reports/:name", r.show, AdminRequired)
+}
+
+// list handles `GET /admin/reports`
+func (r Reports) list(ctx *web.Ctx) web.Result {
+	return ctx.View().View("admin_reports", struct {
+		Reports []report.Report
+	}{
+		Reports: report.Allowed(ctx.Session()),
+	})
+}
+
+// show handles `GET /admin/reports/:name`
+func (r Reports) show(ctx *web.Ctx) web.Result {
+	name, err := ctx.RouteParam("name")
+	if err != nil {
+		return ctx.View().BadRequest(err)
+	}
+	found, ok := report.Get(name)
+	if !ok {
+		return ctx.View().NotFound()
+	}
+	if !found.Allowed(ctx.Session()) {
+		return ctx.View().NotAuthorized()
+	}
+	table, err := found.Run(r.DB, web.Tx(ctx))
+	if err != nil {
+		return ctx.View().InternalError(err)
+	}
+	return ctx.View().View("admin_report", struct {
+		Report report.Report
+		Table  *report.Table
+	}{
+		Report: found,
+		Table:  table,
+	})
+}
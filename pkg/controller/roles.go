@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/rbac"
+)
+
+// Roles is the admin page for giving admin users roles; only owners can use it.
+// It handles:
+// - /admin/roles
+// - /admin/roles/remove
+type Roles struct {
+	Log   *logger.Logger
+	Store *rbac.Store
+}
+
+// Register adds routes for the controller.
+func (r Roles) Register(app *web.App) {
+	app.Views().AddPaths("_views/flashes.html", "_views/admin/layout.html", "_views/admin/roles.html")
+
+	app.GET("/admin/roles", r.list, rbac.RequireRole(rbac.Owner))
+	app.POST("/admin/roles", r.assign, rbac.RequireRole(rbac.Owner))
+	app.POST("/admin/roles/remove", r.remove, rbac.RequireRole(rbac.Owner))
+}
+
+// roleForm is the form for giving or taking a role.
+type roleForm struct {
+	UserID string `form:"user_id" validate:"required,email,max=255"`
+	Role   string `form:"role" validate:"required,oneof=owner planner caterer photographer"`
+}
+
+// list handles `GET /admin/roles`
+func (r Roles) list(ctx *web.Ctx) web.Result {
+	return r.view(ctx, roleForm{}, nil)
+}
+
+// assign handles `POST /admin/roles`
+func (r Roles) assign(ctx *web.Ctx) web.Result {
+	var form roleForm
+	fieldErrors, err := ctx.Bind(&form)
+	if err != nil {
+		return ctx.View().BadRequest(err)
+	}
+	if fieldErrors != nil {
+		return r.view(ctx, form, fieldErrors)
+	}
+	userID := rbac.NormalizeUserID(form.UserID)
+	if err := r.Store.Assign(ctx.Session().UserID, userID, form.Role, web.Tx(ctx)); err != nil {
+		return ctx.View().InternalError(err)
+	}
+	ctx.AddFlash(web.FlashSuccess, userID+" is now a "+form.Role+".")
+	return ctx.RedirectWithMethodf("GET", "/admin/roles")
+}
+
+// remove handles `POST /admin/roles/remove`
+func (r Roles) remove(ctx *web.Ctx) web.Result {
+	// the form is from the list of roles given out, so it isn't validated; a role that wasn't
+	// given isn't found.
+	var form roleForm
+	if _, err := ctx.Bind(&form); err != nil {
+		return ctx.View().BadRequest(err)
+	}
+	if err := r.Store.Unassign(ctx.Session().UserID, form.UserID, form.Role, web.Tx(ctx)); err != nil {
+		if exception.Is(err, rbac.ErrAssignmentNotFound) {
+			return ctx.View().NotFound()
+		}
+		return ctx.View().InternalError(err)
+	}
+	ctx.AddFlash(web.FlashSuccess, rbac.NormalizeUserID(form.UserID)+" is no longer a "+form.Role+".")
+	return ctx.RedirectWithMethodf("GET", "/admin/roles")
+}
+
+func (r Roles) view(ctx *web.Ctx, form roleForm, fieldErrors web.FieldErrors) web.Result {
+	assignments, err := r.Store.All(web.Tx(ctx))
+	if err != nil {
+		return ctx.View().InternalError(err)
+	}
+	return ctx.View().View("admin_roles", struct {
+		Form        roleForm
+		Errors      web.FieldErrors
+		Roles       []string
+		Assignments []rbac.Assignment
+	}{
+		Form:        form,
+		Errors:      fieldErrors,
+		Roles:       rbac.Roles,
+		Assignments: assignments,
+	})
+}
@@ -0,0 +1,281 @@
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/audit"
+	"github.com/wcharczuk/katwillmarry.com/pkg/gate"
+	"github.com/wcharczuk/katwillmarry.com/pkg/job"
+	"github.com/wcharczuk/katwillmarry.com/pkg/magiclink"
+	"github.com/wcharczuk/katwillmarry.com/pkg/metrics"
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/query"
+)
+
+// RSVP is the guest side of the site; guests log in to their household with an emailed link
+// and answer for everyone in it.
+// It handles:
+// - /rsvp
+// - /rsvp/login
+// - /rsvp/login/:token
+// - /rsvp/logout
+type RSVP struct {
+	Log   *logger.Logger
+	DB    *db.Connection
+	Links *magiclink.Manager
+	// Gate is the site password gate; a guest who logs in with a link is let through it.
+	Gate *gate.Gate
+}
+
+// Register adds routes for the controller.
+func (r RSVP) Register(app *web.App) {
+	app.Views().AddPaths(
+		"_views/flashes.html",
+		"_views/rsvp/layout.html",
+		"_views/rsvp/login.html",
+		"_views/rsvp/confirm.html",
+		"_views/rsvp/household.html",
+	)
+
+	app.GET("/rsvp", r.household, HouseholdRequired)
+	app.POST("/rsvp", r.respond, HouseholdRequired)
+	app.GET("/rsvp/login", r.login)
+	app.POST("/rsvp/login", r.sendLink)
+	app.GET("/rsvp/login/:token", r.confirm)
+	app.POST("/rsvp/login/:token", r.redeem)
+	app.POST("/rsvp/logout", r.logout, web.SessionAware)
+}
+
+// loginForm is the form for requesting a login link.
+type loginForm struct {
+	Email string `form:"email" validate:"required,email,max=255"`
+}
+
+// rsvpForm is the household's answer to the invitation.
+type rsvpForm struct {
+	// Attending are the ids of the guests who are coming; the rest of the household isn't.
+	Attending []string `form:"attending"`
+	Message   string   `form:"message" validate:"max=2000"`
+}
+
+// attending returns if a guest was marked as coming.
+func (rf rsvpForm) attending(guestID int64) bool {
+	for _, id := range rf.Attending {
+		if id == strconv.FormatInt(guestID, 10) {
+			return true
+		}
+	}
+	return false
+}
+
+// household handles `GET /rsvp`
+func (r RSVP) household(ctx *web.Ctx) web.Result {
+	household, guests, rsvps, err := r.load(ctx)
+	if err != nil {
+		return ctx.View().InternalError(err)
+	}
+	if household == nil {
+		return ctx.View().NotFound()
+	}
+	var form rsvpForm
+	for _, rsvp := range rsvps {
+		if rsvp.Attending {
+			form.Attending = append(form.Attending, strconv.FormatInt(rsvp.GuestID, 10))
+		}
+		if len(rsvp.Message) > 0 {
+			form.Message = rsvp.Message
+		}
+	}
+	return r.householdView(ctx, *household, guests, rsvps, form, nil)
+}
+
+// respond handles `POST /rsvp`
+// Everyone in the household is answered for at once; guests that aren't checked aren't coming.
+func (r RSVP) respond(ctx *web.Ctx) web.Result {
+	household, guests, rsvps, err := r.load(ctx)
+	if err != nil {
+		return ctx.View().InternalError(err)
+	}
+	if household == nil {
+		return ctx.View().NotFound()
+	}
+	var form rsvpForm
+	fieldErrors, err := ctx.Bind(&form)
+	if err != nil {
+		return ctx.View().BadRequest(err)
+	}
+	if fieldErrors != nil {
+		return r.householdView(ctx, *household, guests, rsvps, form, fieldErrors)
+	}
+
+	principal := ctx.Session().UserID
+	invocation := audit.Invoke(r.DB, principal, web.Tx(ctx))
+	now := time.Now().UTC()
+	for _, guest := range guests {
+		rsvp, existing := rsvps[guest.ID]
+		if !existing {
+			rsvp = &model.RSVP{GuestID: guest.ID, HouseholdID: household.ID}
+		}
+		rsvp.Attending = form.attending(guest.ID)
+		rsvp.Message = strings.TrimSpace(form.Message)
+		rsvp.RespondedUTC = now
+		if existing {
+			err = invocation.Update(rsvp)
+		} else {
+			err = invocation.Create(rsvp)
+		}
+		if db.IsVersionConflict(err) {
+			ctx.AddFlash(web.FlashError, "Someone else in your household answered at the same time; check the answers below and send them again.")
+			return ctx.RedirectWithMethodf("GET", "/rsvp")
+		}
+		if err != nil {
+			return ctx.View().InternalError(err)
+		}
+		if r.Log != nil {
+			r.Log.Trigger(metrics.RSVPEvent(principal, rsvp.Attending))
+		}
+	}
+	ctx.AddFlash(web.FlashSuccess, "Thanks! We got your RSVP.")
+	return ctx.RedirectWithMethodf("GET", "/rsvp")
+}
+
+// load reads the household of the guest logged in, its guests and their rsvps by guest id;
+// the household is nil if it doesn't exist.
+func (r RSVP) load(ctx *web.Ctx) (*model.Household, []model.Guest, map[int64]*model.RSVP, error) {
+	var household model.Household
+	if err := r.DB.Invoke(web.Tx(ctx)).Get(&household, householdID(ctx)); err != nil {
+		return nil, nil, nil, err
+	}
+	if household.ID == 0 {
+		return nil, nil, nil, nil
+	}
+	guests, err := household.Guests(r.DB, web.Tx(ctx))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var rsvps []model.RSVP
+	if err := query.Select(model.RSVP{}).Where(query.Eq("household_id", household.ID)).OutMany(r.DB, &rsvps, web.Tx(ctx)); err != nil {
+		return nil, nil, nil, err
+	}
+	byGuest := map[int64]*model.RSVP{}
+	for index := range rsvps {
+		byGuest[rsvps[index].GuestID] = &rsvps[index]
+	}
+	return &household, guests, byGuest, nil
+}
+
+func (r RSVP) householdView(ctx *web.Ctx, household model.Household, guests []model.Guest, rsvps map[int64]*model.RSVP, form rsvpForm, fieldErrors web.FieldErrors) web.Result {
+	attending := map[int64]bool{}
+	for _, guest := range guests {
+		attending[guest.ID] = form.attending(guest.ID)
+	}
+	return ctx.View().View("rsvp_household", struct {
+		Household model.Household
+		Guests    []model.Guest
+		RSVPs     map[int64]*model.RSVP
+		Attending map[int64]bool
+		Form      rsvpForm
+		Errors    web.FieldErrors
+	}{
+		Household: household,
+		Guests:    guests,
+		RSVPs:     rsvps,
+		Attending: attending,
+		Form:      form,
+		Errors:    fieldErrors,
+	})
+}
+
+// login handles `GET /rsvp/login`
+func (r RSVP) login(ctx *web.Ctx) web.Result {
+	return r.loginView(ctx, loginForm{}, nil)
+}
+
+// sendLink handles `POST /rsvp/login`
+// The response is the same whether or not the email is on the guest list, so the form can't be
+// used to find out who's invited; the link is sent by a job (see `magiclink.Handler`) so the
+// request takes the same time either way too.
+func (r RSVP) sendLink(ctx *web.Ctx) web.Result {
+	var form loginForm
+	fieldErrors, err := ctx.Bind(&form)
+	if err != nil {
+		return ctx.View().BadRequest(err)
+	}
+	if fieldErrors != nil {
+		return r.loginView(ctx, form, fieldErrors)
+	}
+	if _, err := job.Enqueue(r.DB, magiclink.Args{Email: strings.TrimSpace(form.Email)}, web.Tx(ctx)); err != nil {
+		return ctx.View().InternalError(err)
+	}
+
+	ctx.AddFlash(web.FlashInfo, fmt.Sprintf("If %s is on the guest list, we're sending it a link to your RSVP. The link works once, for the next %s.",
+		strings.TrimSpace(form.Email), r.Links.DescribeTTL()))
+	return ctx.RedirectWithMethodf("GET", "/rsvp/login")
+}
+
+// confirm handles `GET /rsvp/login/:token`
+// The link is redeemed by a form post rather than on the get, so email scanners that follow links
+// don't use it up before the guest clicks it.
+func (r RSVP) confirm(ctx *web.Ctx) web.Result {
+	token, err := ctx.RouteParam("token")
+	if err != nil {
+		return ctx.View().BadRequest(err)
+	}
+	return ctx.View().View("rsvp_confirm", struct {
+		Token string
+	}{
+		Token: token,
+	})
+}
+
+// redeem handles `POST /rsvp/login/:token`
+func (r RSVP) redeem(ctx *web.Ctx) web.Result {
+	token, err := ctx.RouteParam("token")
+	if err != nil {
+		return ctx.View().BadRequest(err)
+	}
+	link, err := r.Links.Redeem(token, web.Tx(ctx))
+	if err != nil {
+		if exception.Is(err, magiclink.ErrInvalidLink) || exception.Is(err, magiclink.ErrLinkExpired) || exception.Is(err, magiclink.ErrLinkUsed) {
+			ctx.AddFlash(web.FlashError, "That link has expired or was already used; enter your email for a new one.")
+			return ctx.RedirectWithMethodf("GET", "/rsvp/login")
+		}
+		return ctx.View().InternalError(err)
+	}
+	if _, err := ctx.Auth().Login(model.HouseholdUserID(link.HouseholdID), ctx); err != nil {
+		return ctx.View().InternalError(err)
+	}
+	if r.Gate.Enabled() {
+		r.Gate.Admit(ctx)
+	}
+	return ctx.RedirectWithMethodf("GET", "/rsvp")
+}
+
+// logout handles `POST /rsvp/logout`
+func (r RSVP) logout(ctx *web.Ctx) web.Result {
+	if ctx.Session() != nil {
+		if err := ctx.Auth().Logout(ctx); err != nil {
+			return ctx.View().InternalError(err)
+		}
+	}
+	ctx.AddFlash(web.FlashInfo, "You're signed out.")
+	return ctx.RedirectWithMethodf("GET", "/rsvp/login")
+}
+
+func (r RSVP) loginView(ctx *web.Ctx, form loginForm, fieldErrors web.FieldErrors) web.Result {
+	return ctx.View().View("rsvp_login", struct {
+		Form   loginForm
+		Errors web.FieldErrors
+	}{
+		Form:   form,
+		Errors: fieldErrors,
+	})
+}
@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/rbac"
+	"github.com/wcharczuk/katwillmarry.com/pkg/session"
+)
+
+// Sessions is the admin page for logged in sessions.
+// It handles:
+// - /admin/sessions
+// - /admin/sessions/:handle/revoke
+type Sessions struct {
+	Log   *logger.Logger
+	Store *session.Store
+}
+
+// Register adds routes for the controller.
+func (s Sessions) Register(app *web.App) {
+	app.Views().AddPaths("_views/flashes.html", "_views/admin/layout.html", "_views/admin/sessions.html")
+
+	app.GET("/admin/sessions", s.list, rbac.RequireRole(rbac.Owner))
+	app.POST("/admin/sessions/:handle/revoke", s.revoke, rbac.RequireRole(rbac.Owner))
+}
+
+// list handles `GET /admin/sessions`
+func (s Sessions) list(ctx *web.Ctx) web.Result {
+	active, err := s.Store.Active(web.Tx(ctx))
+	if err != nil {
+		return ctx.View().InternalError(err)
+	}
+	return ctx.View().View("admin_sessions", struct {
+		Current  string
+		Sessions []session.Record
+	}{
+		Current:  session.Record{SessionID: ctx.Session().SessionID}.Handle(),
+		Sessions: active,
+	})
+}
+
+// revoke handles `POST /admin/sessions/:handle/revoke`
+func (s Sessions) revoke(ctx *web.Ctx) web.Result {
+	handle, err := ctx.RouteParam("handle")
+	if err != nil {
+		return ctx.View().BadRequest(err)
+	}
+	if err := s.Store.Revoke(ctx.Session().UserID, handle, web.Tx(ctx)); err != nil {
+		if exception.Is(err, session.ErrSessionNotFound) {
+			return ctx.View().NotFound()
+		}
+		return ctx.View().InternalError(err)
+	}
+	ctx.AddFlash(web.FlashSuccess, "The session was revoked.")
+	return ctx.RedirectWithMethodf("GET", "/admin/sessions")
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/notify"
+)
+
+const (
+	// smsPrincipal is the audit principal for changes made by guests texting the site's number.
+	smsPrincipal = "sms"
+
+	// emptyTwiML is the webhook response that doesn't reply to the message;
+	// the provider sends the carrier required STOP and START confirmations itself.
+	emptyTwiML = `<?xml version="1.0" encoding="UTF-8"?><Response></Response>`
+)
+
+// SMS is the webhook for text messages sent to the site's number.
+// It handles:
+// - /webhooks/sms
+type SMS struct {
+	Log      *logger.Logger
+	DB       *db.Connection
+	Receiver notify.SMSReceiver
+}
+
+// Register adds routes for the controller.
+func (s SMS) Register(app *web.App) {
+	app.POST("/webhooks/sms", s.receive)
+}
+
+// receive handles `POST /webhooks/sms`
+// STOP opts the guests with the sender's number out of text messages, START opts them back in;
+// anything else is ignored.
+func (s SMS) receive(ctx *web.Ctx) web.Result {
+	inbound, err := s.Receiver.ReceiveSMS(ctx.Request())
+	if err != nil {
+		if exception.Is(err, notify.ErrInvalidSignature) {
+			return ctx.Text().NotAuthorized()
+		}
+		return ctx.Text().BadRequest(err)
+	}
+
+	optOut, optIn := notify.IsOptOut(inbound.Body), notify.IsOptIn(inbound.Body)
+	if optOut || optIn {
+		changed, err := model.SetSMSOptOut(s.DB, smsPrincipal, inbound.From, optOut, web.Tx(ctx))
+		if err != nil {
+			return ctx.Text().InternalError(err)
+		}
+		if changed > 0 && s.Log != nil {
+			s.Log.Infof("sms opt out: %t for %d guests", optOut, changed)
+		}
+	}
+	return ctx.RawWithContentType("text/xml; charset=utf-8", []byte(emptyTwiML))
+}
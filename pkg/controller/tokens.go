@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"strings"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/apitoken"
+	"github.com/wcharczuk/katwillmarry.com/pkg/rbac"
+)
+
+// Tokens is the admin page for api tokens.
+// It handles:
+// - /admin/tokens
+// - /admin/tokens/:id/revoke
+type Tokens struct {
+	Log *logger.Logger
+	DB  *db.Connection
+}
+
+// Register adds routes for the controller.
+func (t Tokens) Register(app *web.App) {
+	app.Views().AddPaths("_views/flashes.html", "_views/admin/layout.html", "_views/admin/tokens.html")
+
+	app.GET("/admin/tokens", t.list, rbac.RequireRole(rbac.Owner))
+	app.POST("/admin/tokens", t.create, rbac.RequireRole(rbac.Owner))
+	app.POST("/admin/tokens/:id/revoke", t.revoke, rbac.RequireRole(rbac.Owner))
+}
+
+// tokenForm is the form for creating a token.
+type tokenForm struct {
+	Name  string `form:"name" validate:"required,max=255"`
+	Scope string `form:"scope" validate:"required,oneof=read write"`
+}
+
+// list handles `GET /admin/tokens`
+func (t Tokens) list(ctx *web.Ctx) web.Result {
+	return t.view(ctx, "", tokenForm{Scope: apitoken.ScopeRead}, nil)
+}
+
+// create handles `POST /admin/tokens`
+// The secret is shown on the response page rather than redirecting, since it's only shown once.
+func (t Tokens) create(ctx *web.Ctx) web.Result {
+	var form tokenForm
+	fieldErrors, err := ctx.Bind(&form)
+	if err != nil {
+		return ctx.View().BadRequest(err)
+	}
+	if fieldErrors != nil {
+		return t.view(ctx, "", form, fieldErrors)
+	}
+	secret, _, err := apitoken.Create(t.DB, ctx.Session().UserID, strings.TrimSpace(form.Name), form.Scope, web.Tx(ctx))
+	if err != nil {
+		return ctx.View().InternalError(err)
+	}
+	return t.view(ctx, secret, tokenForm{Scope: apitoken.ScopeRead}, nil)
+}
+
+// revoke handles `POST /admin/tokens/:id/revoke`
+func (t Tokens) revoke(ctx *web.Ctx) web.Result {
+	id, err := ctx.RouteParamInt64("id")
+	if err != nil {
+		return ctx.View().BadRequest(err)
+	}
+	if err := apitoken.Revoke(t.DB, ctx.Session().UserID, id, web.Tx(ctx)); err != nil {
+		if exception.Is(err, apitoken.ErrTokenNotFound) {
+			return ctx.View().NotFound()
+		}
+		return ctx.View().InternalError(err)
+	}
+	ctx.AddFlash(web.FlashSuccess, "The token was revoked.")
+	return ctx.RedirectWithMethodf("GET", "/admin/tokens")
+}
+
+func (t Tokens) view(ctx *web.Ctx, secret string, form tokenForm, fieldErrors web.FieldErrors) web.Result {
+	tokens, err := apitoken.All(t.DB, web.Tx(ctx))
+	if err != nil {
+		return ctx.View().InternalError(err)
+	}
+	return ctx.View().View("admin_tokens", struct {
+		Secret string
+		Form   tokenForm
+		Errors web.FieldErrors
+		Tokens []apitoken.Token
+		Scopes []string
+	}{
+		Secret: secret,
+		Form:   form,
+		Errors: fieldErrors,
+		Tokens: tokens,
+		Scopes: apitoken.Scopes,
+	})
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/audit"
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/query"
+	"github.com/wcharczuk/katwillmarry.com/pkg/rbac"
+	"github.com/wcharczuk/katwillmarry.com/pkg/softdelete"
+)
+
+// trashPageSize is the number of deleted rows of each type listed on the admin page.
+const trashPageSize = 100
+
+// Trash is the admin page for guest list rows that were deleted and haven't been purged yet.
+// It handles:
+// - /admin/trash
+// - /admin/trash/:entity/:id/restore
+type Trash struct {
+	Log *logger.Logger
+	DB  *db.Connection
+}
+
+// Register adds routes for the controller.
+func (t Trash) Register(app *web.App) {
+	app.Views().AddPaths("_views/flashes.html", "_views/admin/layout.html", "_views/admin/trash.html")
+
+	app.GET("/admin/trash", t.list, rbac.RequireRole(rbac.Owner, rbac.Planner))
+	app.POST("/admin/trash/:entity/:id/restore", t.restore, rbac.RequireRole(rbac.Owner, rbac.Planner))
+}
+
+// list handles `GET /admin/trash`
+func (t Trash) list(ctx *web.Ctx) web.Result {
+	var households []model.Household
+	if err := query.Select(model.Household{}).OnlyDeleted().OrderBy(query.Desc("deleted_utc")).Limit(trashPageSize).OutMany(t.DB, &households, web.Tx(ctx)); err != nil {
+		return ctx.View().InternalError(err)
+	}
+	var guests []model.Guest
+	if err := query.Select(model.Guest{}).OnlyDeleted().OrderBy(query.Desc("deleted_utc")).Limit(trashPageSize).OutMany(t.DB, &guests, web.Tx(ctx)); err != nil {
+		return ctx.View().InternalError(err)
+	}
+	var events []model.Event
+	if err := query.Select(model.Event{}).OnlyDeleted().OrderBy(query.Desc("deleted_utc")).Limit(trashPageSize).OutMany(t.DB, &events, web.Tx(ctx)); err != nil {
+		return ctx.View().InternalError(err)
+	}
+	return ctx.View().View("admin_trash", struct {
+		Households []model.Household
+		Guests     []model.Guest
+		Events     []model.Event
+	}{
+		Households: households,
+		Guests:     guests,
+		Events:     events,
+	})
+}
+
+// restore handles `POST /admin/trash/:entity/:id/restore`
+func (t Trash) restore(ctx *web.Ctx) web.Result {
+	entity, err := ctx.RouteParam("entity")
+	if err != nil {
+		return ctx.View().BadRequest(err)
+	}
+	id, err := ctx.RouteParamInt64("id")
+	if err != nil {
+		return ctx.View().BadRequest(err)
+	}
+
+	var object db.DatabaseMapped
+	switch entity {
+	case model.Household{}.TableName():
+		object = &model.Household{}
+	case model.Guest{}.TableName():
+		object = &model.Guest{}
+	case model.Event{}.TableName():
+		object = &model.Event{}
+	default:
+		return ctx.View().NotFound()
+	}
+	if err := t.DB.Invoke(web.Tx(ctx)).WithDeleted().Get(object, id); err != nil {
+		return ctx.View().InternalError(err)
+	}
+	// a row that isn't found, or isn't deleted, has nothing to restore.
+	if !softdelete.IsDeleted(object) {
+		return ctx.View().NotFound()
+	}
+	if err := audit.Invoke(t.DB, ctx.Session().UserID, web.Tx(ctx)).Restore(object); err != nil {
+		return ctx.View().InternalError(err)
+	}
+	ctx.AddFlash(web.FlashSuccess, "The "+entity+" was restored.")
+	return ctx.RedirectWithMethodf("GET", "/admin/trash")
+}
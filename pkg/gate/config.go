@@ -0,0 +1,67 @@
+package gate
+
+import "github.com/blend/go-sdk/util"
+
+// DefaultCookieName is the default name of the cookie that remembers a browser got past the gate.
+const DefaultCookieName = "SITE_GATE"
+
+var (
+	// DefaultHealthPaths are the default health check paths, for load balancers that check the app
+	// rather than the healthz sidecar.
+	DefaultHealthPaths = []string{"/healthz", "/readyz", "/varz"}
+	// DefaultDeepLinkPaths are the default deep link path prefixes; the rsvp login links guests
+	// are emailed.
+	DefaultDeepLinkPaths = []string{"/rsvp/login/"}
+	// DefaultExemptPaths are the default path prefixes of routes that authenticate on their own,
+	// ex. the sms webhook with twilio's signature. Api routes that need a token are let through by
+	// their route meta (see `Gate.Middleware`), so the public api reads still need the passphrase.
+	DefaultExemptPaths = []string{"/webhooks/"}
+)
+
+// Config is the site password gate config.
+type Config struct {
+	// Passphrase is the passphrase shared with guests; the gate is off if it's unset.
+	Passphrase string `json:"passphrase,omitempty" yaml:"passphrase,omitempty" env:"SITE_PASSPHRASE"`
+	// CookieName is the name of the cookie that remembers a browser got past the gate.
+	CookieName string `json:"cookieName,omitempty" yaml:"cookieName,omitempty" env:"SITE_GATE_COOKIE"`
+	// HealthPaths are the health check paths let through the gate; they're matched exactly.
+	HealthPaths []string `json:"healthPaths,omitempty" yaml:"healthPaths,omitempty" env:"SITE_GATE_HEALTH_PATHS,csv"`
+	// DeepLinkPaths are the path prefixes of links guests are sent that are let through the gate.
+	DeepLinkPaths []string `json:"deepLinkPaths,omitempty" yaml:"deepLinkPaths,omitempty" env:"SITE_GATE_DEEP_LINK_PATHS,csv"`
+	// ExemptPaths are the path prefixes of any other routes let through the gate.
+	ExemptPaths []string `json:"exemptPaths,omitempty" yaml:"exemptPaths,omitempty" env:"SITE_GATE_EXEMPT_PATHS,csv"`
+}
+
+// IsZero returns if the config doesn't have a passphrase, and so the gate is off.
+func (c Config) IsZero() bool {
+	return len(c.Passphrase) == 0
+}
+
+// GetCookieName returns a property or a default.
+func (c Config) GetCookieName(inherited ...string) string {
+	return util.Coalesce.String(c.CookieName, DefaultCookieName, inherited...)
+}
+
+// GetHealthPaths returns a property or a default.
+func (c Config) GetHealthPaths() []string {
+	if len(c.HealthPaths) > 0 {
+		return c.HealthPaths
+	}
+	return DefaultHealthPaths
+}
+
+// GetDeepLinkPaths returns a property or a default.
+func (c Config) GetDeepLinkPaths() []string {
+	if len(c.DeepLinkPaths) > 0 {
+		return c.DeepLinkPaths
+	}
+	return DefaultDeepLinkPaths
+}
+
+// GetExemptPaths returns a property or a default.
+func (c Config) GetExemptPaths() []string {
+	if len(c.ExemptPaths) > 0 {
+		return c.ExemptPaths
+	}
+	return DefaultExemptPaths
+}
@@ -0,0 +1,180 @@
+// Package gate keeps the site private behind a passphrase shared with guests, for before the
+// invites go out.
+//
+// A browser that enters the passphrase gets a cookie, for as long as the browser is open, signed
+// over the passphrase; changing the passphrase sends everyone back to the gate.
+package gate
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"net/url"
+	"strings"
+
+	"github.com/blend/go-sdk/util"
+	"github.com/blend/go-sdk/web"
+)
+
+const (
+	// Path is the path of the passphrase page.
+	Path = "/gate"
+	// QueryNext is the query string value with the page to send a browser to once it's through.
+	QueryNext = "next"
+	// StaticPath is the path prefix of static files, which are always let through; the passphrase
+	// page needs its styles.
+	StaticPath = "/static/"
+
+	// keySize is the size of the key generated when one isn't given.
+	keySize = 64
+)
+
+// New returns a new gate for a passphrase, with cookies signed with a key.
+// If the key is empty a random one is used, and browsers go back through the gate when the
+// process restarts.
+func New(passphrase string, key []byte) *Gate {
+	if len(key) == 0 {
+		key = util.Crypto.MustCreateKey(keySize)
+	}
+	return &Gate{
+		passphrase:    passphrase,
+		key:           key,
+		cookieName:    DefaultCookieName,
+		healthPaths:   DefaultHealthPaths,
+		deepLinkPaths: DefaultDeepLinkPaths,
+		exemptPaths:   DefaultExemptPaths,
+	}
+}
+
+// NewFromConfig returns a new gate from a config.
+func NewFromConfig(cfg *Config, key []byte) *Gate {
+	return New(cfg.Passphrase, key).
+		WithCookieName(cfg.GetCookieName()).
+		WithHealthPaths(cfg.GetHealthPaths()...).
+		WithDeepLinkPaths(cfg.GetDeepLinkPaths()...).
+		WithExemptPaths(cfg.GetExemptPaths()...)
+}
+
+// Gate is the site password gate.
+type Gate struct {
+	passphrase    string
+	key           []byte
+	cookieName    string
+	healthPaths   []string
+	deepLinkPaths []string
+	exemptPaths   []string
+}
+
+// WithCookieName sets the name of the cookie that remembers a browser got through.
+func (g *Gate) WithCookieName(name string) *Gate {
+	g.cookieName = name
+	return g
+}
+
+// WithHealthPaths sets the health check paths let through; they're matched exactly.
+func (g *Gate) WithHealthPaths(paths ...string) *Gate {
+	g.healthPaths = paths
+	return g
+}
+
+// WithDeepLinkPaths sets the path prefixes of links guests are sent that are let through.
+func (g *Gate) WithDeepLinkPaths(paths ...string) *Gate {
+	g.deepLinkPaths = paths
+	return g
+}
+
+// WithExemptPaths sets the path prefixes of any other routes let through.
+func (g *Gate) WithExemptPaths(paths ...string) *Gate {
+	g.exemptPaths = paths
+	return g
+}
+
+// Enabled returns if the gate has a passphrase; without one everything is let through.
+func (g *Gate) Enabled() bool {
+	return g != nil && len(g.passphrase) > 0
+}
+
+// Middleware is the default middleware that sends browsers that haven't entered the passphrase
+// to the passphrase page, and back to where they were going once they have.
+// Routes described as secured (`web.RouteMeta.Secured`, ex. the api routes that need a token)
+// check credentials of their own and are let through.
+func (g *Gate) Middleware(action web.Action) web.Action {
+	return func(ctx *web.Ctx) web.Result {
+		if !g.Enabled() || g.Exempt(ctx.Request().URL.Path) || isSecured(ctx.Route()) || g.Admitted(ctx) {
+			return action(ctx)
+		}
+		next := ctx.Request().URL.RequestURI()
+		if ctx.Request().Method != "GET" {
+			next = "/"
+		}
+		return ctx.RedirectWithMethodf("GET", "%s?%s=%s", Path, QueryNext, url.QueryEscape(next))
+	}
+}
+
+// Exempt returns if a path is let through without the passphrase.
+func (g *Gate) Exempt(path string) bool {
+	if path == Path || strings.HasPrefix(path, StaticPath) {
+		return true
+	}
+	for _, healthPath := range g.healthPaths {
+		if strings.EqualFold(path, healthPath) {
+			return true
+		}
+	}
+	for _, prefix := range g.deepLinkPaths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	for _, prefix := range g.exemptPaths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSecured returns if a route is described as needing credentials.
+func isSecured(route *web.Route) bool {
+	return route != nil && route.Meta != nil && route.Meta.Secured
+}
+
+// Admitted returns if the browser has entered the passphrase.
+func (g *Gate) Admitted(ctx *web.Ctx) bool {
+	cookie := ctx.GetCookie(g.cookieName)
+	return cookie != nil && hmac.Equal([]byte(cookie.Value), []byte(g.sign()))
+}
+
+// Check returns if a passphrase is the gate's passphrase.
+func (g *Gate) Check(passphrase string) bool {
+	// the passphrases are compared by their signatures so the time it takes doesn't depend on
+	// how much of the passphrase is right.
+	return hmac.Equal(g.signPassphrase(strings.TrimSpace(passphrase)), g.signPassphrase(g.passphrase))
+}
+
+// Admit sets the cookie that lets the browser through; it lasts until the browser is closed.
+func (g *Gate) Admit(ctx *web.Ctx) {
+	var secure bool
+	if ctx.Auth() != nil {
+		secure = ctx.Auth().CookiesHTTPSOnly()
+	}
+	ctx.WriteNewCookie(g.cookieName, g.sign(), nil, "/", secure)
+}
+
+// Next returns where to send a browser once it's through, from the `next` query string value.
+// Only paths on this site are allowed, so the gate can't be used to send guests somewhere else.
+func Next(ctx *web.Ctx) string {
+	next := ctx.Request().URL.Query().Get(QueryNext)
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+// sign returns the cookie value; it changes when the passphrase does.
+func (g *Gate) sign() string {
+	return base64.RawURLEncoding.EncodeToString(g.signPassphrase(g.passphrase))
+}
+
+func (g *Gate) signPassphrase(passphrase string) []byte {
+	return util.Crypto.Hash(g.key, []byte("gate:"+passphrase))
+}
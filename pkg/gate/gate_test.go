@@ -0,0 +1,46 @@
+package gate_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/gate"
+)
+
+func TestMiddlewareExemptions(t *testing.T) {
+	siteGate := gate.New("open sesame", []byte("key"))
+	app := web.New()
+	app.WithDefaultMiddleware(siteGate.Middleware)
+	ok := func(ctx *web.Ctx) web.Result { return ctx.Text().Result("ok") }
+	app.GET("/api/v1/events", ok)
+	app.Describe("GET", "/api/v1/events", web.RouteMeta{Summary: "List the events"})
+	app.GET("/api/v1/guests", ok)
+	app.Describe("GET", "/api/v1/guests", web.RouteMeta{Summary: "List guests", Secured: true})
+	app.GET("/api/openapi.json", ok)
+	app.POST("/webhooks/sms", ok)
+	app.GET("/healthz", ok)
+
+	testCases := []struct {
+		method  string
+		path    string
+		through bool
+	}{
+		{method: "GET", path: "/api/v1/events", through: false},
+		{method: "GET", path: "/api/openapi.json", through: false},
+		{method: "GET", path: "/api/v1/guests", through: true},
+		{method: "POST", path: "/webhooks/sms", through: true},
+		{method: "GET", path: "/healthz", through: true},
+	}
+	for _, tc := range testCases {
+		res, err := web.NewMockRequestBuilder(app).WithVerb(tc.method).WithPathf("%s", tc.path).Response()
+		if err != nil {
+			t.Fatalf("%s %s: %+v", tc.method, tc.path, err)
+		}
+		res.Body.Close()
+		if through := res.StatusCode == http.StatusOK; through != tc.through {
+			t.Fatalf("%s %s: expected through: %t, got status %d", tc.method, tc.path, tc.through, res.StatusCode)
+		}
+	}
+}
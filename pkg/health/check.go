@@ -0,0 +1,77 @@
+package health
+
+import (
+	"context"
+	"os"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/schema"
+)
+
+const (
+	// ErrMigrationsPending is returned when the schema is behind the code.
+	ErrMigrationsPending exception.Class = "migrations pending"
+	// ErrNotDirectory is returned when a storage path isn't a directory.
+	ErrNotDirectory exception.Class = "path is not a directory"
+)
+
+// Check is a named readiness check.
+type Check struct {
+	Name   string
+	Action func(context.Context) error
+}
+
+// DB returns a check that the database is reachable.
+// It opens the connection if it hasn't been opened yet.
+func DB(conn *db.Connection) Check {
+	return Check{
+		Name: "db",
+		Action: func(ctx context.Context) error {
+			if _, err := conn.Open(); err != nil {
+				return err
+			}
+			return exception.New(conn.Connection().PingContext(ctx))
+		},
+	}
+}
+
+// Migrations returns a check that every schema migration has been applied.
+func Migrations(conn *db.Connection) Check {
+	return Check{
+		Name: "migrations",
+		Action: func(ctx context.Context) error {
+			pending, err := schema.Pending(conn)
+			if err != nil {
+				return err
+			}
+			if len(pending) > 0 {
+				return exception.New(ErrMigrationsPending).WithMessagef("pending: %d, next: %d %s", len(pending), pending[0].Version, pending[0].Name)
+			}
+			return nil
+		},
+	}
+}
+
+// Directory returns a check that a storage directory exists and can be read.
+func Directory(name, path string) Check {
+	return Check{
+		Name: name,
+		Action: func(ctx context.Context) error {
+			f, err := os.Open(path)
+			if err != nil {
+				return exception.New(err)
+			}
+			defer f.Close()
+			info, err := f.Stat()
+			if err != nil {
+				return exception.New(err)
+			}
+			if !info.IsDir() {
+				return exception.New(ErrNotDirectory).WithMessagef("path: %s", path)
+			}
+			return nil
+		},
+	}
+}
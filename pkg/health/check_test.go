@@ -0,0 +1,49 @@
+package health_test
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/blend/go-sdk/exception"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/health"
+	"github.com/wcharczuk/katwillmarry.com/pkg/schema/schematest"
+)
+
+func TestDBAndMigrations(t *testing.T) {
+	conn := schematest.Open(t)
+	if err := health.DB(conn).Action(context.Background()); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	migrations := health.Migrations(conn)
+	if err := migrations.Action(context.Background()); err != nil {
+		t.Fatalf("a migrated database should pass, got %+v", err)
+	}
+
+	if err := conn.Invoke().Exec("DELETE FROM schema_migration WHERE version = (SELECT max(version) FROM schema_migration)"); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := migrations.Action(context.Background()); !exception.Is(err, health.ErrMigrationsPending) {
+		t.Fatalf("expected %v, got %v", health.ErrMigrationsPending, err)
+	}
+}
+
+func TestDirectory(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "guests.csv")
+	if err := ioutil.WriteFile(file, []byte("name\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := health.Directory("uploads", dir).Action(context.Background()); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := health.Directory("uploads", file).Action(context.Background()); !exception.Is(err, health.ErrNotDirectory) {
+		t.Fatalf("expected %v, got %v", health.ErrNotDirectory, err)
+	}
+	if err := health.Directory("uploads", filepath.Join(dir, "missing")).Action(context.Background()); err == nil {
+		t.Fatal("a missing directory should fail")
+	}
+}
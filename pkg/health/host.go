@@ -0,0 +1,35 @@
+package health
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/blend/go-sdk/web"
+)
+
+const (
+	// RouteReadiness is the readiness route on the healthz server.
+	RouteReadiness = "/readyz"
+)
+
+// Handler returns the handler for the healthz sidecar.
+// Liveness stays with the sdk at `/healthz` (and `/varz`); any extra routes (readiness at `/readyz`,
+// metrics, etc.) are matched by exact path first.
+func Handler(hz *web.Healthz, routes map[string]http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handler, ok := routes[strings.ToLower(r.URL.Path)]; ok {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		hz.ServeHTTP(w, r)
+	})
+}
+
+// Host starts both the app and the healthz sidecar, with the extra routes served by the sidecar.
+// It returns when either server exits.
+func Host(app *web.App, hz *web.Healthz, routes map[string]http.Handler) error {
+	server := hz.CreateServer()
+	server.Handler = Handler(hz, routes)
+	hz.WithServer(server)
+	return web.HealthzHost(app, hz)
+}
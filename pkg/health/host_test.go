@@ -0,0 +1,22 @@
+package health_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/health"
+)
+
+func TestHandler(t *testing.T) {
+	readiness := health.NewReadiness(health.Check{Name: "db", Action: func(context.Context) error { return nil }})
+	handler := health.Handler(web.NewHealthz(web.New()), map[string]http.Handler{health.RouteReadiness: readiness})
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest("GET", "/READYZ", nil))
+	if rw.Code != http.StatusOK || rw.Header().Get(web.HeaderContentType) != web.ContentTypeApplicationJSON {
+		t.Fatalf("readiness should be served from /readyz, got %d", rw.Code)
+	}
+}
@@ -0,0 +1,162 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/web"
+)
+
+const (
+	// DefaultCheckTimeout is the default timeout for all readiness checks to finish.
+	DefaultCheckTimeout = 5 * time.Second
+
+	// StatusOK is the status for a passing check.
+	StatusOK = "ok"
+	// StatusUnavailable is the status for a failing check.
+	StatusUnavailable = "unavailable"
+	// StatusDraining is the overall status while shutting down.
+	StatusDraining = "draining"
+)
+
+// NewReadiness returns a new readiness reporter.
+func NewReadiness(checks ...Check) *Readiness {
+	return &Readiness{
+		checks:  checks,
+		timeout: DefaultCheckTimeout,
+	}
+}
+
+// Readiness reports if the process should receive traffic.
+// Unlike liveness, failing readiness doesn't mean the process should be restarted,
+// only that load balancers should route around it for now.
+type Readiness struct {
+	checks   []Check
+	timeout  time.Duration
+	draining func() bool
+}
+
+// WithCheck adds a check.
+func (r *Readiness) WithCheck(check Check) *Readiness {
+	r.checks = append(r.checks, check)
+	return r
+}
+
+// Checks returns the checks.
+func (r *Readiness) Checks() []Check {
+	return r.checks
+}
+
+// WithTimeout sets the timeout for all checks to finish.
+func (r *Readiness) WithTimeout(timeout time.Duration) *Readiness {
+	r.timeout = timeout
+	return r
+}
+
+// Timeout returns the check timeout.
+func (r *Readiness) Timeout() time.Duration {
+	return r.timeout
+}
+
+// WithDraining sets the func used to tell if the process is shutting down.
+// While draining the process always reports as not ready.
+func (r *Readiness) WithDraining(draining func() bool) *Readiness {
+	r.draining = draining
+	return r
+}
+
+// Report is the result of evaluating the readiness checks.
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// Ready returns if the report is passing.
+func (r Report) Ready() bool {
+	return r.Status == StatusOK
+}
+
+// CheckResult is the result of a single check.
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	ElapsedMS float64 `json:"elapsedMS"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Evaluate runs the checks concurrently and returns a report.
+func (r *Readiness) Evaluate(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	results := make([]CheckResult, len(r.checks))
+	wg := sync.WaitGroup{}
+	wg.Add(len(r.checks))
+	for index, check := range r.checks {
+		go func(index int, check Check) {
+			defer wg.Done()
+			results[index] = r.run(ctx, check)
+		}(index, check)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: results}
+	for _, result := range results {
+		if result.Status != StatusOK {
+			report.Status = StatusUnavailable
+		}
+	}
+	if r.draining != nil && r.draining() {
+		report.Status = StatusDraining
+	}
+	return report
+}
+
+// ServeHTTP implements http.Handler.
+// It responds 200 when ready and 503 otherwise, with the report as json.
+func (r *Readiness) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	report := r.Evaluate(req.Context())
+	w.Header().Set(web.HeaderContentType, web.ContentTypeApplicationJSON)
+	if report.Ready() {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+func (r *Readiness) run(ctx context.Context, check Check) (result CheckResult) {
+	start := time.Now()
+	result.Name = check.Name
+	defer func() {
+		result.ElapsedMS = float64(time.Since(start)) / float64(time.Millisecond)
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if rcv := recover(); rcv != nil {
+				done <- exception.New(rcv)
+			}
+		}()
+		done <- check.Action(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			result.Status = StatusUnavailable
+			result.Error = err.Error()
+			return
+		}
+		result.Status = StatusOK
+	case <-ctx.Done():
+		result.Status = StatusUnavailable
+		result.Error = ctx.Err().Error()
+	}
+	return
+}
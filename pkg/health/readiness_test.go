@@ -0,0 +1,95 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/health"
+)
+
+func passing(name string) health.Check {
+	return health.Check{Name: name, Action: func(context.Context) error { return nil }}
+}
+
+// serve returns the status code and report for a readiness request.
+func serve(t *testing.T, readiness *health.Readiness) (int, health.Report) {
+	t.Helper()
+	rw := httptest.NewRecorder()
+	readiness.ServeHTTP(rw, httptest.NewRequest("GET", health.RouteReadiness, nil))
+	if contentType := rw.Header().Get(web.HeaderContentType); contentType != web.ContentTypeApplicationJSON {
+		t.Fatalf("the report should be json, got %q", contentType)
+	}
+	var report health.Report
+	if err := json.NewDecoder(rw.Body).Decode(&report); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return rw.Code, report
+}
+
+func TestReadiness(t *testing.T) {
+	code, report := serve(t, health.NewReadiness(passing("db"), passing("migrations")))
+	if code != http.StatusOK || report.Status != health.StatusOK || len(report.Checks) != 2 {
+		t.Fatalf("passing checks should be ready, got %d %+v", code, report)
+	}
+	for _, check := range report.Checks {
+		if check.Status != health.StatusOK || len(check.Error) > 0 {
+			t.Fatalf("unexpected check %+v", check)
+		}
+	}
+
+	failing := health.Check{Name: "db", Action: func(context.Context) error { return exception.New("connection refused") }}
+	panics := health.Check{Name: "uploads", Action: func(context.Context) error { panic("boom") }}
+	code, report = serve(t, health.NewReadiness(passing("migrations"), failing, panics))
+	if code != http.StatusServiceUnavailable || report.Status != health.StatusUnavailable {
+		t.Fatalf("a failing check should be unavailable, got %d %+v", code, report)
+	}
+	expected := []health.CheckResult{
+		{Name: "migrations", Status: health.StatusOK},
+		{Name: "db", Status: health.StatusUnavailable, Error: "connection refused"},
+		{Name: "uploads", Status: health.StatusUnavailable, Error: "boom"},
+	}
+	for index, check := range report.Checks {
+		if check.Name != expected[index].Name || check.Status != expected[index].Status || check.Error != expected[index].Error {
+			t.Fatalf("expected %+v, got %+v", expected[index], check)
+		}
+	}
+}
+
+func TestReadinessTimeout(t *testing.T) {
+	blocks := health.Check{Name: "db", Action: func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(time.Second)
+		return nil
+	}}
+	start := time.Now()
+	code, report := serve(t, health.NewReadiness(passing("migrations"), blocks).WithTimeout(20*time.Millisecond))
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("checks should be abandoned at the timeout, took %v", elapsed)
+	}
+	if code != http.StatusServiceUnavailable || report.Checks[1].Status != health.StatusUnavailable || len(report.Checks[1].Error) == 0 {
+		t.Fatalf("a check that times out should be unavailable, got %d %+v", code, report)
+	}
+}
+
+func TestReadinessDraining(t *testing.T) {
+	draining := false
+	readiness := health.NewReadiness(passing("db")).WithDraining(func() bool { return draining })
+	if code, _ := serve(t, readiness); code != http.StatusOK {
+		t.Fatalf("expected %d before draining, got %d", http.StatusOK, code)
+	}
+	draining = true
+	code, report := serve(t, readiness)
+	if code != http.StatusServiceUnavailable || report.Status != health.StatusDraining {
+		t.Fatalf("a draining process shouldn't be ready even with passing checks, got %d %+v", code, report)
+	}
+	if report.Checks[0].Status != health.StatusOK {
+		t.Fatalf("the checks should still be reported while draining, got %+v", report.Checks)
+	}
+}
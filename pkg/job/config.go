@@ -0,0 +1,78 @@
+package job
+
+import (
+	"time"
+
+	"github.com/blend/go-sdk/util"
+)
+
+const (
+	// DefaultConcurrency is the default number of jobs run at once.
+	DefaultConcurrency = 4
+	// DefaultPollInterval is the default time between checking for jobs.
+	DefaultPollInterval = time.Second
+	// DefaultLease is the default time a claimed job is held before another worker can take it over.
+	DefaultLease = 5 * time.Minute
+	// DefaultMaxAttempts is the default number of attempts before a job is dead.
+	DefaultMaxAttempts = 8
+	// DefaultBackoff is the default delay before the first retry; it doubles each attempt.
+	DefaultBackoff = 30 * time.Second
+	// DefaultMaxBackoff is the default longest delay between retries.
+	DefaultMaxBackoff = 6 * time.Hour
+	// DefaultRetention is the default time succeeded jobs are kept.
+	DefaultRetention = 7 * 24 * time.Hour
+)
+
+// Config is the job queue config.
+type Config struct {
+	// Concurrency is the number of jobs run at once by each process.
+	Concurrency int `json:"concurrency,omitempty" yaml:"concurrency,omitempty" env:"JOB_CONCURRENCY"`
+	// PollInterval is the time between checking for jobs.
+	PollInterval time.Duration `json:"pollInterval,omitempty" yaml:"pollInterval,omitempty" env:"JOB_POLL_INTERVAL"`
+	// Lease is how long a claimed job is held; if the process dies, the job is picked up again once it passes.
+	// It should be longer than any job takes.
+	Lease time.Duration `json:"lease,omitempty" yaml:"lease,omitempty" env:"JOB_LEASE"`
+	// MaxAttempts is the number of attempts before a job is dead, for jobs that don't set their own.
+	MaxAttempts int `json:"maxAttempts,omitempty" yaml:"maxAttempts,omitempty" env:"JOB_MAX_ATTEMPTS"`
+	// Backoff is the delay before the first retry; it doubles with each attempt.
+	Backoff time.Duration `json:"backoff,omitempty" yaml:"backoff,omitempty" env:"JOB_BACKOFF"`
+	// MaxBackoff is the longest delay between retries.
+	MaxBackoff time.Duration `json:"maxBackoff,omitempty" yaml:"maxBackoff,omitempty" env:"JOB_MAX_BACKOFF"`
+	// Retention is how long succeeded jobs are kept.
+	Retention time.Duration `json:"retention,omitempty" yaml:"retention,omitempty" env:"JOB_RETENTION"`
+}
+
+// GetConcurrency returns a property or a default.
+func (c Config) GetConcurrency(inherited ...int) int {
+	return util.Coalesce.Int(c.Concurrency, DefaultConcurrency, inherited...)
+}
+
+// GetPollInterval returns a property or a default.
+func (c Config) GetPollInterval(inherited ...time.Duration) time.Duration {
+	return util.Coalesce.Duration(c.PollInterval, DefaultPollInterval, inherited...)
+}
+
+// GetLease returns a property or a default.
+func (c Config) GetLease(inherited ...time.Duration) time.Duration {
+	return util.Coalesce.Duration(c.Lease, DefaultLease, inherited...)
+}
+
+// GetMaxAttempts returns a property or a default.
+func (c Config) GetMaxAttempts(inherited ...int) int {
+	return util.Coalesce.Int(c.MaxAttempts, DefaultMaxAttempts, inherited...)
+}
+
+// GetBackoff returns a property or a default.
+func (c Config) GetBackoff(inherited ...time.Duration) time.Duration {
+	return util.Coalesce.Duration(c.Backoff, DefaultBackoff, inherited...)
+}
+
+// GetMaxBackoff returns a property or a default.
+func (c Config) GetMaxBackoff(inherited ...time.Duration) time.Duration {
+	return util.Coalesce.Duration(c.MaxBackoff, DefaultMaxBackoff, inherited...)
+}
+
+// GetRetention returns a property or a default.
+func (c Config) GetRetention(inherited ...time.Duration) time.Duration {
+	return util.Coalesce.Duration(c.Retention, DefaultRetention, inherited...)
+}
@@ -0,0 +1,145 @@
+package job
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+)
+
+const (
+	// StatusQueued is a job waiting for its first attempt.
+	StatusQueued = "queued"
+	// StatusRunning is a job claimed by a worker.
+	StatusRunning = "running"
+	// StatusRetrying is a job that failed and is waiting for its next attempt.
+	StatusRetrying = "retrying"
+	// StatusSucceeded is a job that finished.
+	StatusSucceeded = "succeeded"
+	// StatusDead is a job that failed every attempt; it stays until it's retried or deleted.
+	StatusDead = "dead"
+)
+
+// Args are the arguments for a kind of job; they're stored as json.
+type Args interface {
+	Kind() string
+}
+
+// Job is a unit of background work.
+type Job struct {
+	ID      int64           `db:"id,pk,serial" json:"id"`
+	Kind    string          `db:"kind" json:"kind"`
+	Payload json.RawMessage `db:"payload,json" json:"payload,omitempty"`
+	Status  string          `db:"status" json:"status"`
+	// Attempts is the number of times the job has been claimed.
+	Attempts int `db:"attempts" json:"attempts"`
+	// MaxAttempts is the number of attempts before the job is dead; zero uses the queue's default.
+	MaxAttempts int       `db:"max_attempts" json:"maxAttempts,omitempty"`
+	RunAt       time.Time `db:"run_at" json:"runAt"`
+	// LockedBy and LockedUntil are the worker holding a running job, and when its lease runs out.
+	LockedBy    string     `db:"locked_by" json:"lockedBy,omitempty"`
+	LockedUntil *time.Time `db:"locked_until" json:"lockedUntil,omitempty"`
+	LastError   string     `db:"last_error" json:"lastError,omitempty"`
+	CreatedUTC  time.Time  `db:"created_utc" json:"createdUTC"`
+	UpdatedUTC  time.Time  `db:"updated_utc" json:"updatedUTC"`
+	FinishedUTC *time.Time `db:"finished_utc" json:"finishedUTC,omitempty"`
+}
+
+// TableName returns the mapped table name.
+func (j Job) TableName() string {
+	return "job"
+}
+
+// Decode reads the job's payload into its args.
+func (j Job) Decode(args Args) error {
+	if len(j.Payload) == 0 {
+		return nil
+	}
+	return exception.New(json.Unmarshal(j.Payload, args))
+}
+
+// New returns a queued job for a set of args, to run as soon as possible.
+func New(args Args) (*Job, error) {
+	payload, err := json.Marshal(args)
+	if err != nil {
+		return nil, exception.New(err)
+	}
+	now := time.Now().UTC()
+	return &Job{
+		Kind:       args.Kind(),
+		Payload:    payload,
+		Status:     StatusQueued,
+		RunAt:      now,
+		CreatedUTC: now,
+		UpdatedUTC: now,
+	}, nil
+}
+
+// WithRunAt sets when the job should run.
+func (j *Job) WithRunAt(runAt time.Time) *Job {
+	j.RunAt = runAt.UTC()
+	return j
+}
+
+// WithMaxAttempts sets the number of attempts before the job is dead.
+func (j *Job) WithMaxAttempts(maxAttempts int) *Job {
+	j.MaxAttempts = maxAttempts
+	return j
+}
+
+// Enqueue adds a job to run as soon as possible.
+// Pass the request's transaction so the job is only queued if the work that asked for it commits.
+func Enqueue(conn *db.Connection, args Args, txs ...*sql.Tx) (*Job, error) {
+	job, err := New(args)
+	if err != nil {
+		return nil, err
+	}
+	return job, Add(conn, job, txs...)
+}
+
+// EnqueueAt adds a job to run at a given time.
+func EnqueueAt(conn *db.Connection, args Args, runAt time.Time, txs ...*sql.Tx) (*Job, error) {
+	job, err := New(args)
+	if err != nil {
+		return nil, err
+	}
+	return job, Add(conn, job.WithRunAt(runAt), txs...)
+}
+
+// Add adds a job created with `New`.
+func Add(conn *db.Connection, job *Job, txs ...*sql.Tx) error {
+	return conn.Invoke(txs...).Create(job)
+}
+
+// Retry puts a dead job back in the queue with its attempts reset.
+func Retry(conn *db.Connection, id int64, txs ...*sql.Tx) error {
+	dialect := conn.Dialect()
+	statement := "UPDATE job SET status = " + dialect.Placeholder(1) +
+		", attempts = 0, run_at = " + dialect.Placeholder(2) + ", updated_utc = " + dialect.Placeholder(2) +
+		" WHERE id = " + dialect.Placeholder(3) + " AND status = " + dialect.Placeholder(4)
+	return conn.Invoke(txs...).WithLabel("job_retry").Exec(statement, StatusQueued, time.Now().UTC(), id, StatusDead)
+}
+
+// Cancel deletes a job that isn't running.
+func Cancel(conn *db.Connection, id int64, txs ...*sql.Tx) error {
+	dialect := conn.Dialect()
+	statement := "DELETE FROM job WHERE id = " + dialect.Placeholder(1) + " AND status <> " + dialect.Placeholder(2)
+	return conn.Invoke(txs...).WithLabel("job_cancel").Exec(statement, id, StatusRunning)
+}
+
+// Counts returns the number of jobs in each status.
+func Counts(conn *db.Connection, txs ...*sql.Tx) (map[string]int, error) {
+	counts := map[string]int{}
+	err := conn.Invoke(txs...).WithLabel("job_counts").Query("SELECT status, count(*) FROM job GROUP BY status").Each(func(rows *sql.Rows) error {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return exception.New(err)
+		}
+		counts[status] = count
+		return nil
+	})
+	return counts, err
+}
@@ -0,0 +1,68 @@
+package job
+
+import (
+	"testing"
+)
+
+func TestRetryAndCancel(t *testing.T) {
+	conn, q := newTestQueue(t, Config{}, nil)
+	statuses := []string{StatusQueued, StatusRunning, StatusRetrying, StatusDead}
+	jobs := map[string]*Job{}
+	for _, status := range statuses {
+		job, err := New(testArgs{Name: status})
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		job.Status, job.Attempts = status, 3
+		if err := Add(conn, job); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		jobs[status] = job
+	}
+
+	for _, status := range statuses {
+		if err := Retry(conn, jobs[status].ID); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+	for _, status := range statuses {
+		job := getJob(t, conn, jobs[status].ID)
+		if status == StatusDead {
+			if job.Status != StatusQueued || job.Attempts != 0 {
+				t.Fatalf("a retried dead job should be queued with its attempts reset, got %+v", job)
+			}
+			continue
+		}
+		if job.Status != status || job.Attempts != 3 {
+			t.Fatalf("only dead jobs should be retried, got %+v", job)
+		}
+	}
+	if claimed, err := q.Claim(10); err != nil || len(claimed) != 3 {
+		t.Fatalf("the queued, retrying and retried jobs should be claimed, got %+v %v", claimed, err)
+	}
+
+	counts, err := Counts(conn)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(counts) != 1 || counts[StatusRunning] != 4 {
+		t.Fatalf("want every job running, got %v", counts)
+	}
+
+	queued, err := Enqueue(conn, testArgs{})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := Cancel(conn, queued.ID); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if getJob(t, conn, queued.ID).ID != 0 {
+		t.Fatal("cancel should delete a job that isn't running")
+	}
+	if err := Cancel(conn, jobs[StatusRunning].ID); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if getJob(t, conn, jobs[StatusRunning].ID).ID == 0 {
+		t.Fatal("cancel shouldn't delete a running job")
+	}
+}
@@ -0,0 +1,355 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/logger"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/lifecycle"
+)
+
+const (
+	// ErrNoHandler is returned for a job whose kind has no handler.
+	ErrNoHandler exception.Class = "job: no handler for kind"
+	// ErrPanic is returned for a job whose handler panicked.
+	ErrPanic exception.Class = "job: handler panicked"
+	// ErrLeaseExpired is recorded for a job whose last attempt ran out its lease without finishing,
+	// ex. because the process died.
+	ErrLeaseExpired exception.Class = "job: lease ran out"
+
+	// cleanupInterval is the time between deleting succeeded jobs past the retention window.
+	cleanupInterval = time.Hour
+)
+
+// Handler runs jobs of a kind.
+// A handler should stop when the context is cancelled (the lease ran out or the process is shutting down);
+// returning an error schedules a retry.
+type Handler interface {
+	Handle(ctx context.Context, job *Job) error
+}
+
+// HandlerFunc is a function that implements Handler.
+type HandlerFunc func(ctx context.Context, job *Job) error
+
+// Handle implements Handler.
+func (hf HandlerFunc) Handle(ctx context.Context, job *Job) error {
+	return hf(ctx, job)
+}
+
+// NewQueue returns a new queue.
+func NewQueue(conn *db.Connection) *Queue {
+	return &Queue{
+		conn:         conn,
+		workerID:     workerID(),
+		concurrency:  DefaultConcurrency,
+		pollInterval: DefaultPollInterval,
+		lease:        DefaultLease,
+		maxAttempts:  DefaultMaxAttempts,
+		backoff:      DefaultBackoff,
+		maxBackoff:   DefaultMaxBackoff,
+		retention:    DefaultRetention,
+		handlers:     map[string]Handler{},
+	}
+}
+
+// NewQueueFromConfig returns a new queue from a config.
+func NewQueueFromConfig(conn *db.Connection, cfg *Config) *Queue {
+	q := NewQueue(conn)
+	q.concurrency = cfg.GetConcurrency()
+	q.pollInterval = cfg.GetPollInterval()
+	q.lease = cfg.GetLease()
+	q.maxAttempts = cfg.GetMaxAttempts()
+	q.backoff = cfg.GetBackoff()
+	q.maxBackoff = cfg.GetMaxBackoff()
+	q.retention = cfg.GetRetention()
+	return q
+}
+
+// Queue runs jobs from the job table.
+//
+// Workers claim due jobs with a single update (`FOR UPDATE SKIP LOCKED` on postgres, sqlite
+// serializes writers), so any number of processes can share the table. A claimed job is
+// leased to the worker; if the process dies mid-job the job is claimed again once the lease
+// runs out, or is dead if that was its last attempt. Failed jobs are retried with exponential
+// backoff until they run out of attempts, then they're dead and stay in the table until
+// they're retried or cancelled.
+type Queue struct {
+	conn     *db.Connection
+	log      *logger.Logger
+	workerID string
+
+	concurrency  int
+	pollInterval time.Duration
+	lease        time.Duration
+	maxAttempts  int
+	backoff      time.Duration
+	maxBackoff   time.Duration
+	retention    time.Duration
+
+	handlersLock sync.Mutex
+	handlers     map[string]Handler
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	slots   chan struct{}
+	running sync.WaitGroup
+	poll    *lifecycle.Interval
+	cleanup *lifecycle.Interval
+}
+
+// WithLogger sets the logger.
+func (q *Queue) WithLogger(log *logger.Logger) *Queue {
+	q.log = log
+	return q
+}
+
+// WithHandler sets the handler for a kind of job.
+// Only jobs with a handler are claimed, so processes can split up work by registering different kinds.
+func (q *Queue) WithHandler(kind string, handler Handler) *Queue {
+	q.handlersLock.Lock()
+	defer q.handlersLock.Unlock()
+	q.handlers[kind] = handler
+	return q
+}
+
+// Backoff returns the delay before retrying a job that has failed a number of attempts.
+func (q *Queue) Backoff(attempts int) time.Duration {
+	delay := q.backoff
+	for attempt := 1; attempt < attempts && delay < q.maxBackoff; attempt++ {
+		delay *= 2
+	}
+	if delay > q.maxBackoff {
+		return q.maxBackoff
+	}
+	return delay
+}
+
+// Start starts claiming and running jobs in the background.
+func (q *Queue) Start() {
+	q.ctx, q.cancel = context.WithCancel(context.Background())
+	q.slots = make(chan struct{}, q.concurrency)
+	q.poll = lifecycle.NewInterval(q.pollInterval, q.Poll).WithLogger(q.log)
+	q.poll.Start()
+	q.cleanup = lifecycle.NewInterval(cleanupInterval, q.deleteSucceeded).WithLogger(q.log)
+	q.cleanup.Start()
+}
+
+// Stop stops claiming jobs and waits for running jobs to finish.
+// If the context expires first, running jobs are cancelled; they'll be claimed again once their lease runs out.
+func (q *Queue) Stop(ctx context.Context) error {
+	if q.poll == nil {
+		return nil
+	}
+	if err := q.poll.Stop(ctx); err != nil {
+		q.cancel()
+		return err
+	}
+	if err := q.cleanup.Stop(ctx); err != nil {
+		q.cancel()
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		q.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		q.cancel()
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		return exception.New(ctx.Err())
+	}
+}
+
+// Poll claims as many due jobs as there are free workers and starts running them.
+func (q *Queue) Poll() error {
+	free := cap(q.slots) - len(q.slots)
+	if free <= 0 {
+		return nil
+	}
+	jobs, err := q.Claim(free)
+	if err != nil {
+		return err
+	}
+	for index := range jobs {
+		job := jobs[index]
+		q.slots <- struct{}{}
+		q.running.Add(1)
+		go func() {
+			defer q.running.Done()
+			defer func() { <-q.slots }()
+			q.run(&job)
+		}()
+	}
+	return nil
+}
+
+// Claim leases up to a number of due jobs (including running jobs whose lease ran out) to this worker.
+// Running jobs whose lease ran out on their last attempt are marked dead instead.
+func (q *Queue) Claim(limit int) ([]Job, error) {
+	kinds := q.kinds()
+	if len(kinds) == 0 || limit <= 0 {
+		return nil, nil
+	}
+	now := time.Now().UTC()
+	if err := q.expire(kinds, now); err != nil {
+		return nil, err
+	}
+	dialect := q.conn.Dialect()
+
+	args := []interface{}{StatusRunning, q.workerID, now.Add(q.lease), now, StatusQueued, StatusRetrying, limit, q.maxAttempts}
+	kindTokens := db.ParamTokensFor(dialect, len(args)+1, len(kinds))
+	for _, kind := range kinds {
+		args = append(args, kind)
+	}
+
+	statement := "UPDATE job SET status = " + dialect.Placeholder(1) +
+		", attempts = attempts + 1, locked_by = " + dialect.Placeholder(2) +
+		", locked_until = " + dialect.Placeholder(3) + ", updated_utc = " + dialect.Placeholder(4) +
+		" WHERE id IN (SELECT id FROM job WHERE kind IN (" + kindTokens + ")" +
+		" AND ((status IN (" + dialect.Placeholder(5) + "," + dialect.Placeholder(6) + ") AND run_at <= " + dialect.Placeholder(4) + ")" +
+		" OR (status = " + dialect.Placeholder(1) + " AND locked_until < " + dialect.Placeholder(4) +
+		" AND attempts < " + maxAttemptsExpr(dialect.Placeholder(8)) + "))" +
+		" ORDER BY run_at, id LIMIT " + dialect.Placeholder(7)
+	if dialect.Name() == db.DialectPostgres {
+		statement += " FOR UPDATE SKIP LOCKED"
+	}
+	statement += ")" + dialect.Returning(db.Columns(Job{}).ColumnNames())
+
+	var jobs []Job
+	err := q.conn.Invoke().WithLabel(fmt.Sprintf("job_claim_%d", len(kinds))).Query(statement, args...).OutMany(&jobs)
+	return jobs, err
+}
+
+// expire marks running jobs of the given kinds dead if their lease ran out on their last attempt,
+// so they aren't claimed again.
+func (q *Queue) expire(kinds []string, now time.Time) error {
+	dialect := q.conn.Dialect()
+	args := []interface{}{StatusDead, string(ErrLeaseExpired), now, StatusRunning, q.maxAttempts}
+	kindTokens := db.ParamTokensFor(dialect, len(args)+1, len(kinds))
+	for _, kind := range kinds {
+		args = append(args, kind)
+	}
+
+	statement := "UPDATE job SET status = " + dialect.Placeholder(1) +
+		", last_error = " + dialect.Placeholder(2) + ", locked_by = '', locked_until = NULL" +
+		", updated_utc = " + dialect.Placeholder(3) + ", finished_utc = " + dialect.Placeholder(3) +
+		" WHERE kind IN (" + kindTokens + ") AND status = " + dialect.Placeholder(4) +
+		" AND locked_until < " + dialect.Placeholder(3) +
+		" AND attempts >= " + maxAttemptsExpr(dialect.Placeholder(5))
+	expired, err := q.conn.Invoke().WithLabel(fmt.Sprintf("job_expire_%d", len(kinds))).ExecAffected(statement, args...)
+	if err != nil {
+		return err
+	}
+	if expired > 0 && q.log != nil {
+		q.log.Warningf("%d jobs ran out their lease on their last attempt, dead", expired)
+	}
+	return nil
+}
+
+// maxAttemptsExpr returns the sql for a job's max attempts, given the placeholder for the queue's default.
+func maxAttemptsExpr(defaultMaxAttempts string) string {
+	return "(CASE WHEN max_attempts > 0 THEN max_attempts ELSE " + defaultMaxAttempts + " END)"
+}
+
+// run runs a claimed job and records the outcome.
+func (q *Queue) run(job *Job) {
+	ctx, cancel := context.WithTimeout(q.ctx, q.lease)
+	defer cancel()
+
+	err := q.handle(ctx, job)
+	if err == nil {
+		err = q.succeed(job)
+	} else {
+		err = q.fail(job, err)
+	}
+	if err != nil && q.log != nil {
+		q.log.Error(err)
+	}
+}
+
+func (q *Queue) handle(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = exception.New(ErrPanic).WithMessagef("%v", r)
+		}
+	}()
+	q.handlersLock.Lock()
+	handler, ok := q.handlers[job.Kind]
+	q.handlersLock.Unlock()
+	if !ok {
+		return exception.New(ErrNoHandler).WithMessagef("kind: %s", job.Kind)
+	}
+	return handler.Handle(ctx, job)
+}
+
+// succeed marks a job succeeded, if this worker still holds it.
+func (q *Queue) succeed(job *Job) error {
+	now := time.Now().UTC()
+	dialect := q.conn.Dialect()
+	statement := "UPDATE job SET status = " + dialect.Placeholder(1) +
+		", locked_by = '', locked_until = NULL, last_error = '', updated_utc = " + dialect.Placeholder(2) +
+		", finished_utc = " + dialect.Placeholder(2) +
+		" WHERE id = " + dialect.Placeholder(3) + " AND locked_by = " + dialect.Placeholder(4)
+	return q.conn.Invoke().WithLabel("job_succeed").Exec(statement, StatusSucceeded, now, job.ID, q.workerID)
+}
+
+// fail schedules a retry for a job, or marks it dead if it's out of attempts.
+func (q *Queue) fail(job *Job, cause error) error {
+	now := time.Now().UTC()
+	maxAttempts := job.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = q.maxAttempts
+	}
+
+	status, runAt, finished := StatusRetrying, now.Add(q.Backoff(job.Attempts)), (*time.Time)(nil)
+	if job.Attempts >= maxAttempts {
+		status, runAt, finished = StatusDead, job.RunAt, &now
+	}
+	if q.log != nil {
+		q.log.Warningf("job %d (%s) failed attempt %d of %d, %s: %v", job.ID, job.Kind, job.Attempts, maxAttempts, status, cause)
+	}
+
+	dialect := q.conn.Dialect()
+	statement := "UPDATE job SET status = " + dialect.Placeholder(1) +
+		", run_at = " + dialect.Placeholder(2) + ", last_error = " + dialect.Placeholder(3) +
+		", locked_by = '', locked_until = NULL, updated_utc = " + dialect.Placeholder(4) +
+		", finished_utc = " + dialect.Placeholder(5) +
+		" WHERE id = " + dialect.Placeholder(6) + " AND locked_by = " + dialect.Placeholder(7)
+	return q.conn.Invoke().WithLabel("job_fail").Exec(statement, status, runAt, cause.Error(), now, finished, job.ID, q.workerID)
+}
+
+// deleteSucceeded deletes succeeded jobs that finished before the retention window.
+func (q *Queue) deleteSucceeded() error {
+	dialect := q.conn.Dialect()
+	statement := "DELETE FROM job WHERE status = " + dialect.Placeholder(1) + " AND finished_utc < " + dialect.Placeholder(2)
+	return q.conn.Invoke().WithLabel("job_delete_succeeded").Exec(statement, StatusSucceeded, time.Now().UTC().Add(-q.retention))
+}
+
+// kinds returns the kinds with handlers, sorted so the claim statement is stable.
+func (q *Queue) kinds() []string {
+	q.handlersLock.Lock()
+	defer q.handlersLock.Unlock()
+	kinds := make([]string, 0, len(q.handlers))
+	for kind := range q.handlers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// workerID identifies this process as the holder of the jobs it claims.
+func workerID() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
@@ -0,0 +1,229 @@
+package job
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/schema/schematest"
+)
+
+type testArgs struct {
+	Name string `json:"name"`
+}
+
+func (testArgs) Kind() string {
+	return "test"
+}
+
+type otherArgs struct{}
+
+func (otherArgs) Kind() string {
+	return "other"
+}
+
+// newTestQueue returns a queue on a new database with a handler for test jobs, ready to run claimed jobs.
+func newTestQueue(t *testing.T, cfg Config, handler HandlerFunc) (*db.Connection, *Queue) {
+	t.Helper()
+	conn := schematest.Open(t)
+	q := NewQueueFromConfig(conn, &cfg).WithHandler(testArgs{}.Kind(), handler)
+	q.ctx = context.Background()
+	return conn, q
+}
+
+func getJob(t *testing.T, conn *db.Connection, id int64) Job {
+	t.Helper()
+	var job Job
+	if err := conn.Invoke().Get(&job, id); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return job
+}
+
+func claimOne(t *testing.T, q *Queue) Job {
+	t.Helper()
+	jobs, err := q.Claim(10)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("want one job claimed, got %+v", jobs)
+	}
+	return jobs[0]
+}
+
+func TestClaimAndSucceed(t *testing.T) {
+	var handled []string
+	conn, q := newTestQueue(t, Config{}, func(ctx context.Context, job *Job) error {
+		var args testArgs
+		if err := job.Decode(&args); err != nil {
+			return err
+		}
+		handled = append(handled, args.Name)
+		return nil
+	})
+
+	due, err := Enqueue(conn, testArgs{Name: "due"})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if _, err := EnqueueAt(conn, testArgs{Name: "later"}, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if _, err := Enqueue(conn, otherArgs{}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	claimed := claimOne(t, q)
+	if claimed.ID != due.ID || claimed.Status != StatusRunning || claimed.Attempts != 1 || claimed.LockedBy != q.workerID {
+		t.Fatalf("only the due job with a handler should be claimed, got %+v", claimed)
+	}
+	if claimed.LockedUntil == nil || claimed.LockedUntil.Before(time.Now().Add(DefaultLease-time.Minute)) {
+		t.Fatalf("the claimed job should be leased, got %v", claimed.LockedUntil)
+	}
+	if jobs, err := q.Claim(10); err != nil || len(jobs) != 0 {
+		t.Fatalf("a leased job shouldn't be claimed again, got %+v %v", jobs, err)
+	}
+
+	q.run(&claimed)
+	if len(handled) != 1 || handled[0] != "due" {
+		t.Fatalf("the handler should run with the job's args, got %v", handled)
+	}
+	succeeded := getJob(t, conn, due.ID)
+	if succeeded.Status != StatusSucceeded || succeeded.FinishedUTC == nil || len(succeeded.LockedBy) > 0 || succeeded.LockedUntil != nil {
+		t.Fatalf("a handled job should succeed and be released, got %+v", succeeded)
+	}
+}
+
+func TestClaimExpiredLease(t *testing.T) {
+	conn, q := newTestQueue(t, Config{MaxAttempts: 2}, func(context.Context, *Job) error { return nil })
+	job, err := Enqueue(conn, testArgs{})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expireLease := func() {
+		t.Helper()
+		if err := conn.Invoke().Exec(`UPDATE job SET locked_by = 'dead-worker', locked_until = ?1 WHERE id = ?2`, time.Now().UTC().Add(-time.Minute), job.ID); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+
+	claimOne(t, q)
+	expireLease()
+	reclaimed := claimOne(t, q)
+	if reclaimed.ID != job.ID || reclaimed.Attempts != 2 || reclaimed.LockedBy != q.workerID {
+		t.Fatalf("a job whose lease ran out should be claimed again, got %+v", reclaimed)
+	}
+
+	// the lease runs out again on the last attempt, so it's dead rather than claimed a third time.
+	expireLease()
+	if jobs, err := q.Claim(10); err != nil || len(jobs) != 0 {
+		t.Fatalf("a job out of attempts shouldn't be claimed, got %+v %v", jobs, err)
+	}
+	dead := getJob(t, conn, job.ID)
+	if dead.Status != StatusDead || dead.LastError != string(ErrLeaseExpired) || dead.FinishedUTC == nil || len(dead.LockedBy) > 0 {
+		t.Fatalf("a job whose lease ran out on its last attempt should be dead, got %+v", dead)
+	}
+}
+
+func TestRetryThenDead(t *testing.T) {
+	conn, q := newTestQueue(t, Config{MaxAttempts: 2, Backoff: time.Minute}, func(context.Context, *Job) error {
+		return exception.New("smtp: connection refused")
+	})
+	job, err := Enqueue(conn, testArgs{})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	claimed := claimOne(t, q)
+	before := time.Now().UTC()
+	q.run(&claimed)
+	retrying := getJob(t, conn, job.ID)
+	if retrying.Status != StatusRetrying || !strings.Contains(retrying.LastError, "connection refused") || len(retrying.LockedBy) > 0 {
+		t.Fatalf("a failed job should be retried, got %+v", retrying)
+	}
+	if retrying.RunAt.Before(before.Add(time.Minute)) || retrying.RunAt.After(time.Now().UTC().Add(time.Minute)) {
+		t.Fatalf("the retry should wait out the backoff, got %v", retrying.RunAt)
+	}
+	if jobs, err := q.Claim(10); err != nil || len(jobs) != 0 {
+		t.Fatalf("a job shouldn't be retried before its backoff, got %+v %v", jobs, err)
+	}
+
+	if err := conn.Invoke().Exec(`UPDATE job SET run_at = ?1 WHERE id = ?2`, time.Now().UTC().Add(-time.Second), job.ID); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	claimed = claimOne(t, q)
+	q.run(&claimed)
+	dead := getJob(t, conn, job.ID)
+	if dead.Status != StatusDead || dead.Attempts != 2 || dead.FinishedUTC == nil {
+		t.Fatalf("a job that fails its last attempt should be dead, got %+v", dead)
+	}
+}
+
+func TestRunRecordsPanics(t *testing.T) {
+	conn, q := newTestQueue(t, Config{}, func(context.Context, *Job) error { panic("boom") })
+	job, err := Enqueue(conn, testArgs{})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	claimed := claimOne(t, q)
+	q.run(&claimed)
+	failed := getJob(t, conn, job.ID)
+	if failed.Status != StatusRetrying || !strings.Contains(failed.LastError, string(ErrPanic)) {
+		t.Fatalf("a handler that panics should fail the attempt, got %+v", failed)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	q := NewQueueFromConfig(nil, &Config{Backoff: 30 * time.Second, MaxBackoff: time.Hour})
+	testCases := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{attempts: 0, expected: 30 * time.Second},
+		{attempts: 1, expected: 30 * time.Second},
+		{attempts: 2, expected: time.Minute},
+		{attempts: 3, expected: 2 * time.Minute},
+		{attempts: 7, expected: 32 * time.Minute},
+		{attempts: 8, expected: time.Hour},
+		{attempts: 1000, expected: time.Hour},
+	}
+	for _, tc := range testCases {
+		if actual := q.Backoff(tc.attempts); actual != tc.expected {
+			t.Errorf("attempts %d: want %v, got %v", tc.attempts, tc.expected, actual)
+		}
+	}
+}
+
+func TestDeleteSucceeded(t *testing.T) {
+	conn, q := newTestQueue(t, Config{Retention: 24 * time.Hour}, nil)
+	now := time.Now().UTC()
+	finished := func(status string, finishedUTC time.Time) *Job {
+		job, err := New(testArgs{})
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		job.Status, job.FinishedUTC = status, &finishedUTC
+		if err := Add(conn, job); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		return job
+	}
+	expired := finished(StatusSucceeded, now.Add(-25*time.Hour))
+	recent := finished(StatusSucceeded, now.Add(-23*time.Hour))
+	dead := finished(StatusDead, now.Add(-25*time.Hour))
+
+	if err := q.deleteSucceeded(); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if getJob(t, conn, expired.ID).ID != 0 {
+		t.Fatal("succeeded jobs past the retention window should be deleted")
+	}
+	if getJob(t, conn, recent.ID).ID == 0 || getJob(t, conn, dead.ID).ID == 0 {
+		t.Fatal("recent succeeded jobs and dead jobs should be kept")
+	}
+}
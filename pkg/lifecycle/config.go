@@ -0,0 +1,34 @@
+package lifecycle
+
+import (
+	"time"
+
+	"github.com/blend/go-sdk/util"
+)
+
+const (
+	// DefaultShutdownTimeout is the default deadline for all shutdown hooks to finish.
+	DefaultShutdownTimeout = 30 * time.Second
+	// DefaultDrainDelay is the default time to wait after reporting not ready before running shutdown hooks.
+	// It's a few readiness probe intervals, so load balancers stop routing to the process before it stops serving.
+	DefaultDrainDelay = 5 * time.Second
+)
+
+// Config is the lifecycle config.
+type Config struct {
+	// ShutdownTimeout is the deadline for all shutdown hooks to finish.
+	ShutdownTimeout time.Duration `json:"shutdownTimeout,omitempty" yaml:"shutdownTimeout,omitempty" env:"SHUTDOWN_TIMEOUT"`
+	// DrainDelay is how long to keep serving after readiness flips to unhealthy,
+	// giving load balancers time to stop routing new requests to the process.
+	DrainDelay time.Duration `json:"drainDelay,omitempty" yaml:"drainDelay,omitempty" env:"SHUTDOWN_DRAIN_DELAY"`
+}
+
+// GetShutdownTimeout returns a property or a default.
+func (c Config) GetShutdownTimeout(inherited ...time.Duration) time.Duration {
+	return util.Coalesce.Duration(c.ShutdownTimeout, DefaultShutdownTimeout, inherited...)
+}
+
+// GetDrainDelay returns a property or a default.
+func (c Config) GetDrainDelay(inherited ...time.Duration) time.Duration {
+	return util.Coalesce.Duration(c.DrainDelay, DefaultDrainDelay, inherited...)
+}
@@ -0,0 +1,73 @@
+package lifecycle
+
+import (
+	"context"
+	"time"
+
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/logger"
+)
+
+// NewInterval returns a background task that runs an action every interval.
+func NewInterval(interval time.Duration, action func() error) *Interval {
+	return &Interval{
+		interval: interval,
+		action:   action,
+	}
+}
+
+// Interval runs an action on a ticker in the background until it's stopped.
+// Errors from the action are logged as warnings and don't stop the loop.
+type Interval struct {
+	log      *logger.Logger
+	interval time.Duration
+	action   func() error
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// WithLogger sets the logger.
+func (i *Interval) WithLogger(log *logger.Logger) *Interval {
+	i.log = log
+	return i
+}
+
+// Start starts running the action in the background.
+func (i *Interval) Start() {
+	i.stop = make(chan struct{})
+	i.done = make(chan struct{})
+	go i.loop()
+}
+
+func (i *Interval) loop() {
+	defer close(i.done)
+
+	ticker := time.NewTicker(i.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-i.stop:
+			return
+		case <-ticker.C:
+			if err := i.action(); err != nil && i.log != nil {
+				i.log.Warning(err)
+			}
+		}
+	}
+}
+
+// Stop stops the loop, waiting for a run in progress to finish or the context to expire.
+// It can be passed directly to `Manager.OnShutdown`.
+func (i *Interval) Stop(ctx context.Context) error {
+	if i.stop == nil {
+		return nil
+	}
+	close(i.stop)
+	select {
+	case <-i.done:
+		return nil
+	case <-ctx.Done():
+		return exception.New(ctx.Err())
+	}
+}
@@ -0,0 +1,58 @@
+package lifecycle_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/exception"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/lifecycle"
+)
+
+func TestInterval(t *testing.T) {
+	var runs int32
+	interval := lifecycle.NewInterval(5*time.Millisecond, func() error {
+		atomic.AddInt32(&runs, 1)
+		return exception.New("failed")
+	})
+	if err := interval.Stop(context.Background()); err != nil {
+		t.Fatalf("stopping an interval that wasn't started should do nothing, got %v", err)
+	}
+	interval.Start()
+	time.Sleep(50 * time.Millisecond)
+	if err := interval.Stop(context.Background()); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	stopped := atomic.LoadInt32(&runs)
+	if stopped < 2 {
+		t.Fatalf("the action should keep running after it fails, ran %d times", stopped)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if atomic.LoadInt32(&runs) != stopped {
+		t.Fatal("the action shouldn't run once the interval is stopped")
+	}
+}
+
+func TestIntervalStopDeadline(t *testing.T) {
+	release := make(chan struct{})
+	running := make(chan struct{}, 1)
+	interval := lifecycle.NewInterval(time.Millisecond, func() error {
+		select {
+		case running <- struct{}{}:
+		default:
+		}
+		<-release
+		return nil
+	})
+	interval.Start()
+	<-running
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := interval.Stop(ctx); err == nil {
+		t.Fatal("stop should give up when a run doesn't finish before the context expires")
+	}
+	close(release)
+}
@@ -0,0 +1,188 @@
+package lifecycle
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/logger"
+)
+
+const (
+	// ErrShutdownTimeout is returned when a hook doesn't finish before the shutdown deadline.
+	ErrShutdownTimeout exception.Class = "shutdown hook timed out"
+
+	// hookGracePeriod is how long hooks that start after the deadline has passed are given to finish.
+	hookGracePeriod = time.Second
+)
+
+// New returns a new lifecycle manager.
+func New() *Manager {
+	return &Manager{
+		shutdownTimeout: DefaultShutdownTimeout,
+		drainDelay:      DefaultDrainDelay,
+	}
+}
+
+// NewFromConfig returns a new lifecycle manager from a config.
+func NewFromConfig(cfg *Config) *Manager {
+	return New().
+		WithShutdownTimeout(cfg.GetShutdownTimeout()).
+		WithDrainDelay(cfg.GetDrainDelay())
+}
+
+// Hook is a named shutdown step.
+type Hook struct {
+	Name   string
+	Action func(context.Context) error
+}
+
+// Manager coordinates graceful shutdown of the process.
+//
+// On shutdown it first flips to draining (so readiness checks start failing),
+// waits out the drain delay, then runs each hook in the order it was registered
+// under a single shared deadline. A hook that misses the deadline is abandoned;
+// hooks after it still get a short grace period, so the logger can always be flushed last.
+type Manager struct {
+	log *logger.Logger
+
+	shutdownTimeout time.Duration
+	drainDelay      time.Duration
+
+	hooksLock sync.Mutex
+	hooks     []Hook
+
+	draining     int32
+	shutdownOnce sync.Once
+	shutdownErr  error
+}
+
+// WithLogger sets the logger.
+func (m *Manager) WithLogger(log *logger.Logger) *Manager {
+	m.log = log
+	return m
+}
+
+// Logger returns the logger.
+func (m *Manager) Logger() *logger.Logger {
+	return m.log
+}
+
+// WithShutdownTimeout sets the shutdown deadline.
+func (m *Manager) WithShutdownTimeout(timeout time.Duration) *Manager {
+	m.shutdownTimeout = timeout
+	return m
+}
+
+// ShutdownTimeout returns the shutdown deadline.
+func (m *Manager) ShutdownTimeout() time.Duration {
+	return m.shutdownTimeout
+}
+
+// WithDrainDelay sets the drain delay.
+func (m *Manager) WithDrainDelay(delay time.Duration) *Manager {
+	m.drainDelay = delay
+	return m
+}
+
+// DrainDelay returns the drain delay.
+func (m *Manager) DrainDelay() time.Duration {
+	return m.drainDelay
+}
+
+// OnShutdown registers a shutdown hook.
+// Hooks run in registration order.
+func (m *Manager) OnShutdown(name string, action func(context.Context) error) *Manager {
+	m.hooksLock.Lock()
+	defer m.hooksLock.Unlock()
+	m.hooks = append(m.hooks, Hook{Name: name, Action: action})
+	return m
+}
+
+// Draining returns if shutdown has started.
+func (m *Manager) Draining() bool {
+	return atomic.LoadInt32(&m.draining) == 1
+}
+
+// Wait blocks until the process receives SIGINT or SIGTERM, then shuts down.
+func (m *Manager) Wait() error {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	sig := <-quit
+	signal.Stop(quit)
+	m.infof("received %v, shutting down", sig)
+	return m.Shutdown()
+}
+
+// Shutdown runs the shutdown hooks.
+// It is safe to call more than once; only the first call does anything.
+func (m *Manager) Shutdown() error {
+	m.shutdownOnce.Do(func() {
+		m.shutdownErr = m.shutdown()
+	})
+	return m.shutdownErr
+}
+
+func (m *Manager) shutdown() error {
+	atomic.StoreInt32(&m.draining, 1)
+	if m.drainDelay > 0 {
+		m.infof("draining for %v", m.drainDelay)
+		time.Sleep(m.drainDelay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.shutdownTimeout)
+	defer cancel()
+
+	m.hooksLock.Lock()
+	hooks := make([]Hook, len(m.hooks))
+	copy(hooks, m.hooks)
+	m.hooksLock.Unlock()
+
+	var errs []error
+	for _, hook := range hooks {
+		m.infof("shutting down %s", hook.Name)
+		if err := m.run(ctx, hook); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return exception.Nest(errs...)
+}
+
+func (m *Manager) run(ctx context.Context, hook Hook) error {
+	if ctx.Err() != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), hookGracePeriod)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- exception.New(r).WithMessagef("hook: %s", hook.Name)
+			}
+		}()
+		done <- hook.Action(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return exception.New(err).WithMessagef("hook: %s", hook.Name)
+		}
+		return nil
+	case <-ctx.Done():
+		return exception.New(ErrShutdownTimeout).WithMessagef("hook: %s", hook.Name)
+	}
+}
+
+func (m *Manager) infof(format string, args ...interface{}) {
+	if m.log != nil {
+		m.log.SyncInfof(format, args...)
+	}
+}
@@ -0,0 +1,116 @@
+package lifecycle_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/exception"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/lifecycle"
+)
+
+func TestShutdownRunsHooksInOrder(t *testing.T) {
+	m := lifecycle.New().WithDrainDelay(0)
+	var order []string
+	for _, name := range []string{"web", "jobs", "db", "logger"} {
+		name := name
+		m.OnShutdown(name, func(context.Context) error {
+			order = append(order, name)
+			return nil
+		})
+	}
+	m.OnShutdown("panics", func(context.Context) error { panic("boom") })
+	m.OnShutdown("fails", func(context.Context) error { return exception.New("failed") })
+	m.OnShutdown("last", func(context.Context) error {
+		order = append(order, "last")
+		return nil
+	})
+
+	err := m.Shutdown()
+	if fmt.Sprint(order) != "[web jobs db logger last]" {
+		t.Fatalf("hooks should run in the order they're registered, even after one fails, got %v", order)
+	}
+	if err == nil {
+		t.Fatal("shutdown should return the hooks' errors")
+	}
+	if again := m.Shutdown(); again != err || len(order) != 5 {
+		t.Fatalf("shutting down again shouldn't run the hooks again, got %v", order)
+	}
+}
+
+func TestShutdownDrainDelay(t *testing.T) {
+	const drainDelay = 50 * time.Millisecond
+	m := lifecycle.New().WithDrainDelay(drainDelay)
+	if m.Draining() {
+		t.Fatal("the manager shouldn't be draining before shutdown")
+	}
+	var drainingInHook bool
+	var elapsed time.Duration
+	start := time.Now()
+	m.OnShutdown("web", func(context.Context) error {
+		drainingInHook = m.Draining()
+		elapsed = time.Since(start)
+		return nil
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- m.Shutdown() }()
+	time.Sleep(drainDelay / 5)
+	if !m.Draining() {
+		t.Fatal("the manager should be draining as soon as shutdown starts")
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !drainingInHook || elapsed < drainDelay {
+		t.Fatalf("hooks should run once the drain delay has passed, ran after %v", elapsed)
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	m := lifecycle.New().WithDrainDelay(0).WithShutdownTimeout(timeout)
+
+	// abandoned hooks keep running, so they report their deadlines on a channel.
+	deadlines := make(chan time.Time, 2)
+	record := func(ctx context.Context) {
+		deadline, _ := ctx.Deadline()
+		deadlines <- deadline
+	}
+	m.OnShutdown("web", func(ctx context.Context) error {
+		record(ctx)
+		time.Sleep(timeout / 2)
+		return nil
+	})
+	m.OnShutdown("jobs", func(ctx context.Context) error {
+		record(ctx)
+		// blocks past the deadline, ex. a job that doesn't check its context.
+		time.Sleep(3 * timeout)
+		return nil
+	})
+	var lateDeadline time.Duration
+	var lateRan bool
+	m.OnShutdown("logger", func(ctx context.Context) error {
+		deadline, _ := ctx.Deadline()
+		lateDeadline = time.Until(deadline)
+		lateRan = ctx.Err() == nil
+		return nil
+	})
+
+	start := time.Now()
+	err := m.Shutdown()
+	if elapsed := time.Since(start); elapsed > 2*timeout {
+		t.Fatalf("a hook that misses the deadline should be abandoned, shutdown took %v", elapsed)
+	}
+	if !exception.Is(err, lifecycle.ErrShutdownTimeout) {
+		t.Fatalf("expected %v, got %v", lifecycle.ErrShutdownTimeout, err)
+	}
+	if first, second := <-deadlines, <-deadlines; !first.Equal(second) {
+		t.Fatalf("hooks should share one deadline, got %v and %v", first, second)
+	}
+	if !lateRan || lateDeadline <= timeout || lateDeadline > time.Second {
+		t.Fatalf("hooks after the deadline should get the grace period, got %v", lateDeadline)
+	}
+}
@@ -0,0 +1,33 @@
+package magiclink
+
+import (
+	"time"
+
+	"github.com/blend/go-sdk/util"
+)
+
+const (
+	// DefaultTTL is the default time a link works for after it's sent.
+	DefaultTTL = 30 * time.Minute
+	// DefaultResendInterval is the default time after sending a guest a link before another can be sent.
+	DefaultResendInterval = time.Minute
+)
+
+// Config is the magic link config.
+type Config struct {
+	// TTL is the time a link works for after it's sent.
+	TTL time.Duration `json:"ttl,omitempty" yaml:"ttl,omitempty" env:"MAGIC_LINK_TTL"`
+	// ResendInterval is the time after sending a guest a link before another can be sent,
+	// so the form can't be used to flood an inbox.
+	ResendInterval time.Duration `json:"resendInterval,omitempty" yaml:"resendInterval,omitempty" env:"MAGIC_LINK_RESEND_INTERVAL"`
+}
+
+// GetTTL returns a property or a default.
+func (c Config) GetTTL(inherited ...time.Duration) time.Duration {
+	return util.Coalesce.Duration(c.TTL, DefaultTTL, inherited...)
+}
+
+// GetResendInterval returns a property or a default.
+func (c Config) GetResendInterval(inherited ...time.Duration) time.Duration {
+	return util.Coalesce.Duration(c.ResendInterval, DefaultResendInterval, inherited...)
+}
@@ -0,0 +1,92 @@
+package magiclink
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blend/go-sdk/exception"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/job"
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/notify"
+)
+
+const (
+	// Kind is the job kind for sending login links.
+	Kind = "magic_link"
+)
+
+// Args are the login link job args.
+type Args struct {
+	// Email is the address someone asked for a link at; it may not be on the guest list.
+	Email string `json:"email"`
+}
+
+// Kind implements job.Args.
+func (a Args) Kind() string {
+	return Kind
+}
+
+// Handler returns the login link job handler, which issues a link to each guest with the email and
+// sends it over a channel; emails that aren't on the guest list are dropped.
+//
+// Links are sent from a job rather than the request so the request does the same work whether or
+// not the email is on the guest list, and can't be timed to find out who's invited.
+// A link that fails to send is discarded, so the retry isn't refused as too soon.
+func Handler(m *Manager, channel notify.Channel, baseURL string) job.Handler {
+	return job.HandlerFunc(func(ctx context.Context, j *job.Job) error {
+		var args Args
+		if err := j.Decode(&args); err != nil {
+			return err
+		}
+		guests, err := model.GuestsByEmail(m.conn, args.Email)
+		if err != nil {
+			return err
+		}
+		var errs []error
+		for _, guest := range guests {
+			link, err := m.issue(guest)
+			if exception.Is(err, ErrTooSoon) {
+				continue
+			}
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			to := notify.Recipient{Name: guest.Name, Email: guest.Email, Channels: []string{notify.ChannelEmail}}
+			if err := channel.Send(ctx, to, m.message(guest, m.token(*link), baseURL)); err != nil {
+				errs = append(errs, err)
+				if err := m.conn.Invoke().Delete(link); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+		return exception.Nest(errs...)
+	})
+}
+
+// message returns the email with a guest's login link; without a base url the link is sent as a path.
+func (m *Manager) message(guest model.Guest, token, baseURL string) notify.Message {
+	return notify.Message{
+		Subject: "Your RSVP link",
+		Body: fmt.Sprintf("Hi %s,\n\nHere's the link to your RSVP:\n\n%s/rsvp/login/%s\n\nIt works once, for the next %s. If you didn't ask for it, you can ignore this email.",
+			guest.Name, strings.TrimSuffix(baseURL, "/"), token, m.DescribeTTL()),
+	}
+}
+
+// DescribeTTL returns the time a link works for in words, ex. `30 minutes`.
+func (m *Manager) DescribeTTL() string {
+	if m.ttl >= time.Hour && m.ttl%time.Hour == 0 {
+		return plural(int(m.ttl/time.Hour), "hour")
+	}
+	return plural(int(m.ttl/time.Minute), "minute")
+}
+
+func plural(count int, unit string) string {
+	if count == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", count, unit)
+}
@@ -0,0 +1,97 @@
+package magiclink_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/exception"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/job"
+	"github.com/wcharczuk/katwillmarry.com/pkg/magiclink"
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/notify"
+	"github.com/wcharczuk/katwillmarry.com/pkg/schema/schematest"
+)
+
+// outbox records the messages it's sent, and fails while `err` is set.
+type outbox struct {
+	sent []notify.Message
+	err  error
+}
+
+func (o *outbox) Name() string { return notify.ChannelEmail }
+
+func (o *outbox) Send(ctx context.Context, to notify.Recipient, message notify.Message) error {
+	if o.err != nil {
+		return o.err
+	}
+	o.sent = append(o.sent, message)
+	return nil
+}
+
+func TestHandler(t *testing.T) {
+	conn := schematest.Open(t)
+	links := magiclink.NewManager(conn, []byte("secret")).WithResendInterval(time.Hour)
+	now := time.Now().UTC()
+	household := model.Household{Name: "The Smiths", CreatedUTC: now, UpdatedUTC: now}
+	if err := conn.Invoke().Create(&household); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	guest := model.Guest{HouseholdID: household.ID, Name: "Alice", Email: "alice@example.com", CreatedUTC: now, UpdatedUTC: now}
+	if err := conn.Invoke().Create(&guest); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	box := &outbox{}
+	handler := magiclink.Handler(links, box, "https://katwillmarry.test/")
+	run := func(email string) error {
+		j, err := job.New(magiclink.Args{Email: email})
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		return handler.Handle(context.Background(), j)
+	}
+
+	if err := run("stranger@example.com"); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(box.sent) != 0 {
+		t.Fatalf("an email that isn't on the guest list shouldn't be sent anything, sent %v", box.sent)
+	}
+
+	// a link that fails to send is discarded, so the retry can send another.
+	box.err = exception.New("email: send failed")
+	if err := run("Alice@Example.com"); err == nil {
+		t.Fatal("the job should fail if the link can't be sent")
+	}
+	box.err = nil
+	if err := run("Alice@Example.com"); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(box.sent) != 1 {
+		t.Fatalf("the retry should send the link, sent %v", box.sent)
+	}
+	body := box.sent[0].Body
+	start := strings.Index(body, "https://katwillmarry.test/rsvp/login/")
+	if start < 0 {
+		t.Fatalf("the message should have the link, got %q", body)
+	}
+	token := strings.Fields(body[start+len("https://katwillmarry.test/rsvp/login/"):])[0]
+	link, err := links.Redeem(token)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if link.HouseholdID != household.ID {
+		t.Fatalf("the link should log in to the guest's household, got %d", link.HouseholdID)
+	}
+
+	// a second request inside the resend interval is dropped rather than failing the job.
+	if err := run("alice@example.com"); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(box.sent) != 1 {
+		t.Fatalf("a link shouldn't be sent again inside the resend interval, sent %d", len(box.sent))
+	}
+}
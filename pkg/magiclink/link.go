@@ -0,0 +1,211 @@
+// Package magiclink logs guests in to their household with a link emailed to them, for guests
+// who've lost their invite.
+//
+// A link's token is its id and expiry signed with an hmac, so it can't be guessed or altered;
+// the link is recorded when it's sent and marked when it's used, so it only works once.
+package magiclink
+
+import (
+	"crypto/hmac"
+	"database/sql"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/util"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/query"
+)
+
+const (
+	// ErrInvalidLink is returned for a token that's malformed, has a bad signature or whose guest is gone.
+	ErrInvalidLink exception.Class = "magiclink: invalid link"
+	// ErrLinkExpired is returned for a link that's past its expiry.
+	ErrLinkExpired exception.Class = "magiclink: link expired"
+	// ErrLinkUsed is returned for a link that's already been used.
+	ErrLinkUsed exception.Class = "magiclink: link already used"
+	// ErrTooSoon is returned when a guest was sent a link less than the resend interval ago.
+	ErrTooSoon exception.Class = "magiclink: a link was sent recently"
+
+	// keySize is the size of the key generated when one isn't given.
+	keySize = 64
+)
+
+// Link is a sent login link.
+type Link struct {
+	ID          int64      `db:"id,pk,serial"`
+	GuestID     int64      `db:"guest_id"`
+	HouseholdID int64      `db:"household_id"`
+	CreatedUTC  time.Time  `db:"created_utc"`
+	ExpiresUTC  time.Time  `db:"expires_utc"`
+	UsedUTC     *time.Time `db:"used_utc"`
+}
+
+// TableName returns the mapped table name.
+func (l Link) TableName() string {
+	return "magic_link"
+}
+
+// NewManager returns a new manager that signs links with a key.
+// If the key is empty a random one is used, and links stop working when the process restarts.
+func NewManager(conn *db.Connection, key []byte) *Manager {
+	if len(key) == 0 {
+		key = util.Crypto.MustCreateKey(keySize)
+	}
+	return &Manager{
+		conn:           conn,
+		key:            key,
+		ttl:            DefaultTTL,
+		resendInterval: DefaultResendInterval,
+	}
+}
+
+// NewManagerFromConfig returns a new manager from a config.
+func NewManagerFromConfig(conn *db.Connection, cfg *Config, key []byte) *Manager {
+	return NewManager(conn, key).
+		WithTTL(cfg.GetTTL()).
+		WithResendInterval(cfg.GetResendInterval())
+}
+
+// Manager issues and redeems login links.
+type Manager struct {
+	conn           *db.Connection
+	key            []byte
+	ttl            time.Duration
+	resendInterval time.Duration
+}
+
+// WithTTL sets the time a link works for after it's sent.
+func (m *Manager) WithTTL(ttl time.Duration) *Manager {
+	m.ttl = ttl
+	return m
+}
+
+// TTL returns the time a link works for after it's sent.
+func (m *Manager) TTL() time.Duration {
+	return m.ttl
+}
+
+// WithResendInterval sets the time after sending a guest a link before another can be sent.
+func (m *Manager) WithResendInterval(interval time.Duration) *Manager {
+	m.resendInterval = interval
+	return m
+}
+
+// Issue records a link for a guest, returning its token.
+// It returns `ErrTooSoon` if the guest was sent a link less than the resend interval ago.
+func (m *Manager) Issue(guest model.Guest, txs ...*sql.Tx) (string, error) {
+	link, err := m.issue(guest, txs...)
+	if err != nil {
+		return "", err
+	}
+	return m.token(*link), nil
+}
+
+func (m *Manager) issue(guest model.Guest, txs ...*sql.Tx) (*Link, error) {
+	now := time.Now().UTC()
+	count, err := query.Select(Link{}).
+		Where(query.Eq("guest_id", guest.ID)).
+		And(query.Gt("created_utc", now.Add(-m.resendInterval))).
+		Count(m.conn, txs...)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, exception.New(ErrTooSoon).WithMessagef("guest: %d", guest.ID)
+	}
+
+	link := Link{
+		GuestID:     guest.ID,
+		HouseholdID: guest.HouseholdID,
+		CreatedUTC:  now,
+		ExpiresUTC:  now.Add(m.ttl),
+	}
+	if err := m.conn.Invoke(txs...).Create(&link); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// Redeem checks a token and marks its link used, returning the link.
+// A link can only be redeemed once; later attempts return `ErrLinkUsed`.
+func (m *Manager) Redeem(token string, txs ...*sql.Tx) (*Link, error) {
+	id, expires, ok := m.verify(token)
+	if !ok {
+		return nil, exception.New(ErrInvalidLink)
+	}
+	now := time.Now().UTC()
+	if !now.Before(expires) {
+		return nil, exception.New(ErrLinkExpired).WithMessagef("link: %d", id)
+	}
+
+	var link Link
+	if err := m.conn.Invoke(txs...).Get(&link, id); err != nil {
+		return nil, err
+	}
+	if link.ID == 0 {
+		return nil, exception.New(ErrInvalidLink).WithMessagef("link: %d", id)
+	}
+	var guest model.Guest
+	if err := m.conn.Invoke(txs...).Get(&guest, link.GuestID); err != nil {
+		return nil, err
+	}
+	if guest.ID == 0 {
+		return nil, exception.New(ErrInvalidLink).WithMessagef("link: %d", id)
+	}
+
+	// the link is marked used only if it wasn't already, so two requests racing with the same
+	// token can't both log in.
+	marked, err := m.markUsed(id, now, txs...)
+	if err != nil {
+		return nil, err
+	}
+	if !marked {
+		return nil, exception.New(ErrLinkUsed).WithMessagef("link: %d", id)
+	}
+	link.UsedUTC = &now
+	return &link, nil
+}
+
+// token returns the token for a link: its id and expiry, and their signature.
+func (m *Manager) token(link Link) string {
+	payload := strconv.FormatInt(link.ID, 10) + "." + strconv.FormatInt(link.ExpiresUTC.Unix(), 10)
+	return payload + "." + m.sign(payload)
+}
+
+// verify checks a token's signature, returning the link id and expiry it carries.
+func (m *Manager) verify(token string) (int64, time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, time.Time{}, false
+	}
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(m.sign(payload))) {
+		return 0, time.Time{}, false
+	}
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	return id, time.Unix(expires, 0).UTC(), true
+}
+
+func (m *Manager) sign(payload string) string {
+	return base64.RawURLEncoding.EncodeToString(util.Crypto.Hash(m.key, []byte("magiclink:"+payload)))
+}
+
+// markUsed sets when a link was used if it hasn't been, returning if it was set.
+func (m *Manager) markUsed(id int64, now time.Time, txs ...*sql.Tx) (bool, error) {
+	dialect := m.conn.Dialect()
+	statement := "UPDATE magic_link SET used_utc = " + dialect.Placeholder(1) + " WHERE id = " + dialect.Placeholder(2) + " AND used_utc IS NULL"
+	updated, err := m.conn.Invoke(txs...).WithLabel("magic_link_used").ExecAffected(statement, now, id)
+	return updated == 1, err
+}
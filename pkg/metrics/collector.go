@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/web"
+)
+
+const (
+	// Namespace prefixes every metric name.
+	Namespace = "katwillmarry_"
+
+	// MetricHTTPRequestDuration is the request latency histogram by route.
+	MetricHTTPRequestDuration = Namespace + "http_request_duration_seconds"
+	// MetricHTTPResponses is the response counter by route and status code.
+	MetricHTTPResponses = Namespace + "http_responses_total"
+	// MetricDBQueryDuration is the query latency histogram by query label.
+	MetricDBQueryDuration = Namespace + "db_query_duration_seconds"
+	// MetricDBQueryErrors is the failed query counter by query label.
+	MetricDBQueryErrors = Namespace + "db_query_errors_total"
+	// MetricRSVPResponses counts submitted rsvps by response.
+	MetricRSVPResponses = Namespace + "rsvp_responses_total"
+
+	// VerbRSVP is the verb of the audit events counted as rsvps, see `RSVPEvent`.
+	VerbRSVP = "rsvp"
+	// ResponseAttending is the response label of rsvps saying the guest is coming.
+	ResponseAttending = "attending"
+	// ResponseDeclined is the response label of rsvps saying the guest isn't coming.
+	ResponseDeclined = "declined"
+
+	// RouteMetrics is the route the metrics are served from on the healthz server.
+	RouteMetrics = "/metrics"
+	// ListenerMetrics is the uid of the metrics logger listeners.
+	ListenerMetrics = "metrics"
+
+	// ContentTypeText is the prometheus text exposition format content type.
+	ContentTypeText = "text/plain; version=0.0.4; charset=utf-8"
+
+	// unmatchedRoute is the route label for requests that didn't match a route.
+	unmatchedRoute = "unmatched"
+	// unlabeledQuery is the query label for queries run without a statement label.
+	unlabeledQuery = "unlabeled"
+)
+
+// New returns a new collector with the standard metrics registered.
+func New() *Collector {
+	c := &Collector{}
+	c.HTTPRequestDuration = c.Histogram(MetricHTTPRequestDuration, "HTTP request latency by route.", DefaultBuckets, "method", "route")
+	c.HTTPResponses = c.Counter(MetricHTTPResponses, "HTTP responses by route and status code.", "method", "route", "code")
+	c.DBQueryDuration = c.Histogram(MetricDBQueryDuration, "Database query latency by query label.", DefaultBuckets, "query")
+	c.DBQueryErrors = c.Counter(MetricDBQueryErrors, "Failed database queries by query label.", "query")
+	c.RSVPResponses = c.Counter(MetricRSVPResponses, "Submitted RSVPs by response.", "response")
+	return c
+}
+
+// Collector collects metrics from logger events and serves them in the prometheus text format.
+type Collector struct {
+	HTTPRequestDuration *HistogramVec
+	HTTPResponses       *CounterVec
+	DBQueryDuration     *HistogramVec
+	DBQueryErrors       *CounterVec
+	RSVPResponses       *CounterVec
+
+	familiesLock sync.Mutex
+	families     []family
+}
+
+// Counter returns the counter with the given name, registering it if it doesn't exist.
+func (c *Collector) Counter(name, help string, labels ...string) *CounterVec {
+	c.familiesLock.Lock()
+	defer c.familiesLock.Unlock()
+	for _, f := range c.families {
+		if typed, ok := f.(*CounterVec); ok && typed.Name() == name {
+			return typed
+		}
+	}
+	cv := &CounterVec{name: name, help: help, labels: labels, values: map[string]*counterValue{}}
+	c.families = append(c.families, cv)
+	return cv
+}
+
+// Histogram returns the histogram with the given name, registering it if it doesn't exist.
+func (c *Collector) Histogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	c.familiesLock.Lock()
+	defer c.familiesLock.Unlock()
+	for _, f := range c.families {
+		if typed, ok := f.(*HistogramVec); ok && typed.Name() == name {
+			return typed
+		}
+	}
+	hv := &HistogramVec{name: name, help: help, labels: labels, buckets: buckets, values: map[string]*histogramValue{}}
+	c.families = append(c.families, hv)
+	return hv
+}
+
+// RSVPEvent returns the event to trigger when a guest responds, which the collector counts by response.
+func RSVPEvent(principal string, attending bool) *logger.AuditEvent {
+	response := ResponseDeclined
+	if attending {
+		response = ResponseAttending
+	}
+	return logger.NewAuditEvent(principal, VerbRSVP).WithNoun("rsvp").WithProperty(response)
+}
+
+// Listen adds the http response, query and audit listeners to a logger.
+// Query and audit events are only triggered when their flags are enabled, so if they aren't
+// already they're enabled and hidden so the events don't start showing up in the output.
+func (c *Collector) Listen(log *logger.Logger) {
+	for _, flag := range []logger.Flag{logger.Query, logger.Audit} {
+		if !log.IsEnabled(flag) {
+			log.Enable(flag)
+			log.Hide(flag)
+		}
+	}
+	log.Listen(logger.HTTPResponse, ListenerMetrics, logger.NewHTTPResponseEventListener(c.httpResponseListener))
+	log.Listen(logger.Query, ListenerMetrics, logger.NewQueryEventListener(c.queryListener))
+	log.Listen(logger.Audit, ListenerMetrics, logger.NewAuditEventListener(c.auditListener))
+}
+
+// ServeHTTP implements http.Handler.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.familiesLock.Lock()
+	families := make([]family, len(c.families))
+	copy(families, c.families)
+	c.familiesLock.Unlock()
+
+	buffer := new(bytes.Buffer)
+	for _, f := range families {
+		f.Write(buffer)
+	}
+	w.Header().Set(web.HeaderContentType, ContentTypeText)
+	w.WriteHeader(http.StatusOK)
+	w.Write(buffer.Bytes())
+}
+
+func (c *Collector) httpResponseListener(e *logger.HTTPResponseEvent) {
+	route := e.Route()
+	if len(route) == 0 {
+		route = unmatchedRoute
+	}
+	var method string
+	if e.Request() != nil {
+		method = e.Request().Method
+	}
+	c.HTTPRequestDuration.Observe(e.Elapsed().Seconds(), method, route)
+	c.HTTPResponses.Inc(method, route, strconv.Itoa(e.StatusCode()))
+}
+
+func (c *Collector) queryListener(e *logger.QueryEvent) {
+	label := e.QueryLabel()
+	if len(label) == 0 {
+		label = unlabeledQuery
+	}
+	c.DBQueryDuration.Observe(e.Elapsed().Seconds(), label)
+	if e.Err() != nil {
+		c.DBQueryErrors.Inc(label)
+	}
+}
+
+func (c *Collector) auditListener(e *logger.AuditEvent) {
+	if e.Verb() == VerbRSVP {
+		c.RSVPResponses.Inc(e.Property())
+	}
+}
@@ -0,0 +1,95 @@
+package metrics_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/metrics"
+)
+
+// scrape returns the collector's metrics in the text format.
+func scrape(t *testing.T, c *metrics.Collector) string {
+	t.Helper()
+	rw := httptest.NewRecorder()
+	c.ServeHTTP(rw, httptest.NewRequest("GET", metrics.RouteMetrics, nil))
+	if rw.Code != http.StatusOK || rw.Header().Get(web.HeaderContentType) != metrics.ContentTypeText {
+		t.Fatalf("unexpected response %d %q", rw.Code, rw.Header().Get(web.HeaderContentType))
+	}
+	body, err := ioutil.ReadAll(rw.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestCollectorListen(t *testing.T) {
+	c := metrics.New()
+	log := logger.New(logger.HTTPResponse)
+	c.Listen(log)
+	if !log.IsEnabled(logger.Query) || !log.IsEnabled(logger.Audit) || !log.IsHidden(logger.Query) || !log.IsHidden(logger.Audit) {
+		t.Fatal("query and audit events should be enabled for the collector and hidden from the output")
+	}
+
+	req := httptest.NewRequest("POST", "/rsvp", nil)
+	log.SyncTrigger(logger.NewHTTPResponseEvent(req).WithRoute("/rsvp").WithStatusCode(http.StatusSeeOther).WithElapsed(20 * time.Millisecond))
+	log.SyncTrigger(logger.NewHTTPResponseEvent(req).WithRoute("/rsvp").WithStatusCode(http.StatusSeeOther).WithElapsed(2 * time.Second))
+	log.SyncTrigger(logger.NewHTTPResponseEvent(httptest.NewRequest("GET", "/nope", nil)).WithStatusCode(http.StatusNotFound))
+	log.SyncTrigger(logger.NewQueryEvent("SELECT 1", 3*time.Millisecond).WithQueryLabel("guest_get"))
+	log.SyncTrigger(logger.NewQueryEvent("SELECT 1", 30*time.Millisecond).WithQueryLabel("guest_get").WithErr(exception.New("db: closed")))
+	log.SyncTrigger(logger.NewQueryEvent("SELECT 1", time.Millisecond))
+	log.SyncTrigger(metrics.RSVPEvent("household:1", true))
+	log.SyncTrigger(metrics.RSVPEvent("household:1", true))
+	log.SyncTrigger(metrics.RSVPEvent("household:2", false))
+	log.SyncTrigger(logger.NewAuditEvent("owner@example.com", "update").WithNoun("household"))
+
+	output := scrape(t, c)
+	expected := []string{
+		`katwillmarry_http_request_duration_seconds_bucket{method="POST",route="/rsvp",le="0.025"} 1`,
+		`katwillmarry_http_request_duration_seconds_bucket{method="POST",route="/rsvp",le="2.5"} 2`,
+		`katwillmarry_http_request_duration_seconds_bucket{method="POST",route="/rsvp",le="+Inf"} 2`,
+		`katwillmarry_http_request_duration_seconds_count{method="POST",route="/rsvp"} 2`,
+		`katwillmarry_http_responses_total{method="POST",route="/rsvp",code="303"} 2`,
+		`katwillmarry_http_responses_total{method="GET",route="unmatched",code="404"} 1`,
+		`katwillmarry_db_query_duration_seconds_bucket{query="guest_get",le="0.005"} 1`,
+		`katwillmarry_db_query_duration_seconds_count{query="guest_get"} 2`,
+		`katwillmarry_db_query_duration_seconds_count{query="unlabeled"} 1`,
+		`katwillmarry_db_query_errors_total{query="guest_get"} 1`,
+		`katwillmarry_rsvp_responses_total{response="attending"} 2`,
+		`katwillmarry_rsvp_responses_total{response="declined"} 1`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(output, line+"\n") {
+			t.Fatalf("expected %q in:\n%s", line, output)
+		}
+	}
+	if strings.Contains(output, `response=""`) {
+		t.Fatalf("audit events that aren't rsvps shouldn't be counted:\n%s", output)
+	}
+}
+
+func TestCollectorRegister(t *testing.T) {
+	c := metrics.New()
+	jobs := c.Counter(metrics.Namespace+"jobs_total", "Jobs run by kind.", "kind")
+	if again := c.Counter(metrics.Namespace+"jobs_total", "Jobs run by kind.", "kind"); again != jobs {
+		t.Fatal("registering a counter again should return the existing one")
+	}
+	jobs.Add(2, "reminder")
+	output := scrape(t, c)
+	for _, line := range []string{
+		"# TYPE katwillmarry_jobs_total counter",
+		`katwillmarry_jobs_total{kind="reminder"} 2`,
+		"# TYPE katwillmarry_rsvp_responses_total counter",
+	} {
+		if !strings.Contains(output, line+"\n") {
+			t.Fatalf("expected %q in:\n%s", line, output)
+		}
+	}
+}
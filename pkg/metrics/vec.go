@@ -0,0 +1,169 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the default histogram upper bounds, in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// family is a metric name that can be written in the prometheus text format.
+type family interface {
+	Name() string
+	Write(w io.Writer)
+}
+
+// CounterVec is a set of counters partitioned by label values.
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	lock   sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labelValues []string
+	value       float64
+}
+
+// Name returns the metric name.
+func (cv *CounterVec) Name() string {
+	return cv.name
+}
+
+// Inc increments the counter for the given label values by one.
+func (cv *CounterVec) Inc(labelValues ...string) {
+	cv.Add(1, labelValues...)
+}
+
+// Add increments the counter for the given label values.
+func (cv *CounterVec) Add(delta float64, labelValues ...string) {
+	cv.lock.Lock()
+	defer cv.lock.Unlock()
+
+	key := strings.Join(labelValues, "\xff")
+	value, ok := cv.values[key]
+	if !ok {
+		value = &counterValue{labelValues: labelValues}
+		cv.values[key] = value
+	}
+	value.value += delta
+}
+
+// Write writes the counter in the prometheus text format.
+func (cv *CounterVec) Write(w io.Writer) {
+	cv.lock.Lock()
+	defer cv.lock.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n", cv.name, cv.help)
+	fmt.Fprintf(w, "# TYPE %s counter\n", cv.name)
+	for _, key := range sortedKeys(cv.values) {
+		value := cv.values[key]
+		fmt.Fprintf(w, "%s%s %s\n", cv.name, formatLabels(cv.labels, value.labelValues), formatFloat(value.value))
+	}
+}
+
+// HistogramVec is a set of histograms partitioned by label values.
+type HistogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	lock   sync.Mutex
+	values map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labelValues []string
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+// Name returns the metric name.
+func (hv *HistogramVec) Name() string {
+	return hv.name
+}
+
+// Observe adds an observation for the given label values.
+func (hv *HistogramVec) Observe(observed float64, labelValues ...string) {
+	hv.lock.Lock()
+	defer hv.lock.Unlock()
+
+	key := strings.Join(labelValues, "\xff")
+	value, ok := hv.values[key]
+	if !ok {
+		value = &histogramValue{labelValues: labelValues, counts: make([]uint64, len(hv.buckets))}
+		hv.values[key] = value
+	}
+	for index, upperBound := range hv.buckets {
+		if observed <= upperBound {
+			value.counts[index]++
+		}
+	}
+	value.count++
+	value.sum += observed
+}
+
+// Write writes the histogram in the prometheus text format.
+func (hv *HistogramVec) Write(w io.Writer) {
+	hv.lock.Lock()
+	defer hv.lock.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n", hv.name, hv.help)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", hv.name)
+	bucketLabels := append(append([]string{}, hv.labels...), "le")
+	for _, key := range sortedKeys(hv.values) {
+		value := hv.values[key]
+		for index, upperBound := range hv.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", hv.name, formatLabels(bucketLabels, append(append([]string{}, value.labelValues...), formatFloat(upperBound))), value.counts[index])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", hv.name, formatLabels(bucketLabels, append(append([]string{}, value.labelValues...), "+Inf")), value.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", hv.name, formatLabels(hv.labels, value.labelValues), formatFloat(value.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", hv.name, formatLabels(hv.labels, value.labelValues), value.count)
+	}
+}
+
+func sortedKeys(values interface{}) (keys []string) {
+	switch typed := values.(type) {
+	case map[string]*counterValue:
+		for key := range typed {
+			keys = append(keys, key)
+		}
+	case map[string]*histogramValue:
+		for key := range typed {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for index, name := range names {
+		var value string
+		if index < len(values) {
+			value = values[index]
+		}
+		pairs[index] = name + `="` + labelValueEscaper.Replace(value) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
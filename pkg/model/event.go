@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+)
+
+// Event is a part of the wedding on the schedule, ex. the ceremony or the rehearsal dinner.
+type Event struct {
+	ID          int64      `db:"id,pk,serial" json:"id"`
+	Name        string     `db:"name" json:"name"`
+	Description string     `db:"description" json:"description,omitempty"`
+	Location    string     `db:"location" json:"location,omitempty"`
+	StartsUTC   time.Time  `db:"starts_utc" json:"startsUTC"`
+	EndsUTC     *time.Time `db:"ends_utc" json:"endsUTC,omitempty"`
+	CreatedUTC  time.Time  `db:"created_utc" json:"createdUTC"`
+	UpdatedUTC  time.Time  `db:"updated_utc" json:"updatedUTC"`
+	DeletedUTC  *time.Time `db:"deleted_utc,nullable,softdelete" json:"deletedUTC,omitempty"`
+	Version     int64      `db:"version,version" json:"version"`
+}
+
+// TableName returns the mapped table name.
+func (e Event) TableName() string {
+	return "event"
+}
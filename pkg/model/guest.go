@@ -0,0 +1,119 @@
+package model
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/audit"
+	"github.com/wcharczuk/katwillmarry.com/pkg/notify"
+	"github.com/wcharczuk/katwillmarry.com/pkg/query"
+)
+
+const (
+	// NotifyByEmail is a guest who wants messages by email; it's the default.
+	NotifyByEmail = "email"
+	// NotifyBySMS is a guest who wants messages by text.
+	NotifyBySMS = "sms"
+	// NotifyByBoth is a guest who wants messages by email and text.
+	NotifyByBoth = "both"
+)
+
+// Guest is an invited person.
+type Guest struct {
+	ID          int64  `db:"id,pk,serial" json:"id"`
+	HouseholdID int64  `db:"household_id" json:"householdID"`
+	Name        string `db:"name" json:"name"`
+	Email       string `db:"email" json:"email,omitempty"`
+	Phone       string `db:"phone,encrypted" json:"phone,omitempty"`
+	// DietaryNotes are allergies, dietary and medical notes for the caterer.
+	DietaryNotes string `db:"dietary_notes,encrypted" json:"dietaryNotes,omitempty"`
+	// NotifyBy is how the guest wants reminders and updates; empty is `NotifyByEmail`.
+	NotifyBy string `db:"notify_by" json:"notifyBy,omitempty"`
+	// SMSOptOutUTC is when the guest texted STOP; they aren't sent texts until they text START.
+	SMSOptOutUTC *time.Time `db:"sms_opt_out_utc" json:"smsOptOutUTC,omitempty"`
+	CreatedUTC   time.Time  `db:"created_utc" json:"createdUTC"`
+	UpdatedUTC   time.Time  `db:"updated_utc" json:"updatedUTC"`
+	DeletedUTC   *time.Time `db:"deleted_utc,nullable,softdelete" json:"deletedUTC,omitempty"`
+	Version      int64      `db:"version,version" json:"version"`
+}
+
+// TableName returns the mapped table name.
+func (g Guest) TableName() string {
+	return "guest"
+}
+
+// AuditScope implements `audit.Scoper`.
+func (g Guest) AuditScope() string {
+	return HouseholdScope(g.HouseholdID)
+}
+
+// Recipient returns the guest as a notification recipient, with the channels they want
+// messages on and can get them on.
+func (g Guest) Recipient() notify.Recipient {
+	recipient := notify.Recipient{Name: g.Name, Email: g.Email, Phone: g.Phone}
+	notifyBy := g.NotifyBy
+	if len(notifyBy) == 0 {
+		notifyBy = NotifyByEmail
+	}
+	if notifyBy != NotifyBySMS && len(g.Email) > 0 {
+		recipient.Channels = append(recipient.Channels, notify.ChannelEmail)
+	}
+	if notifyBy != NotifyByEmail && g.SMSOptOutUTC == nil && len(notify.NormalizePhone(g.Phone)) > 0 {
+		recipient.Channels = append(recipient.Channels, notify.ChannelSMS)
+	}
+	return recipient
+}
+
+// SetSMSOptOut records that the guests with a phone number texted STOP (or START, to opt back in),
+// on behalf of a principal, returning the number of guests changed.
+//
+// Phone numbers are encrypted so they can't be matched in sql; every guest is read and compared.
+func SetSMSOptOut(conn *db.Connection, principal, phone string, optOut bool, txs ...*sql.Tx) (int, error) {
+	phone = notify.NormalizePhone(phone)
+	if len(phone) == 0 {
+		return 0, nil
+	}
+	var guests []Guest
+	if err := conn.Invoke(txs...).GetAll(&guests); err != nil {
+		return 0, err
+	}
+	now := time.Now().UTC()
+	var changed int
+	for _, guest := range guests {
+		if notify.NormalizePhone(guest.Phone) != phone || (guest.SMSOptOutUTC != nil) == optOut {
+			continue
+		}
+		guest.SMSOptOutUTC = nil
+		if optOut {
+			guest.SMSOptOutUTC = &now
+		}
+		guest.UpdatedUTC = now
+		if err := audit.Invoke(conn, principal, txs...).Update(&guest); err != nil {
+			return changed, err
+		}
+		changed++
+	}
+	return changed, nil
+}
+
+// GuestsByEmail returns the guests with an email address, ignoring case.
+func GuestsByEmail(conn *db.Connection, email string, txs ...*sql.Tx) ([]Guest, error) {
+	email = strings.TrimSpace(email)
+	if len(email) == 0 {
+		return nil, nil
+	}
+	var guests []Guest
+	if err := query.Select(Guest{}).OrderBy(query.Asc("id")).OutMany(conn, &guests, txs...); err != nil {
+		return nil, err
+	}
+	var matches []Guest
+	for _, guest := range guests {
+		if strings.EqualFold(strings.TrimSpace(guest.Email), email) {
+			matches = append(matches, guest)
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/query"
+)
+
+// householdUserIDPrefix starts the session user ids of guests, see `HouseholdUserID`.
+const householdUserIDPrefix = "household:"
+
+// Household is a group of guests invited together, ex. a couple and their kids.
+// RSVP reminders and invitations go to a household.
+type Household struct {
+	ID   int64  `db:"id,pk,serial" json:"id"`
+	Name string `db:"name" json:"name"`
+	// Address is the mailing address for invitations.
+	Address    string     `db:"address,encrypted" json:"address,omitempty"`
+	CreatedUTC time.Time  `db:"created_utc" json:"createdUTC"`
+	UpdatedUTC time.Time  `db:"updated_utc" json:"updatedUTC"`
+	DeletedUTC *time.Time `db:"deleted_utc,nullable,softdelete" json:"deletedUTC,omitempty"`
+	Version    int64      `db:"version,version" json:"version"`
+}
+
+// TableName returns the mapped table name.
+func (h Household) TableName() string {
+	return "household"
+}
+
+// AuditScope implements `audit.Scoper`.
+func (h Household) AuditScope() string {
+	return HouseholdScope(h.ID)
+}
+
+// HouseholdScope returns the audit scope for a household and its guests and rsvps.
+func HouseholdScope(householdID int64) string {
+	return "household:" + strconv.FormatInt(householdID, 10)
+}
+
+// HouseholdUserID returns the session user id of a guest logged in to their household.
+func HouseholdUserID(householdID int64) string {
+	return householdUserIDPrefix + strconv.FormatInt(householdID, 10)
+}
+
+// ParseHouseholdUserID returns the household a session user id is logged in to; it returns false
+// for the user ids of admins.
+func ParseHouseholdUserID(userID string) (int64, bool) {
+	if !strings.HasPrefix(userID, householdUserIDPrefix) {
+		return 0, false
+	}
+	householdID, err := strconv.ParseInt(strings.TrimPrefix(userID, householdUserIDPrefix), 10, 64)
+	if err != nil || householdID <= 0 {
+		return 0, false
+	}
+	return householdID, true
+}
+
+// Guests returns the guests in the household.
+func (h Household) Guests(conn *db.Connection, txs ...*sql.Tx) ([]Guest, error) {
+	var guests []Guest
+	err := query.Select(Guest{}).Where(query.Eq("household_id", h.ID)).OrderBy(query.Asc("id")).OutMany(conn, &guests, txs...)
+	return guests, err
+}
+
+// HouseholdsWithoutRSVP returns the households none of whose guests have responded.
+func HouseholdsWithoutRSVP(conn *db.Connection, txs ...*sql.Tx) ([]Household, error) {
+	statement := "SELECT " + db.Columns(Household{}).ColumnNamesCSV() + " FROM household" +
+		" WHERE deleted_utc IS NULL AND NOT EXISTS (SELECT 1 FROM rsvp WHERE rsvp.household_id = household.id)" +
+		" ORDER BY id"
+	var households []Household
+	err := conn.Invoke(txs...).WithLabel("households_without_rsvp").Query(statement).OutMany(&households)
+	return households, err
+}
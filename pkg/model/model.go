@@ -0,0 +1,24 @@
+// Package model has the guest list types.
+package model
+
+import (
+	"github.com/blend/go-sdk/db"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/audit"
+	"github.com/wcharczuk/katwillmarry.com/pkg/pii"
+)
+
+// Types returns an instance of each model type.
+func Types() []db.DatabaseMapped {
+	return []db.DatabaseMapped{
+		Household{},
+		Guest{},
+		RSVP{},
+		Event{},
+	}
+}
+
+func init() {
+	audit.Register(Types()...)
+	pii.Register(Types()...)
+}
@@ -0,0 +1,366 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/schema/schematest"
+)
+
+func TestCRUD(t *testing.T) {
+	conn := schematest.Open(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	household := model.Household{Name: "The Smiths", CreatedUTC: now, UpdatedUTC: now}
+	if err := conn.Invoke().Create(&household); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if household.ID == 0 {
+		t.Fatal("create should set the serial id")
+	}
+	guests := []model.Guest{
+		{HouseholdID: household.ID, Name: "Alice", Email: "alice@example.com", CreatedUTC: now, UpdatedUTC: now},
+		{HouseholdID: household.ID, Name: "Bob", CreatedUTC: now, UpdatedUTC: now},
+	}
+	for index := range guests {
+		if err := conn.Invoke().Create(&guests[index]); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+
+	var read model.Household
+	if err := conn.Invoke().Get(&read, household.ID); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if read.Name != household.Name || !read.CreatedUTC.Equal(now) {
+		t.Fatalf("get returned %+v", read)
+	}
+
+	read.Name = "The Smith-Joneses"
+	if err := conn.Invoke().Update(&read); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	var updated model.Household
+	if err := conn.Invoke().Get(&updated, household.ID); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if updated.Name != read.Name {
+		t.Fatalf("update wasn't saved: %+v", updated)
+	}
+
+	rsvp := model.RSVP{GuestID: guests[0].ID, HouseholdID: household.ID, Attending: true, RespondedUTC: now}
+	if err := conn.Invoke().Upsert(&rsvp); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	rsvp.Attending = false
+	if err := conn.Invoke().Upsert(&rsvp); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	var rsvps []model.RSVP
+	if err := conn.Invoke().GetAll(&rsvps); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(rsvps) != 1 || rsvps[0].Attending {
+		t.Fatalf("upsert should update the existing row, got %+v", rsvps)
+	}
+
+	var all []model.Guest
+	if err := conn.Invoke().GetAll(&all); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(all) != len(guests) {
+		t.Fatalf("get all returned %d guests, want %d", len(all), len(guests))
+	}
+
+	rsvp = model.RSVP{GuestID: guests[0].ID}
+	if err := conn.Invoke().Delete(&rsvp); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	exists, err := conn.Invoke().Exists(&rsvp)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if exists {
+		t.Fatal("delete should remove the row")
+	}
+}
+
+func TestSoftDelete(t *testing.T) {
+	conn := schematest.Open(t)
+	now := time.Now().UTC()
+
+	household := model.Household{Name: "The Smiths", CreatedUTC: now, UpdatedUTC: now}
+	if err := conn.Invoke().Create(&household); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	guests := []model.Guest{
+		{HouseholdID: household.ID, Name: "Alice", CreatedUTC: now, UpdatedUTC: now},
+		{HouseholdID: household.ID, Name: "Bob", CreatedUTC: now, UpdatedUTC: now},
+	}
+	for index := range guests {
+		if err := conn.Invoke().Create(&guests[index]); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+
+	if err := conn.Invoke().Delete(&guests[1]); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if guests[1].DeletedUTC == nil || guests[1].Version != 1 {
+		t.Fatalf("delete should mark the object deleted and bump its version, got %+v", guests[1])
+	}
+
+	var deleted model.Guest
+	if err := conn.Invoke().Get(&deleted, guests[1].ID); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if deleted.ID != 0 {
+		t.Fatalf("get should skip deleted rows, got %+v", deleted)
+	}
+	var all []model.Guest
+	if err := conn.Invoke().GetAll(&all); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(all) != 1 || all[0].Name != "Alice" {
+		t.Fatalf("get all should skip deleted rows, got %+v", all)
+	}
+	if exists, err := conn.Invoke().Exists(&guests[1]); err != nil || exists {
+		t.Fatalf("exists should skip deleted rows, got %v %v", exists, err)
+	}
+	if exists, err := conn.Invoke().WithDeleted().Exists(&guests[1]); err != nil || !exists {
+		t.Fatalf("exists with deleted should find the deleted row, got %v %v", exists, err)
+	}
+	if err := conn.Invoke().WithDeleted().Get(&deleted, guests[1].ID); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if deleted.DeletedUTC == nil {
+		t.Fatalf("get with deleted should find the deleted row, got %+v", deleted)
+	}
+
+	if err := conn.Invoke().Restore(&deleted); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	var restored model.Guest
+	if err := conn.Invoke().Get(&restored, guests[1].ID); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if restored.ID != guests[1].ID || restored.DeletedUTC != nil || restored.Version != 2 {
+		t.Fatalf("restore should clear the deleted mark, got %+v", restored)
+	}
+}
+
+// keyword is mapped to a table and columns named after sql keywords, which only work quoted.
+type keyword struct {
+	Order int64  `db:"order,pk"`
+	Group string `db:"group"`
+}
+
+func (k keyword) TableName() string {
+	return "select"
+}
+
+func TestInvocationQuotesIdentifiers(t *testing.T) {
+	conn := schematest.Open(t)
+	if err := conn.Invoke().Exec(`CREATE TABLE "select" ("order" integer not null primary key, "group" text not null)`); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	object := keyword{Order: 1, Group: "a"}
+	if err := conn.Invoke().Create(&object); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	object.Group = "b"
+	if err := conn.Invoke().Update(&object); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := conn.Invoke().Upsert(&keyword{Order: 2, Group: "c"}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	var read keyword
+	if err := conn.Invoke().Get(&read, 1); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if read.Group != "b" {
+		t.Fatalf("get returned %+v", read)
+	}
+	var all []keyword
+	if err := conn.Invoke().GetAll(&all); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("get all returned %d rows, want 2", len(all))
+	}
+	if err := conn.Invoke().Delete(&read); err != nil {
+		t.Fatalf("%+v", err)
+	}
+}
+
+func TestHouseholdsWithoutRSVP(t *testing.T) {
+	conn := schematest.Open(t)
+	now := time.Now().UTC()
+
+	var households []model.Household
+	for _, name := range []string{"Responded", "Waiting", "Deleted"} {
+		household := model.Household{Name: name, CreatedUTC: now, UpdatedUTC: now}
+		if name == "Deleted" {
+			household.DeletedUTC = &now
+		}
+		if err := conn.Invoke().Create(&household); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		households = append(households, household)
+	}
+	guest := model.Guest{HouseholdID: households[0].ID, Name: "Alice", CreatedUTC: now, UpdatedUTC: now}
+	if err := conn.Invoke().Create(&guest); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := conn.Invoke().Create(&model.RSVP{GuestID: guest.ID, HouseholdID: guest.HouseholdID, RespondedUTC: now}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	waiting, err := model.HouseholdsWithoutRSVP(conn)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(waiting) != 1 || waiting[0].ID != households[1].ID {
+		t.Fatalf("households without rsvp: %+v", waiting)
+	}
+}
+
+// untaggedVersion has an integer column named version that isn't tagged as the version column.
+type untaggedVersion struct {
+	ID      int64 `db:"id,pk"`
+	Version int64 `db:"version"`
+}
+
+func (u untaggedVersion) TableName() string {
+	return "untagged_version"
+}
+
+func TestInvalidColumnTags(t *testing.T) {
+	testCases := []struct {
+		name   string
+		object db.DatabaseMapped
+	}{
+		{name: "encrypted primary key", object: encryptedPK{}},
+		{name: "encrypted integer", object: encryptedInteger{}},
+		{name: "string version", object: stringVersion{}},
+		{name: "time softdelete", object: timeSoftDelete{}},
+	}
+	for _, tc := range testCases {
+		func() {
+			defer func() {
+				if r, _ := recover().(error); !exception.Is(r, db.ErrInvalidColumnTag) {
+					t.Errorf("%s: want a panic with %v, got %v", tc.name, db.ErrInvalidColumnTag, r)
+				}
+			}()
+			db.Columns(tc.object)
+		}()
+	}
+}
+
+// encryptedPK, encryptedInteger, stringVersion and timeSoftDelete each tag a field with an option it can't have.
+type encryptedPK struct {
+	ID string `db:"id,pk,encrypted"`
+}
+
+func (e encryptedPK) TableName() string {
+	return "encrypted_pk"
+}
+
+type encryptedInteger struct {
+	ID    int64 `db:"id,pk"`
+	Count int64 `db:"count,encrypted"`
+}
+
+func (e encryptedInteger) TableName() string {
+	return "encrypted_integer"
+}
+
+type stringVersion struct {
+	ID      int64  `db:"id,pk"`
+	Version string `db:"version,version"`
+}
+
+func (s stringVersion) TableName() string {
+	return "string_version"
+}
+
+type timeSoftDelete struct {
+	ID         int64     `db:"id,pk"`
+	DeletedUTC time.Time `db:"deleted_utc,softdelete"`
+}
+
+func (s timeSoftDelete) TableName() string {
+	return "time_soft_delete"
+}
+
+func TestVersionConflict(t *testing.T) {
+	if db.Columns(untaggedVersion{}).Version() != nil {
+		t.Fatal("a column named version shouldn't be a version column unless it's tagged")
+	}
+
+	conn := schematest.Open(t)
+	now := time.Now().UTC()
+	household := model.Household{Name: "The Smiths", CreatedUTC: now, UpdatedUTC: now}
+	if err := conn.Invoke().Create(&household); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	stale := household
+	household.Name = "The Smith-Joneses"
+	if err := conn.Invoke().Update(&household); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if household.Version != 1 {
+		t.Fatalf("update should bump the version, got %d", household.Version)
+	}
+	stale.Name = "The Joneses"
+	if err := conn.Invoke().Update(&stale); !db.IsVersionConflict(err) {
+		t.Fatalf("want a version conflict updating a stale row, got %v", err)
+	}
+}
+
+func TestUpsertVersionConflict(t *testing.T) {
+	conn := schematest.Open(t)
+	now := time.Now().UTC()
+	household := model.Household{Name: "The Smiths", CreatedUTC: now, UpdatedUTC: now}
+	if err := conn.Invoke().Create(&household); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	guest := model.Guest{HouseholdID: household.ID, Name: "Alice", CreatedUTC: now, UpdatedUTC: now}
+	if err := conn.Invoke().Create(&guest); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	rsvp := model.RSVP{GuestID: guest.ID, HouseholdID: household.ID, Attending: true, RespondedUTC: now}
+	if err := conn.Invoke().Upsert(&rsvp); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if rsvp.Version != 0 {
+		t.Fatalf("an upsert that inserts should keep the version, got %d", rsvp.Version)
+	}
+	stale := rsvp
+	rsvp.Attending = false
+	if err := conn.Invoke().Upsert(&rsvp); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if rsvp.Version != 1 {
+		t.Fatalf("an upsert that updates should bump the version, got %d", rsvp.Version)
+	}
+
+	stale.Message = "Can't wait"
+	if err := conn.Invoke().Upsert(&stale); !db.IsVersionConflict(err) {
+		t.Fatalf("want a version conflict upserting a stale row, got %v", err)
+	}
+	var stored model.RSVP
+	if err := conn.Invoke().Get(&stored, guest.ID); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if stored.Attending || len(stored.Message) > 0 || stored.Version != 1 {
+		t.Fatalf("a conflicting upsert shouldn't change the row, got %+v", stored)
+	}
+}
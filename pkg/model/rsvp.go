@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+)
+
+// RSVP is a guest's answer to the invitation.
+// A household has responded once any of its guests has an rsvp.
+type RSVP struct {
+	GuestID      int64     `db:"guest_id,pk" json:"guestID"`
+	HouseholdID  int64     `db:"household_id" json:"householdID"`
+	Attending    bool      `db:"attending" json:"attending"`
+	Message      string    `db:"message" json:"message,omitempty"`
+	RespondedUTC time.Time `db:"responded_utc" json:"respondedUTC"`
+	Version      int64     `db:"version,version" json:"version"`
+}
+
+// TableName returns the mapped table name.
+func (r RSVP) TableName() string {
+	return "rsvp"
+}
+
+// AuditScope implements `audit.Scoper`.
+func (r RSVP) AuditScope() string {
+	return HouseholdScope(r.HouseholdID)
+}
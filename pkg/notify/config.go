@@ -0,0 +1,36 @@
+package notify
+
+import (
+	"github.com/blend/go-sdk/util"
+)
+
+const (
+	// DefaultTwilioBaseURL is the default twilio api base url.
+	DefaultTwilioBaseURL = "https://api.twilio.com"
+)
+
+// Config is the notification channel config.
+type Config struct {
+	Twilio TwilioConfig `json:"twilio,omitempty" yaml:"twilio,omitempty"`
+}
+
+// TwilioConfig is the twilio text message provider config.
+type TwilioConfig struct {
+	AccountSID string `json:"accountSID,omitempty" yaml:"accountSID,omitempty" env:"TWILIO_ACCOUNT_SID"`
+	// AuthToken authenticates api calls and signs webhook requests.
+	AuthToken string `json:"authToken,omitempty" yaml:"authToken,omitempty" env:"TWILIO_AUTH_TOKEN"`
+	// From is the number text messages are sent from, in E.164 form.
+	From string `json:"from,omitempty" yaml:"from,omitempty" env:"TWILIO_FROM"`
+	// BaseURL is the api base url; it's only changed for testing.
+	BaseURL string `json:"baseURL,omitempty" yaml:"baseURL,omitempty" env:"TWILIO_BASE_URL"`
+}
+
+// IsZero returns if twilio isn't configured, in which case text messages are off.
+func (tc TwilioConfig) IsZero() bool {
+	return len(tc.AccountSID) == 0 || len(tc.AuthToken) == 0
+}
+
+// GetBaseURL returns a property or a default.
+func (tc TwilioConfig) GetBaseURL(inherited ...string) string {
+	return util.Coalesce.String(tc.BaseURL, DefaultTwilioBaseURL, inherited...)
+}
@@ -0,0 +1,137 @@
+// Package notify sends messages to guests.
+package notify
+
+import (
+	"context"
+	"strings"
+
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/logger"
+)
+
+const (
+	// ChannelEmail is the email channel name.
+	ChannelEmail = "email"
+	// ChannelSMS is the text message channel name.
+	ChannelSMS = "sms"
+
+	// ErrNoChannel is returned when a recipient doesn't want messages on any configured channel.
+	ErrNoChannel exception.Class = "notify: no channel for recipient"
+)
+
+// Recipient is who a message goes to.
+type Recipient struct {
+	Name  string
+	Email string
+	Phone string
+	// Channels are the names of the channels the recipient wants messages on, ex. `email`.
+	Channels []string
+}
+
+// Message is a notification.
+type Message struct {
+	Subject string
+	Body    string
+}
+
+// Channel delivers messages, ex. by email.
+type Channel interface {
+	// Name is the channel name, ex. `email`.
+	Name() string
+	// Send sends a message to a recipient.
+	Send(ctx context.Context, to Recipient, message Message) error
+}
+
+// NewRouter returns a new router.
+func NewRouter() *Router {
+	return &Router{channels: map[string]Channel{}}
+}
+
+// Router is a channel that sends a message on each of the channels the recipient wants.
+type Router struct {
+	channels map[string]Channel
+}
+
+// WithChannel sets the channel used for a channel name, ex. a log channel for `email` in development.
+func (r *Router) WithChannel(name string, channel Channel) *Router {
+	r.channels[name] = channel
+	return r
+}
+
+// Name implements Channel.
+func (r *Router) Name() string {
+	return "router"
+}
+
+// Send implements Channel.
+// Channel names the router doesn't have are skipped; if none of the recipient's channels are set
+// the message isn't sent and `ErrNoChannel` is returned.
+func (r *Router) Send(ctx context.Context, to Recipient, message Message) error {
+	var sent bool
+	var errs []error
+	for _, name := range to.Channels {
+		channel, ok := r.channels[name]
+		if !ok {
+			continue
+		}
+		sent = true
+		if err := channel.Send(ctx, to, message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if !sent {
+		return exception.New(ErrNoChannel).WithMessagef("recipient: %s, channels: %v", to.Name, to.Channels)
+	}
+	return exception.Nest(errs...)
+}
+
+// NewLog returns a channel that writes messages to the log.
+func NewLog(log *logger.Logger) *Log {
+	return &Log{log: log}
+}
+
+// Log is a channel that writes messages to the log instead of sending them,
+// for development and until a real channel is configured.
+type Log struct {
+	log *logger.Logger
+}
+
+// Name implements Channel.
+func (l *Log) Name() string {
+	return "log"
+}
+
+// Send implements Channel.
+// The recipient's email and phone number are redacted, so the log doesn't collect guests' contact details.
+func (l *Log) Send(ctx context.Context, to Recipient, message Message) error {
+	if l.log != nil {
+		l.log.Infof("notify: to %s <%s> %s: %s", to.Name, RedactEmail(to.Email), RedactPhone(to.Phone), message.Subject)
+	}
+	return nil
+}
+
+// RedactEmail returns an email address with all but the first letter of the mailbox hidden,
+// ex. `a***@example.com`, for logs.
+func RedactEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 1 {
+		return redacted(email)
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+// RedactPhone returns a phone number with all but the last two digits hidden, ex. `***00`, for logs.
+func RedactPhone(phone string) string {
+	phone = NormalizePhone(phone)
+	if len(phone) < 6 {
+		return redacted(phone)
+	}
+	return "***" + phone[len(phone)-2:]
+}
+
+func redacted(value string) string {
+	if len(value) == 0 {
+		return ""
+	}
+	return "***"
+}
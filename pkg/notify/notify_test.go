@@ -0,0 +1,29 @@
+package notify_test
+
+import (
+	"testing"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/notify"
+)
+
+func TestRedact(t *testing.T) {
+	for value, expected := range map[string]string{
+		"alice@example.com": "a***@example.com",
+		"not-an-email":      "***",
+		"":                  "",
+	} {
+		if actual := notify.RedactEmail(value); actual != expected {
+			t.Fatalf("RedactEmail(%q) = %q, expected %q", value, actual, expected)
+		}
+	}
+	for value, expected := range map[string]string{
+		"(555) 555-0100":   "***00",
+		"+44 20 7946 0958": "***58",
+		"123":              "***",
+		"":                 "",
+	} {
+		if actual := notify.RedactPhone(value); actual != expected {
+			t.Fatalf("RedactPhone(%q) = %q, expected %q", value, actual, expected)
+		}
+	}
+}
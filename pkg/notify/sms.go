@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/blend/go-sdk/exception"
+)
+
+const (
+	// ErrNoPhone is returned when sending a text message to a recipient without a phone number.
+	ErrNoPhone exception.Class = "notify: recipient has no phone number"
+)
+
+// SMSProvider sends text messages, ex. Twilio.
+type SMSProvider interface {
+	// SendSMS sends a text message to a phone number (see `NormalizePhone`).
+	SendSMS(ctx context.Context, to, body string) error
+}
+
+// InboundSMS is a text message sent to the site's number.
+type InboundSMS struct {
+	From string
+	Body string
+}
+
+// SMSReceiver reads text messages sent to the site's number from a provider's webhook request.
+// It must check that the request came from the provider.
+type SMSReceiver interface {
+	ReceiveSMS(req *http.Request) (*InboundSMS, error)
+}
+
+// NewSMS returns a text message channel that sends with a provider.
+func NewSMS(provider SMSProvider) *SMS {
+	return &SMS{provider: provider}
+}
+
+// SMS is a channel that sends text messages.
+type SMS struct {
+	provider SMSProvider
+}
+
+// Name implements Channel.
+func (s *SMS) Name() string {
+	return ChannelSMS
+}
+
+// Send implements Channel.
+// Text messages don't have a subject, so only the body is sent.
+func (s *SMS) Send(ctx context.Context, to Recipient, message Message) error {
+	phone := NormalizePhone(to.Phone)
+	if len(phone) == 0 {
+		return exception.New(ErrNoPhone).WithMessagef("recipient: %s", to.Name)
+	}
+	return s.provider.SendSMS(ctx, phone, message.Body)
+}
+
+// NormalizePhone returns a phone number in E.164 form (ex. `+15555550100`), or an empty string
+// if there aren't any digits. Numbers without a country code are assumed to be US numbers.
+func NormalizePhone(phone string) string {
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	number := digits.String()
+	if len(number) == 0 {
+		return ""
+	}
+	if len(number) == 10 && !strings.HasPrefix(strings.TrimSpace(phone), "+") {
+		return "+1" + number
+	}
+	return "+" + number
+}
+
+// optOutKeywords and optInKeywords are the carrier standard keywords for stopping and
+// restarting text messages; providers honor them too, so we stop sending before the provider refuses.
+var (
+	optOutKeywords = []string{"STOP", "STOPALL", "UNSUBSCRIBE", "CANCEL", "END", "QUIT"}
+	optInKeywords  = []string{"START", "UNSTOP", "YES"}
+)
+
+// IsOptOut returns if an inbound message asks to stop text messages.
+func IsOptOut(body string) bool {
+	return hasKeyword(optOutKeywords, body)
+}
+
+// IsOptIn returns if an inbound message asks to restart text messages.
+func IsOptIn(body string) bool {
+	return hasKeyword(optInKeywords, body)
+}
+
+func hasKeyword(keywords []string, body string) bool {
+	body = strings.ToUpper(strings.TrimSpace(body))
+	for _, keyword := range keywords {
+		if body == keyword {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/request"
+)
+
+const (
+	// ErrTwilio is returned when the twilio api rejects a request.
+	ErrTwilio exception.Class = "notify: twilio api error"
+	// ErrInvalidSignature is returned for a webhook request that wasn't signed by twilio.
+	ErrInvalidSignature exception.Class = "notify: invalid webhook signature"
+
+	// HeaderTwilioSignature is the header twilio signs webhook requests with.
+	HeaderTwilioSignature = "X-Twilio-Signature"
+)
+
+// NewTwilio returns a new twilio provider.
+func NewTwilio(accountSID, authToken, from string) *Twilio {
+	return &Twilio{
+		accountSID:   accountSID,
+		authToken:    authToken,
+		from:         from,
+		baseURL:      DefaultTwilioBaseURL,
+		mockProvider: request.MockedResponseInjector,
+	}
+}
+
+// NewTwilioFromConfig returns a new twilio provider from a config.
+func NewTwilioFromConfig(cfg *TwilioConfig) *Twilio {
+	return NewTwilio(cfg.AccountSID, cfg.AuthToken, cfg.From).WithBaseURL(cfg.GetBaseURL())
+}
+
+// Twilio sends text messages with the twilio api and reads them from twilio's incoming message webhook.
+//
+// Api requests go through `request.MockedResponseInjector`, so `request.MockResponseFromString` et al.
+// stand in for the live api in tests.
+type Twilio struct {
+	accountSID   string
+	authToken    string
+	from         string
+	baseURL      string
+	webhookURL   string
+	mockProvider request.MockedResponseProvider
+}
+
+// WithBaseURL sets the api base url.
+func (t *Twilio) WithBaseURL(baseURL string) *Twilio {
+	t.baseURL = strings.TrimSuffix(baseURL, "/")
+	return t
+}
+
+// WithWebhookURL sets the public url of the incoming message webhook, which twilio signs requests with.
+// If it's unset the url is taken from the request, which is only right if proxies pass the host through.
+func (t *Twilio) WithWebhookURL(webhookURL string) *Twilio {
+	t.webhookURL = webhookURL
+	return t
+}
+
+// WithMockProvider sets the mock provider api requests go through.
+func (t *Twilio) WithMockProvider(provider request.MockedResponseProvider) *Twilio {
+	t.mockProvider = provider
+	return t
+}
+
+// MessagesURL returns the url text messages are created at.
+func (t *Twilio) MessagesURL() string {
+	return t.baseURL + "/2010-04-01/Accounts/" + url.PathEscape(t.accountSID) + "/Messages.json"
+}
+
+// twilioError is the body of a twilio api error response.
+type twilioError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// SendSMS implements SMSProvider.
+func (t *Twilio) SendSMS(ctx context.Context, to, body string) error {
+	req, err := request.New().AsPost().WithRawURL(t.MessagesURL())
+	if err != nil {
+		return err
+	}
+	contents, meta, err := req.
+		WithContext(ctx).
+		WithMockProvider(t.mockProvider).
+		WithBasicAuth(t.accountSID, t.authToken).
+		WithPostData("To", to).
+		WithPostData("From", t.from).
+		WithPostData("Body", body).
+		BytesWithMeta()
+	if err != nil {
+		return err
+	}
+	if meta.StatusCode >= http.StatusMultipleChoices {
+		var apiErr twilioError
+		if json.Unmarshal(contents, &apiErr) == nil && apiErr.Code != 0 {
+			return exception.New(ErrTwilio).WithMessagef("status: %d, code: %d, %s", meta.StatusCode, apiErr.Code, apiErr.Message)
+		}
+		return exception.New(ErrTwilio).WithMessagef("status: %d", meta.StatusCode)
+	}
+	return nil
+}
+
+// ReceiveSMS implements SMSReceiver.
+func (t *Twilio) ReceiveSMS(req *http.Request) (*InboundSMS, error) {
+	if err := req.ParseForm(); err != nil {
+		return nil, exception.New(err)
+	}
+	expected := t.Signature(t.requestURL(req), req.PostForm)
+	if !hmac.Equal([]byte(expected), []byte(req.Header.Get(HeaderTwilioSignature))) {
+		return nil, exception.New(ErrInvalidSignature)
+	}
+	return &InboundSMS{
+		From: NormalizePhone(req.PostForm.Get("From")),
+		Body: req.PostForm.Get("Body"),
+	}, nil
+}
+
+// Signature returns twilio's signature for a webhook request: the base64 hmac-sha1, keyed by the
+// auth token, of the url followed by each posted field name and value, sorted by name.
+func (t *Twilio) Signature(requestURL string, form url.Values) string {
+	names := make([]string, 0, len(form))
+	for name := range form {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	mac := hmac.New(sha1.New, []byte(t.authToken))
+	mac.Write([]byte(requestURL))
+	for _, name := range names {
+		for _, value := range form[name] {
+			mac.Write([]byte(name + value))
+		}
+	}
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (t *Twilio) requestURL(req *http.Request) string {
+	if len(t.webhookURL) > 0 {
+		return t.webhookURL
+	}
+	scheme := "http"
+	if req.TLS != nil || req.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + req.Host + req.URL.RequestURI()
+}
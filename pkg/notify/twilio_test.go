@@ -0,0 +1,97 @@
+package notify_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/request"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/notify"
+)
+
+func TestTwilioSend(t *testing.T) {
+	defer request.ClearMockedResponses()
+	twilio := notify.NewTwilio("AC123", "secret", "+15555550199").WithBaseURL("https://api.twilio.test")
+
+	var sent *http.Request
+	request.MockResponse(request.New().AsPost().MustWithRawURL(twilio.MessagesURL()), func(req *request.Request) request.MockedResponse {
+		var err error
+		if sent, err = req.Request(); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		return request.MockedResponse{Meta: request.ResponseMeta{StatusCode: http.StatusCreated}, Res: []byte(`{"sid":"SM1"}`)}
+	})
+	if err := notify.NewSMS(twilio).Send(context.Background(), notify.Recipient{Name: "Alice", Phone: "(555) 555-0100"}, notify.Message{Subject: "Please RSVP", Body: "Hi!"}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if sent == nil {
+		t.Fatal("the message should be sent to the twilio api")
+	}
+	if user, password, ok := sent.BasicAuth(); !ok || user != "AC123" || password != "secret" {
+		t.Fatalf("requests should authenticate with the account sid and auth token, got %q %q", user, password)
+	}
+	if err := sent.ParseForm(); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if to, from, body := sent.PostForm.Get("To"), sent.PostForm.Get("From"), sent.PostForm.Get("Body"); to != "+15555550100" || from != "+15555550199" || body != "Hi!" {
+		t.Fatalf("unexpected message, to: %q from: %q body: %q", to, from, body)
+	}
+
+	request.MockResponseFromString("POST", twilio.MessagesURL(), http.StatusBadRequest, `{"code":21211,"message":"The 'To' number is not a valid phone number."}`)
+	err := twilio.SendSMS(context.Background(), "+15555550100", "Hi!")
+	if !exception.Is(err, notify.ErrTwilio) {
+		t.Fatalf("api errors should return ErrTwilio, got %v", err)
+	}
+	if !strings.Contains(fmt.Sprintf("%v", err), "21211") {
+		t.Fatalf("api errors should include twilio's error code, got %v", err)
+	}
+}
+
+func TestTwilioReceiveSMS(t *testing.T) {
+	const webhookURL = "https://katwillmarry.test/webhooks/sms"
+	twilio := notify.NewTwilio("AC123", "secret", "+15555550199").WithWebhookURL(webhookURL)
+
+	newRequest := func(body, signature string) *http.Request {
+		form := url.Values{"From": {"+1 (555) 555-0100"}, "Body": {body}}
+		req := httptest.NewRequest("POST", "/webhooks/sms", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if signature == "" {
+			signature = twilio.Signature(webhookURL, form)
+		}
+		req.Header.Set(notify.HeaderTwilioSignature, signature)
+		return req
+	}
+
+	for body, optOut := range map[string]bool{"STOP": true, " stop ": true, "START": false, "unstop": false} {
+		inbound, err := twilio.ReceiveSMS(newRequest(body, ""))
+		if err != nil {
+			t.Fatalf("%q: %+v", body, err)
+		}
+		if inbound.From != "+15555550100" || inbound.Body != body {
+			t.Fatalf("%q: unexpected message %+v", body, inbound)
+		}
+		if notify.IsOptOut(inbound.Body) != optOut || notify.IsOptIn(inbound.Body) == optOut {
+			t.Fatalf("%q: opt out should be %t", body, optOut)
+		}
+	}
+
+	forged := notify.NewTwilio("AC123", "not-the-secret", "").Signature(webhookURL, url.Values{"From": {"+1 (555) 555-0100"}, "Body": {"STOP"}})
+	if _, err := twilio.ReceiveSMS(newRequest("STOP", forged)); !exception.Is(err, notify.ErrInvalidSignature) {
+		t.Fatalf("a request signed with another token should be rejected, got %v", err)
+	}
+	tampered := newRequest("START", twilio.Signature(webhookURL, url.Values{"From": {"+1 (555) 555-0100"}, "Body": {"STOP"}}))
+	if _, err := twilio.ReceiveSMS(tampered); !exception.Is(err, notify.ErrInvalidSignature) {
+		t.Fatalf("a request with a changed body should be rejected, got %v", err)
+	}
+	unsigned := newRequest("STOP", "")
+	unsigned.Header.Del(notify.HeaderTwilioSignature)
+	if _, err := twilio.ReceiveSMS(unsigned); !exception.Is(err, notify.ErrInvalidSignature) {
+		t.Fatalf("an unsigned request should be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,235 @@
+// Package openapi generates OpenAPI 3 documents from the routes registered with a `web.App`.
+//
+// Only routes with metadata, set with `App.Describe`, are documented.
+package openapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/blend/go-sdk/web"
+)
+
+const (
+	// Version is the OpenAPI version documents are written in.
+	Version = "3.0.3"
+
+	// SecuritySchemeToken is the name of the bearer token security scheme.
+	SecuritySchemeToken = "token"
+)
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Servers    []Server                         `json:"servers,omitempty"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+// Info is the document info.
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Server is a base url the api is served from.
+type Server struct {
+	URL string `json:"url"`
+}
+
+// Components are the shared schemas and security schemes.
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme is how a route is authenticated.
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+}
+
+// Operation is a method on a path.
+type Operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *Body                 `json:"requestBody,omitempty"`
+	Responses   map[string]*Body      `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter is a path or query parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// Body is a request or response body.
+type Body struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema of a body in a content type.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// New returns a new generator.
+func New(title, version string) *Generator {
+	return &Generator{title: title, version: version}
+}
+
+// Generator builds a document from routes.
+type Generator struct {
+	title     string
+	version   string
+	servers   []string
+	errorType interface{}
+}
+
+// WithServer adds a base url the routes are served from.
+func (g *Generator) WithServer(url string) *Generator {
+	g.servers = append(g.servers, url)
+	return g
+}
+
+// WithErrorType sets the body type of error responses.
+func (g *Generator) WithErrorType(errorType interface{}) *Generator {
+	g.errorType = errorType
+	return g
+}
+
+// Generate returns the document for the described routes of an app.
+func (g *Generator) Generate(app *web.App) *Document {
+	return g.Document(app.Routes()...)
+}
+
+// Document returns the document for a set of routes; routes without metadata are skipped.
+func (g *Generator) Document(routes ...*web.Route) *Document {
+	schemas := newSchemas()
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: g.title, Version: g.version},
+		Paths:   map[string]map[string]*Operation{},
+	}
+	for _, server := range g.servers {
+		doc.Servers = append(doc.Servers, Server{URL: server})
+	}
+
+	var secured bool
+	for _, route := range routes {
+		meta := route.Meta
+		if meta == nil {
+			continue
+		}
+		path, params := pathTemplate(route.Path)
+		op := &Operation{
+			Summary:     meta.Summary,
+			Description: meta.Description,
+			OperationID: operationID(route.Method, route.Path),
+			Tags:        meta.Tags,
+			Parameters:  params,
+			Responses:   map[string]*Body{},
+		}
+		for _, param := range meta.Query {
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name:        param.Name,
+				In:          "query",
+				Description: param.Description,
+				Schema:      &Schema{Type: typeOrString(param.Type)},
+			})
+		}
+		if meta.Request != nil {
+			op.RequestBody = &Body{Required: true, Content: jsonContent(schemas.For(meta.Request))}
+		}
+
+		status := meta.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := &Body{Description: http.StatusText(status)}
+		if meta.Response != nil {
+			success.Content = jsonContent(schemas.For(meta.Response))
+		}
+		op.Responses[strconv.Itoa(status)] = success
+		if g.errorType != nil {
+			op.Responses["default"] = &Body{Description: "Error", Content: jsonContent(schemas.For(g.errorType))}
+		}
+
+		if meta.Secured {
+			secured = true
+			op.Security = []map[string][]string{{SecuritySchemeToken: {}}}
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*Operation{}
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+	}
+
+	doc.Components.Schemas = schemas.named
+	if secured {
+		doc.Components.SecuritySchemes = map[string]*SecurityScheme{
+			SecuritySchemeToken: {Type: "http", Scheme: "bearer"},
+		}
+	}
+	return doc
+}
+
+// pathTemplate converts a route path to an OpenAPI path template, ex. `/guests/:id` to `/guests/{id}`,
+// returning the path parameters.
+func pathTemplate(path string) (string, []*Parameter) {
+	var params []*Parameter
+	segments := strings.Split(path, "/")
+	for index, segment := range segments {
+		if !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			continue
+		}
+		name := segment[1:]
+		segments[index] = "{" + name + "}"
+		params = append(params, &Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: paramType(name)}})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// paramType guesses the type of a path parameter from its name; ids are integers.
+func paramType(name string) string {
+	lower := strings.ToLower(name)
+	if lower == "id" || strings.HasSuffix(lower, "_id") || strings.HasSuffix(name, "ID") {
+		return "integer"
+	}
+	return "string"
+}
+
+// operationID returns a stable id for a route, ex. `get_guests_id`.
+func operationID(method, path string) string {
+	var parts []string
+	for _, segment := range strings.Split(path, "/") {
+		segment = strings.TrimLeft(segment, ":*")
+		if len(segment) > 0 {
+			parts = append(parts, strings.Replace(segment, ".", "_", -1))
+		}
+	}
+	return strings.ToLower(method) + "_" + strings.Join(parts, "_")
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
+
+func typeOrString(t string) string {
+	if len(t) == 0 {
+		return "string"
+	}
+	return t
+}
@@ -0,0 +1,73 @@
+package openapi_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/openapi"
+)
+
+type audit struct {
+	CreatedUTC time.Time `json:"createdUTC"`
+}
+
+// node is recursive and embeds a struct, to check both are handled.
+type node struct {
+	audit
+	Name     string            `json:"name"`
+	Note     *string           `json:"note"`
+	Children []node            `json:"children,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Secret   string            `json:"-"`
+}
+
+func TestDocument(t *testing.T) {
+	routes := []*web.Route{
+		{Method: "GET", Path: "/nodes/:node_id/files/*filepath", Meta: &web.RouteMeta{
+			Summary:  "Get a file",
+			Query:    []web.QueryParam{{Name: "raw"}},
+			Response: node{},
+		}},
+		{Method: "POST", Path: "/nodes", Meta: &web.RouteMeta{Secured: true, Request: node{}, Status: 201}},
+		{Method: "GET", Path: "/undocumented"},
+	}
+	doc := openapi.New("Test", "v1").WithServer("https://example.com").Document(routes...)
+
+	if len(doc.Paths) != 2 || doc.Paths["/undocumented"] != nil || len(doc.Servers) != 1 {
+		t.Fatalf("only routes with metadata should be documented, got %v", doc.Paths)
+	}
+	get := doc.Paths["/nodes/{node_id}/files/{filepath}"]["get"]
+	if get == nil || get.OperationID != "get_nodes_node_id_files_filepath" {
+		t.Fatalf("unexpected operation %+v", get)
+	}
+	var params []string
+	for _, param := range get.Parameters {
+		params = append(params, fmt.Sprintf("%s:%s:%s:%v", param.Name, param.In, param.Schema.Type, param.Required))
+	}
+	if fmt.Sprint(params) != "[node_id:path:integer:true filepath:path:string:true raw:query:string:false]" {
+		t.Fatalf("unexpected parameters %v", params)
+	}
+	if len(get.Security) > 0 || get.Responses["200"] == nil || get.Responses["default"] != nil {
+		t.Fatalf("unexpected security or responses %+v %+v", get.Security, get.Responses)
+	}
+	if post := doc.Paths["/nodes"]["post"]; post.Responses["201"] == nil || len(post.Security) != 1 || doc.Components.SecuritySchemes[openapi.SecuritySchemeToken] == nil {
+		t.Fatalf("a secured route should use the token scheme, got %+v", post)
+	}
+
+	schema := doc.Components.Schemas["node"]
+	if schema == nil || fmt.Sprint(schema.Required) != "[createdUTC name]" || schema.Properties["Secret"] != nil {
+		t.Fatalf("unexpected node schema %+v", schema)
+	}
+	if note := schema.Properties["note"]; !note.Nullable || note.Type != "string" {
+		t.Fatalf("pointers should be nullable, got %+v", note)
+	}
+	if children := schema.Properties["children"]; children.Type != "array" || children.Items.Ref != "#/components/schemas/node" {
+		t.Fatalf("recursive types should reference their component, got %+v", children)
+	}
+	if labels := schema.Properties["labels"]; labels.Type != "object" || labels.AdditionalProperties.Type != "string" {
+		t.Fatalf("maps should be objects with additional properties, got %+v", labels)
+	}
+}
@@ -0,0 +1,148 @@
+package passkey
+
+import (
+	"math"
+
+	"github.com/blend/go-sdk/exception"
+)
+
+// The subset of cbor (rfc 7049) webauthn uses: authenticators encode attestation objects and
+// public keys with definite lengths, integer or text map keys and no tags or floats.
+const (
+	cborUnsigned = 0
+	cborNegative = 1
+	cborBytes    = 2
+	cborText     = 3
+	cborArray    = 4
+	cborMap      = 5
+	cborSimple   = 7
+
+	cborFalse = 20
+	cborTrue  = 21
+	cborNull  = 22
+
+	// cborMaxDepth is the deepest nesting decoded, so a hostile document can't exhaust the stack.
+	cborMaxDepth = 16
+)
+
+// decodeCBOR decodes the first cbor item in data, returning it and the bytes after it.
+//
+// Unsigned and negative integers decode to int64, byte strings to []byte, text to string,
+// arrays to []interface{} and maps to map[interface{}]interface{}.
+func decodeCBOR(data []byte) (interface{}, []byte, error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORItem(data []byte, depth int) (interface{}, []byte, error) {
+	if depth > cborMaxDepth {
+		return nil, nil, exception.New(ErrInvalidResponse).WithMessagef("cbor: nested too deeply")
+	}
+	if len(data) == 0 {
+		return nil, nil, exception.New(ErrInvalidResponse).WithMessagef("cbor: unexpected end of data")
+	}
+	major, info := data[0]>>5, data[0]&0x1f
+	if major == cborSimple {
+		switch info {
+		case cborFalse:
+			return false, data[1:], nil
+		case cborTrue:
+			return true, data[1:], nil
+		case cborNull:
+			return nil, data[1:], nil
+		}
+		return nil, nil, exception.New(ErrInvalidResponse).WithMessagef("cbor: unsupported simple value: %d", info)
+	}
+
+	argument, rest, err := decodeCBORArgument(info, data[1:])
+	if err != nil {
+		return nil, nil, err
+	}
+	switch major {
+	case cborUnsigned:
+		if argument > math.MaxInt64 {
+			return nil, nil, exception.New(ErrInvalidResponse).WithMessagef("cbor: integer overflows")
+		}
+		return int64(argument), rest, nil
+	case cborNegative:
+		if argument > math.MaxInt64 {
+			return nil, nil, exception.New(ErrInvalidResponse).WithMessagef("cbor: integer overflows")
+		}
+		return -1 - int64(argument), rest, nil
+	case cborBytes, cborText:
+		if argument > uint64(len(rest)) {
+			return nil, nil, exception.New(ErrInvalidResponse).WithMessagef("cbor: unexpected end of data")
+		}
+		value := rest[:argument]
+		if major == cborText {
+			return string(value), rest[argument:], nil
+		}
+		return append([]byte(nil), value...), rest[argument:], nil
+	case cborArray:
+		// each item is at least a byte, which bounds the allocation by the data's length.
+		if argument > uint64(len(rest)) {
+			return nil, nil, exception.New(ErrInvalidResponse).WithMessagef("cbor: unexpected end of data")
+		}
+		items := make([]interface{}, 0, argument)
+		for index := uint64(0); index < argument; index++ {
+			var item interface{}
+			if item, rest, err = decodeCBORItem(rest, depth+1); err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, rest, nil
+	case cborMap:
+		if argument > uint64(len(rest)) {
+			return nil, nil, exception.New(ErrInvalidResponse).WithMessagef("cbor: unexpected end of data")
+		}
+		values := make(map[interface{}]interface{}, argument)
+		for index := uint64(0); index < argument; index++ {
+			var key, value interface{}
+			if key, rest, err = decodeCBORItem(rest, depth+1); err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, exception.New(ErrInvalidResponse).WithMessagef("cbor: unsupported map key")
+			}
+			if value, rest, err = decodeCBORItem(rest, depth+1); err != nil {
+				return nil, nil, err
+			}
+			if _, ok := values[key]; ok {
+				return nil, nil, exception.New(ErrInvalidResponse).WithMessagef("cbor: duplicate map key")
+			}
+			values[key] = value
+		}
+		return values, rest, nil
+	}
+	return nil, nil, exception.New(ErrInvalidResponse).WithMessagef("cbor: unsupported major type: %d", major)
+}
+
+// decodeCBORArgument reads the argument (the value, length or count) that follows an item's
+// initial byte.
+func decodeCBORArgument(info byte, data []byte) (uint64, []byte, error) {
+	var size int
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, nil, exception.New(ErrInvalidResponse).WithMessagef("cbor: indefinite lengths aren't supported")
+	}
+	if len(data) < size {
+		return 0, nil, exception.New(ErrInvalidResponse).WithMessagef("cbor: unexpected end of data")
+	}
+	var argument uint64
+	for _, b := range data[:size] {
+		argument = argument<<8 | uint64(b)
+	}
+	return argument, data[size:], nil
+}
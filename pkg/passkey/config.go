@@ -0,0 +1,47 @@
+package passkey
+
+import (
+	"time"
+
+	"github.com/blend/go-sdk/util"
+)
+
+const (
+	// DefaultRPName is the default name browsers show when creating a passkey.
+	DefaultRPName = "Kat Will Marry"
+	// DefaultChallengeTTL is the default time to finish creating or using a passkey.
+	DefaultChallengeTTL = 5 * time.Minute
+)
+
+// Config is the passkey config.
+type Config struct {
+	// Origin is the site url passkeys are used on, ex. `https://katwillmarry.com`; it's the base url by default.
+	Origin string `json:"origin,omitempty" yaml:"origin,omitempty" env:"PASSKEY_ORIGIN"`
+	// RPID is the domain passkeys are scoped to; it's the host of the origin by default.
+	// It can be a parent domain of the origin's host, so passkeys work on its subdomains.
+	RPID string `json:"rpID,omitempty" yaml:"rpID,omitempty" env:"PASSKEY_RP_ID"`
+	// RPName is the name browsers show when creating a passkey.
+	RPName string `json:"rpName,omitempty" yaml:"rpName,omitempty" env:"PASSKEY_RP_NAME"`
+	// ChallengeTTL is the time to finish creating or using a passkey once it's started.
+	ChallengeTTL time.Duration `json:"challengeTTL,omitempty" yaml:"challengeTTL,omitempty" env:"PASSKEY_CHALLENGE_TTL"`
+}
+
+// GetOrigin returns a property or a default.
+func (c Config) GetOrigin(inherited ...string) string {
+	return util.Coalesce.String(c.Origin, "", inherited...)
+}
+
+// GetRPID returns a property or a default.
+func (c Config) GetRPID(inherited ...string) string {
+	return util.Coalesce.String(c.RPID, "", inherited...)
+}
+
+// GetRPName returns a property or a default.
+func (c Config) GetRPName(inherited ...string) string {
+	return util.Coalesce.String(c.RPName, DefaultRPName, inherited...)
+}
+
+// GetChallengeTTL returns a property or a default.
+func (c Config) GetChallengeTTL(inherited ...time.Duration) time.Duration {
+	return util.Coalesce.Duration(c.ChallengeTTL, DefaultChallengeTTL, inherited...)
+}
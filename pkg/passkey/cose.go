@@ -0,0 +1,113 @@
+package passkey
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/blend/go-sdk/exception"
+)
+
+// The cose (rfc 8152) algorithms passkeys can use, in order of preference.
+const (
+	AlgES256 = -7
+	AlgEdDSA = -8
+	AlgRS256 = -257
+)
+
+// Algorithms are the algorithms passkeys can use, in order of preference.
+var Algorithms = []int{AlgES256, AlgEdDSA, AlgRS256}
+
+// cose key parameters and values.
+const (
+	coseKeyType   = 1
+	coseAlgorithm = 3
+	coseCurve     = -1
+	coseX         = -2
+	coseY         = -3
+	coseModulus   = -1
+	coseExponent  = -2
+
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+
+	// minRSABits is the smallest rsa key accepted.
+	minRSABits = 2048
+)
+
+// PublicKey is a credential's public key, and the algorithm it signs with.
+type PublicKey struct {
+	Algorithm int
+	Key       crypto.PublicKey
+}
+
+// ParsePublicKey parses a cose encoded public key.
+func ParsePublicKey(data []byte) (*PublicKey, error) {
+	value, rest, err := decodeCBOR(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, exception.New(ErrInvalidResponse).WithMessagef("public key has trailing data")
+	}
+	key, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, exception.New(ErrInvalidResponse).WithMessagef("public key isn't a map")
+	}
+	keyType, _ := key[int64(coseKeyType)].(int64)
+	algorithm, _ := key[int64(coseAlgorithm)].(int64)
+
+	switch {
+	case keyType == coseKeyTypeEC2 && algorithm == AlgES256:
+		curve, _ := key[int64(coseCurve)].(int64)
+		x, _ := key[int64(coseX)].([]byte)
+		y, _ := key[int64(coseY)].([]byte)
+		if curve != coseCurveP256 || len(x) != 32 || len(y) != 32 {
+			return nil, exception.New(ErrUnsupportedKey).WithMessagef("invalid es256 key")
+		}
+		publicKey := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !publicKey.Curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, exception.New(ErrUnsupportedKey).WithMessagef("es256 key isn't on the curve")
+		}
+		return &PublicKey{Algorithm: AlgES256, Key: publicKey}, nil
+	case keyType == coseKeyTypeOKP && algorithm == AlgEdDSA:
+		curve, _ := key[int64(coseCurve)].(int64)
+		x, _ := key[int64(coseX)].([]byte)
+		if curve != coseCurveEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, exception.New(ErrUnsupportedKey).WithMessagef("invalid eddsa key")
+		}
+		return &PublicKey{Algorithm: AlgEdDSA, Key: ed25519.PublicKey(x)}, nil
+	case keyType == coseKeyTypeRSA && algorithm == AlgRS256:
+		n, _ := key[int64(coseModulus)].([]byte)
+		e, _ := key[int64(coseExponent)].([]byte)
+		exponent := new(big.Int).SetBytes(e)
+		if len(n)*8 < minRSABits || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, exception.New(ErrUnsupportedKey).WithMessagef("invalid rs256 key")
+		}
+		return &PublicKey{Algorithm: AlgRS256, Key: &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}}, nil
+	}
+	return nil, exception.New(ErrUnsupportedKey).WithMessagef("key type: %d, algorithm: %d", keyType, algorithm)
+}
+
+// Verify checks a signature of data made with the key.
+func (pk PublicKey) Verify(data, signature []byte) bool {
+	switch key := pk.Key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		return ecdsa.VerifyASN1(key, digest[:], signature)
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, data, signature)
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	}
+	return false
+}
@@ -0,0 +1,416 @@
+// Package passkey logs admins in with webauthn passkeys, so the admin site doesn't depend on
+// the openid connect provider being reachable, ex. at the venue.
+//
+// A logged in admin registers a passkey (a key pair kept by their phone, laptop or security
+// key); later they log in by signing a challenge with it. Challenges are stored when they're
+// issued and marked when they're used, so each works once.
+package passkey
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/util"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/audit"
+	"github.com/wcharczuk/katwillmarry.com/pkg/query"
+)
+
+const (
+	// ErrInvalidResponse is returned for a browser response that's malformed, for another site or
+	// ceremony, or has a bad signature.
+	ErrInvalidResponse exception.Class = "passkey: invalid response"
+	// ErrInvalidChallenge is returned for a challenge that wasn't issued, has expired, was already
+	// used or was issued for another ceremony or user.
+	ErrInvalidChallenge exception.Class = "passkey: invalid challenge"
+	// ErrUnsupportedKey is returned when registering a passkey whose key type or algorithm isn't supported.
+	ErrUnsupportedKey exception.Class = "passkey: unsupported key"
+	// ErrUnknownCredential is returned when logging in with a passkey that isn't registered.
+	ErrUnknownCredential exception.Class = "passkey: unknown credential"
+	// ErrCredentialExists is returned when registering a passkey that's already registered.
+	ErrCredentialExists exception.Class = "passkey: credential already registered"
+	// ErrCredentialNotFound is returned when removing a passkey that doesn't exist or isn't the user's.
+	ErrCredentialNotFound exception.Class = "passkey: not found"
+	// ErrInvalidOrigin is returned for an origin that isn't a url, ex. `https://katwillmarry.com`.
+	ErrInvalidOrigin exception.Class = "passkey: invalid origin"
+	// ErrSignCount is returned when a passkey's signature counter didn't go up, which means the
+	// passkey may have been cloned.
+	ErrSignCount exception.Class = "passkey: signature counter didn't increase"
+
+	// ceremonyRegister and ceremonyLogin are what a challenge was issued for.
+	ceremonyRegister = "register"
+	ceremonyLogin    = "login"
+
+	// challengeSize is the number of random bytes in a challenge.
+	challengeSize = 32
+	// credentialType is the only webauthn credential type.
+	credentialType = "public-key"
+)
+
+// Credential is a registered passkey.
+type Credential struct {
+	ID int64 `db:"id,pk,serial" json:"id"`
+	// CredentialID is the id the authenticator gave the passkey, base64url encoded.
+	CredentialID string `db:"credential_id" json:"-"`
+	UserID       string `db:"user_id" json:"userID"`
+	Name         string `db:"name" json:"name"`
+	// PublicKey is the cose encoded public key, base64url encoded.
+	PublicKey   string     `db:"public_key" json:"-"`
+	SignCount   int64      `db:"sign_count" json:"-"`
+	CreatedUTC  time.Time  `db:"created_utc" json:"createdUTC"`
+	LastUsedUTC *time.Time `db:"last_used_utc" json:"lastUsedUTC,omitempty"`
+}
+
+// TableName returns the mapped table name.
+func (c Credential) TableName() string {
+	return "passkey"
+}
+
+func init() {
+	audit.Register(Credential{})
+}
+
+// Challenge is an issued challenge.
+type Challenge struct {
+	Challenge string `db:"challenge,pk"`
+	Ceremony  string `db:"ceremony"`
+	// UserID is the user a passkey is being registered for; it's empty for logins.
+	UserID     string     `db:"user_id"`
+	ExpiresUTC time.Time  `db:"expires_utc"`
+	UsedUTC    *time.Time `db:"used_utc"`
+}
+
+// TableName returns the mapped table name.
+func (c Challenge) TableName() string {
+	return "passkey_challenge"
+}
+
+// NewManager returns a new manager for passkeys used on an origin, ex. `https://katwillmarry.com`.
+// The relying party id is the origin's host.
+func NewManager(conn *db.Connection, origin string) (*Manager, error) {
+	parsed, err := url.Parse(origin)
+	if err != nil || len(parsed.Scheme) == 0 || len(parsed.Hostname()) == 0 {
+		return nil, exception.New(ErrInvalidOrigin).WithMessagef("origin: %s", origin)
+	}
+	return &Manager{
+		conn:         conn,
+		origin:       parsed.Scheme + "://" + parsed.Host,
+		rpID:         parsed.Hostname(),
+		rpName:       DefaultRPName,
+		challengeTTL: DefaultChallengeTTL,
+	}, nil
+}
+
+// NewManagerFromConfig returns a new manager from a config, for the config's origin or the base url.
+func NewManagerFromConfig(conn *db.Connection, cfg *Config, baseURL string) (*Manager, error) {
+	manager, err := NewManager(conn, cfg.GetOrigin(baseURL))
+	if err != nil {
+		return nil, err
+	}
+	return manager.
+		WithRPID(cfg.GetRPID(manager.RPID())).
+		WithRPName(cfg.GetRPName()).
+		WithChallengeTTL(cfg.GetChallengeTTL()), nil
+}
+
+// Manager registers passkeys and logs in with them.
+type Manager struct {
+	conn         *db.Connection
+	origin       string
+	rpID         string
+	rpName       string
+	challengeTTL time.Duration
+}
+
+// Origin returns the site url passkeys are used on.
+func (m *Manager) Origin() string {
+	return m.origin
+}
+
+// WithRPID sets the domain passkeys are scoped to.
+func (m *Manager) WithRPID(rpID string) *Manager {
+	m.rpID = rpID
+	return m
+}
+
+// RPID returns the domain passkeys are scoped to.
+func (m *Manager) RPID() string {
+	return m.rpID
+}
+
+// WithRPName sets the name browsers show when creating a passkey.
+func (m *Manager) WithRPName(rpName string) *Manager {
+	m.rpName = rpName
+	return m
+}
+
+// WithChallengeTTL sets the time to finish creating or using a passkey once it's started.
+func (m *Manager) WithChallengeTTL(ttl time.Duration) *Manager {
+	m.challengeTTL = ttl
+	return m
+}
+
+// BeginRegistration issues a challenge for registering a passkey for a user, returning the
+// options for the browser.
+func (m *Manager) BeginRegistration(userID string, txs ...*sql.Tx) (*CreationOptions, error) {
+	challenge, err := m.issue(ceremonyRegister, userID, txs...)
+	if err != nil {
+		return nil, err
+	}
+	credentials, err := m.Credentials(userID, txs...)
+	if err != nil {
+		return nil, err
+	}
+	options := CreationOptions{
+		Challenge: challenge,
+		RP:        RelyingParty{ID: m.rpID, Name: m.rpName},
+		User:      User{ID: encode(UserHandle(userID)), Name: userID, DisplayName: userID},
+		Timeout:   int64(m.challengeTTL / time.Millisecond),
+		// passkeys are discoverable, so admins log in without typing who they are.
+		AuthenticatorSelection: AuthenticatorSelection{ResidentKey: "required", RequireResidentKey: true, UserVerification: "required"},
+		Attestation:            "none",
+		ExcludeCredentials:     []CredentialDescriptor{},
+	}
+	for _, alg := range Algorithms {
+		options.PubKeyCredParams = append(options.PubKeyCredParams, CredentialParameter{Type: credentialType, Alg: alg})
+	}
+	for _, credential := range credentials {
+		options.ExcludeCredentials = append(options.ExcludeCredentials, CredentialDescriptor{Type: credentialType, ID: credential.CredentialID})
+	}
+	return &options, nil
+}
+
+// FinishRegistration checks the browser's response to a registration the user began, and
+// stores the passkey under a name.
+func (m *Manager) FinishRegistration(userID, name string, response RegistrationResponse, txs ...*sql.Tx) (*Credential, error) {
+	rawClientData, err := decode(response.ClientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	clientData, err := parseClientData(rawClientData, clientDataCreate, m.origin)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.redeem(clientData.Challenge, ceremonyRegister, userID, txs...); err != nil {
+		return nil, err
+	}
+
+	rawAttestation, err := decode(response.AttestationObject)
+	if err != nil {
+		return nil, err
+	}
+	rawAuthData, err := parseAttestationObject(rawAttestation)
+	if err != nil {
+		return nil, err
+	}
+	authData, err := parseAuthenticatorData(rawAuthData, m.rpID)
+	if err != nil {
+		return nil, err
+	}
+	if len(authData.CredentialID) == 0 {
+		return nil, exception.New(ErrInvalidResponse).WithMessagef("no credential was created")
+	}
+	if encode(authData.CredentialID) != trimPadding(response.ID) {
+		return nil, exception.New(ErrInvalidResponse).WithMessagef("credential id doesn't match")
+	}
+	if _, err := ParsePublicKey(authData.PublicKey); err != nil {
+		return nil, err
+	}
+
+	existing, err := m.credential(encode(authData.CredentialID), txs...)
+	if err != nil {
+		return nil, err
+	}
+	if existing.ID != 0 {
+		return nil, exception.New(ErrCredentialExists).WithMessagef("user: %s", existing.UserID)
+	}
+	credential := Credential{
+		CredentialID: encode(authData.CredentialID),
+		UserID:       userID,
+		Name:         strings.TrimSpace(name),
+		PublicKey:    encode(authData.PublicKey),
+		SignCount:    int64(authData.SignCount),
+		CreatedUTC:   time.Now().UTC(),
+	}
+	if err := audit.Invoke(m.conn, userID, txs...).Create(&credential); err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// BeginLogin issues a challenge for logging in, returning the options for the browser.
+func (m *Manager) BeginLogin(txs ...*sql.Tx) (*RequestOptions, error) {
+	challenge, err := m.issue(ceremonyLogin, "", txs...)
+	if err != nil {
+		return nil, err
+	}
+	return &RequestOptions{
+		Challenge:        challenge,
+		RPID:             m.rpID,
+		Timeout:          int64(m.challengeTTL / time.Millisecond),
+		AllowCredentials: []CredentialDescriptor{},
+		UserVerification: "required",
+	}, nil
+}
+
+// FinishLogin checks the browser's response to a login, returning the passkey it was signed
+// with; its user id is who to log in.
+func (m *Manager) FinishLogin(response AssertionResponse, txs ...*sql.Tx) (*Credential, error) {
+	rawClientData, err := decode(response.ClientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	clientData, err := parseClientData(rawClientData, clientDataGet, m.origin)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.redeem(clientData.Challenge, ceremonyLogin, "", txs...); err != nil {
+		return nil, err
+	}
+
+	credential, err := m.credential(trimPadding(response.ID), txs...)
+	if err != nil {
+		return nil, err
+	}
+	if credential.ID == 0 {
+		return nil, exception.New(ErrUnknownCredential)
+	}
+	if len(response.UserHandle) > 0 && trimPadding(response.UserHandle) != encode(UserHandle(credential.UserID)) {
+		return nil, exception.New(ErrInvalidResponse).WithMessagef("user handle doesn't match")
+	}
+
+	rawAuthData, err := decode(response.AuthenticatorData)
+	if err != nil {
+		return nil, err
+	}
+	authData, err := parseAuthenticatorData(rawAuthData, m.rpID)
+	if err != nil {
+		return nil, err
+	}
+	rawKey, err := decode(credential.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := ParsePublicKey(rawKey)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := decode(response.Signature)
+	if err != nil {
+		return nil, err
+	}
+	// the signature is of the authenticator data and the hash of the client data.
+	clientDataHash := sha256.Sum256(rawClientData)
+	if !publicKey.Verify(append(rawAuthData, clientDataHash[:]...), signature) {
+		return nil, exception.New(ErrInvalidResponse).WithMessagef("bad signature")
+	}
+
+	// authenticators that count signatures must count up; synced passkeys always send zero.
+	signCount := int64(authData.SignCount)
+	if (signCount != 0 || credential.SignCount != 0) && signCount <= credential.SignCount {
+		return nil, exception.New(ErrSignCount).WithMessagef("passkey: %d", credential.ID)
+	}
+	now := time.Now().UTC()
+	statement := "UPDATE passkey SET sign_count = " + m.conn.Dialect().Placeholder(1) + ", last_used_utc = " + m.conn.Dialect().Placeholder(2) + " WHERE id = " + m.conn.Dialect().Placeholder(3)
+	if err := m.conn.Invoke(txs...).WithLabel("passkey_used").Exec(statement, signCount, now, credential.ID); err != nil {
+		return nil, err
+	}
+	credential.SignCount = signCount
+	credential.LastUsedUTC = &now
+	return credential, nil
+}
+
+// Credentials returns a user's passkeys, oldest first.
+func (m *Manager) Credentials(userID string, txs ...*sql.Tx) ([]Credential, error) {
+	var credentials []Credential
+	err := query.Select(Credential{}).Where(query.Eq("user_id", userID)).OrderBy(query.Asc("id")).OutMany(m.conn, &credentials, txs...)
+	return credentials, err
+}
+
+// Remove deletes one of a user's passkeys.
+func (m *Manager) Remove(userID string, id int64, txs ...*sql.Tx) error {
+	var credential Credential
+	if err := m.conn.Invoke(txs...).Get(&credential, id); err != nil {
+		return err
+	}
+	if credential.ID == 0 || credential.UserID != userID {
+		return exception.New(ErrCredentialNotFound).WithMessagef("id: %d", id)
+	}
+	return audit.Invoke(m.conn, userID, txs...).Delete(&credential)
+}
+
+// UserHandle returns the user handle passkeys for a user are created with. It's a hash of the
+// user id rather than the id itself, since authenticators don't keep it secret.
+func UserHandle(userID string) []byte {
+	sum := sha256.Sum256([]byte("passkey:" + userID))
+	return sum[:]
+}
+
+// credential returns a passkey by the id the authenticator gave it; it's empty if it isn't found.
+func (m *Manager) credential(credentialID string, txs ...*sql.Tx) (*Credential, error) {
+	var credential Credential
+	err := query.Select(Credential{}).Where(query.Eq("credential_id", credentialID)).Out(m.conn, &credential, txs...)
+	return &credential, err
+}
+
+// issue stores a new challenge for a ceremony, returning it, and clears out expired challenges.
+func (m *Manager) issue(ceremony, userID string, txs ...*sql.Tx) (string, error) {
+	random, err := util.Crypto.SecureRandomBytes(challengeSize)
+	if err != nil {
+		return "", exception.New(err)
+	}
+	now := time.Now().UTC()
+	statement := "DELETE FROM passkey_challenge WHERE expires_utc < " + m.conn.Dialect().Placeholder(1)
+	if err := m.conn.Invoke(txs...).WithLabel("passkey_challenge_expire").Exec(statement, now); err != nil {
+		return "", err
+	}
+	challenge := Challenge{
+		Challenge:  encode(random),
+		Ceremony:   ceremony,
+		UserID:     userID,
+		ExpiresUTC: now.Add(m.challengeTTL),
+	}
+	if err := m.conn.Invoke(txs...).Create(&challenge); err != nil {
+		return "", err
+	}
+	return challenge.Challenge, nil
+}
+
+// redeem checks a challenge was issued for a ceremony and user, and marks it used.
+func (m *Manager) redeem(value, ceremony, userID string, txs ...*sql.Tx) error {
+	var challenge Challenge
+	if err := m.conn.Invoke(txs...).Get(&challenge, trimPadding(value)); err != nil {
+		return err
+	}
+	if len(challenge.Challenge) == 0 || challenge.Ceremony != ceremony || subtle.ConstantTimeCompare([]byte(challenge.UserID), []byte(userID)) != 1 {
+		return exception.New(ErrInvalidChallenge)
+	}
+	now := time.Now().UTC()
+	if !now.Before(challenge.ExpiresUTC) {
+		return exception.New(ErrInvalidChallenge).WithMessagef("challenge expired")
+	}
+	// the challenge is marked used only if it wasn't already, so two requests racing with the
+	// same response can't both succeed.
+	marked, err := m.markUsed(challenge.Challenge, now, txs...)
+	if err != nil {
+		return err
+	}
+	if !marked {
+		return exception.New(ErrInvalidChallenge).WithMessagef("challenge already used")
+	}
+	return nil
+}
+
+// markUsed sets when a challenge was used if it hasn't been, returning if it was set.
+func (m *Manager) markUsed(challenge string, now time.Time, txs ...*sql.Tx) (bool, error) {
+	dialect := m.conn.Dialect()
+	statement := "UPDATE passkey_challenge SET used_utc = " + dialect.Placeholder(1) + " WHERE challenge = " + dialect.Placeholder(2) + " AND used_utc IS NULL"
+	updated, err := m.conn.Invoke(txs...).WithLabel("passkey_challenge_used").ExecAffected(statement, now, challenge)
+	return updated == 1, err
+}
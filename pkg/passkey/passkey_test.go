@@ -0,0 +1,493 @@
+package passkey
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/util"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/schema/schematest"
+)
+
+const (
+	testOrigin = "https://katwillmarry.test"
+	testUserID = "owner@example.com"
+)
+
+func TestRegisterAndLogin(t *testing.T) {
+	m := newTestManager(t)
+	a := newAuthenticator(testOrigin)
+	registered := register(t, m, a)
+	if registered.UserID != testUserID || registered.Name != "Phone" || registered.SignCount != 0 {
+		t.Fatalf("unexpected passkey %+v", registered)
+	}
+	credentials, err := m.Credentials(testUserID)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(credentials) != 1 || credentials[0].CredentialID != registered.CredentialID {
+		t.Fatalf("the passkey should be stored for the user, got %+v", credentials)
+	}
+
+	for count := int64(1); count <= 2; count++ {
+		credential, err := m.FinishLogin(*login(t, m, a))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if credential.UserID != testUserID || credential.SignCount != count || credential.LastUsedUTC == nil {
+			t.Fatalf("unexpected passkey %+v", credential)
+		}
+	}
+
+	options, err := m.BeginRegistration(testUserID)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(options.ExcludeCredentials) != 1 {
+		t.Fatalf("registering should exclude the user's passkeys, got %+v", options.ExcludeCredentials)
+	}
+	if options.AuthenticatorSelection.UserVerification != "required" {
+		t.Fatalf("registering should require user verification, got %q", options.AuthenticatorSelection.UserVerification)
+	}
+}
+
+func TestRegisterRejects(t *testing.T) {
+	testCases := []struct {
+		name     string
+		origin   string
+		rpID     string
+		flags    byte
+		userID   string
+		expected exception.Class
+	}{
+		{name: "wrong origin", origin: "https://katwillmarry.evil", expected: ErrInvalidResponse},
+		{name: "wrong rp id", rpID: "katwillmarry.evil", expected: ErrInvalidResponse},
+		{name: "user not verified", flags: flagUserPresent, expected: ErrInvalidResponse},
+		{name: "another user's challenge", userID: "someone@example.com", expected: ErrInvalidChallenge},
+	}
+	for _, tc := range testCases {
+		m := newTestManager(t)
+		a := newAuthenticator(testOrigin)
+		if len(tc.origin) > 0 {
+			a.origin = tc.origin
+		}
+		if tc.flags != 0 {
+			a.flags = tc.flags
+		}
+		options, err := m.BeginRegistration(testUserID)
+		if err != nil {
+			t.Fatalf("%s: %+v", tc.name, err)
+		}
+		if len(tc.rpID) > 0 {
+			options.RP.ID = tc.rpID
+		}
+		response, err := a.Register(options)
+		if err != nil {
+			t.Fatalf("%s: %+v", tc.name, err)
+		}
+		userID := testUserID
+		if len(tc.userID) > 0 {
+			userID = tc.userID
+		}
+		if _, err := m.FinishRegistration(userID, "Phone", *response); !exception.Is(err, tc.expected) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.expected, err)
+		}
+	}
+}
+
+func TestLoginRejects(t *testing.T) {
+	testCases := []struct {
+		name string
+		// tamper changes the login response, or the site or authenticator before logging in again.
+		tamper   func(*Manager, *authenticator, *AssertionResponse) *AssertionResponse
+		expected exception.Class
+	}{
+		{
+			name: "wrong origin",
+			tamper: func(m *Manager, a *authenticator, response *AssertionResponse) *AssertionResponse {
+				a.origin = "https://katwillmarry.evil"
+				return login(t, m, a)
+			},
+			expected: ErrInvalidResponse,
+		},
+		{
+			name: "wrong rp id",
+			tamper: func(m *Manager, a *authenticator, response *AssertionResponse) *AssertionResponse {
+				for _, credential := range a.credentials {
+					credential.RPID = "katwillmarry.evil"
+				}
+				options, err := m.BeginLogin()
+				if err != nil {
+					t.Fatalf("%+v", err)
+				}
+				options.RPID = "katwillmarry.evil"
+				response, err = a.Login(options)
+				if err != nil {
+					t.Fatalf("%+v", err)
+				}
+				return response
+			},
+			expected: ErrInvalidResponse,
+		},
+		{
+			name: "reused challenge",
+			tamper: func(m *Manager, a *authenticator, response *AssertionResponse) *AssertionResponse {
+				if _, err := m.FinishLogin(*response); err != nil {
+					t.Fatalf("%+v", err)
+				}
+				return response
+			},
+			expected: ErrInvalidChallenge,
+		},
+		{
+			name: "expired challenge",
+			tamper: func(m *Manager, a *authenticator, response *AssertionResponse) *AssertionResponse {
+				m.WithChallengeTTL(-time.Minute)
+				return login(t, m, a)
+			},
+			expected: ErrInvalidChallenge,
+		},
+		{
+			name: "bad signature",
+			tamper: func(m *Manager, a *authenticator, response *AssertionResponse) *AssertionResponse {
+				signature, err := decode(response.Signature)
+				if err != nil {
+					t.Fatalf("%+v", err)
+				}
+				signature[len(signature)-1] ^= 0xff
+				response.Signature = encode(signature)
+				return response
+			},
+			expected: ErrInvalidResponse,
+		},
+		{
+			name: "sign count didn't increase",
+			tamper: func(m *Manager, a *authenticator, response *AssertionResponse) *AssertionResponse {
+				if _, err := m.FinishLogin(*response); err != nil {
+					t.Fatalf("%+v", err)
+				}
+				// a clone of the passkey counts on from where it was copied.
+				for _, credential := range a.credentials {
+					credential.SignCount = 0
+				}
+				return login(t, m, a)
+			},
+			expected: ErrSignCount,
+		},
+		{
+			name: "user not verified",
+			tamper: func(m *Manager, a *authenticator, response *AssertionResponse) *AssertionResponse {
+				a.flags = flagUserPresent
+				return login(t, m, a)
+			},
+			expected: ErrInvalidResponse,
+		},
+	}
+	for _, tc := range testCases {
+		m := newTestManager(t)
+		a := newAuthenticator(testOrigin)
+		register(t, m, a)
+		response := tc.tamper(m, a, login(t, m, a))
+		if _, err := m.FinishLogin(*response); !exception.Is(err, tc.expected) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.expected, err)
+		}
+	}
+}
+
+func newTestManager(t *testing.T) *Manager {
+	m, err := NewManager(schematest.Open(t), testOrigin)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return m
+}
+
+// register registers a passkey for the test user with an authenticator.
+func register(t *testing.T, m *Manager, a *authenticator) *Credential {
+	options, err := m.BeginRegistration(testUserID)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	response, err := a.Register(options)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	credential, err := m.FinishRegistration(testUserID, "Phone", *response)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return credential
+}
+
+// login begins a login and signs it with an authenticator.
+func login(t *testing.T, m *Manager, a *authenticator) *AssertionResponse {
+	options, err := m.BeginLogin()
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if options.UserVerification != "required" {
+		t.Fatalf("logging in should require user verification, got %q", options.UserVerification)
+	}
+	response, err := a.Login(options)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return response
+}
+
+// errNoCredential is returned by the software authenticator when it has no passkey for a login.
+const errNoCredential exception.Class = "passkey: authenticator has no passkey for the site"
+
+// newAuthenticator returns a new software authenticator for a browser on an origin.
+func newAuthenticator(origin string) *authenticator {
+	return &authenticator{
+		origin:      origin,
+		flags:       flagUserPresent | flagUserVerified,
+		credentials: map[string]*softwareCredential{},
+	}
+}
+
+// authenticator is a software authenticator, standing in for the browser and a device, so
+// registering and logging in with passkeys can be tested without either.
+//
+// It creates es256 passkeys kept in memory, and answers the options the site gives the browser
+// with the responses the browser would send back. Its sign counters start at zero and go up
+// with each login, like a security key's.
+type authenticator struct {
+	origin string
+	// flags are the authenticator data flags it sets, ex. without user verification.
+	flags byte
+
+	mu          sync.Mutex
+	credentials map[string]*softwareCredential
+}
+
+// softwareCredential is a passkey the software authenticator created.
+type softwareCredential struct {
+	ID         []byte
+	RPID       string
+	UserHandle string
+	Key        *ecdsa.PrivateKey
+	SignCount  uint32
+}
+
+// Register creates a passkey for registration options, returning the browser's response.
+func (a *authenticator) Register(options *CreationOptions) (*RegistrationResponse, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, exception.New(err)
+	}
+	id, err := util.Crypto.SecureRandomBytes(challengeSize)
+	if err != nil {
+		return nil, exception.New(err)
+	}
+	credential := &softwareCredential{
+		ID:         id,
+		RPID:       options.RP.ID,
+		UserHandle: options.User.ID,
+		Key:        key,
+	}
+
+	publicKey := encodeES256Key(&key.PublicKey)
+	attested := make([]byte, 0, aaguidSize+2+len(id)+len(publicKey))
+	attested = append(attested, make([]byte, aaguidSize)...)
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(id)))
+	attested = append(attested, id...)
+	attested = append(attested, publicKey...)
+	authData := credential.authenticatorData(a.flags|flagAttestedCredentialData, attested)
+
+	clientDataJSON, err := a.clientData(clientDataCreate, options.Challenge)
+	if err != nil {
+		return nil, err
+	}
+	attestationObject := encodeCBOR(map[interface{}]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[interface{}]interface{}{},
+		"authData": authData,
+	})
+
+	a.mu.Lock()
+	a.credentials[encode(id)] = credential
+	a.mu.Unlock()
+	return &RegistrationResponse{
+		ID:                encode(id),
+		ClientDataJSON:    encode(clientDataJSON),
+		AttestationObject: encode(attestationObject),
+	}, nil
+}
+
+// Login signs login options with a passkey for the site, returning the browser's response.
+// If the options allow specific credentials one of those is used, otherwise any for the site.
+func (a *authenticator) Login(options *RequestOptions) (*AssertionResponse, error) {
+	credential := a.find(options)
+	if credential == nil {
+		return nil, exception.New(errNoCredential).WithMessagef("rp id: %s", options.RPID)
+	}
+
+	a.mu.Lock()
+	credential.SignCount++
+	authData := credential.authenticatorData(a.flags, nil)
+	a.mu.Unlock()
+
+	clientDataJSON, err := a.clientData(clientDataGet, options.Challenge)
+	if err != nil {
+		return nil, err
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, credential.Key, digest[:])
+	if err != nil {
+		return nil, exception.New(err)
+	}
+	return &AssertionResponse{
+		ID:                encode(credential.ID),
+		ClientDataJSON:    encode(clientDataJSON),
+		AuthenticatorData: encode(authData),
+		Signature:         encode(signature),
+		UserHandle:        credential.UserHandle,
+	}, nil
+}
+
+func (a *authenticator) find(options *RequestOptions) *softwareCredential {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(options.AllowCredentials) > 0 {
+		for _, allowed := range options.AllowCredentials {
+			if credential, ok := a.credentials[trimPadding(allowed.ID)]; ok && credential.RPID == options.RPID {
+				return credential
+			}
+		}
+		return nil
+	}
+	for _, credential := range a.credentials {
+		if credential.RPID == options.RPID {
+			return credential
+		}
+	}
+	return nil
+}
+
+// clientData returns the client data the browser would send for a ceremony.
+func (a *authenticator) clientData(ceremony, challenge string) ([]byte, error) {
+	data, err := json.Marshal(clientData{Type: ceremony, Challenge: challenge, Origin: a.origin})
+	if err != nil {
+		return nil, exception.New(err)
+	}
+	return data, nil
+}
+
+// authenticatorData returns the authenticator data for the passkey, with the flags and the
+// attested credential data, if any.
+func (sc *softwareCredential) authenticatorData(flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(sc.RPID))
+	data := make([]byte, 0, authenticatorDataSize+len(attested))
+	data = append(data, rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, sc.SignCount)
+	return append(data, attested...)
+}
+
+// encodeES256Key returns the cose encoding of a p-256 public key.
+func encodeES256Key(key *ecdsa.PublicKey) []byte {
+	x, y := make([]byte, 32), make([]byte, 32)
+	key.X.FillBytes(x)
+	key.Y.FillBytes(y)
+	return encodeCBOR(map[interface{}]interface{}{
+		int64(coseKeyType):   int64(coseKeyTypeEC2),
+		int64(coseAlgorithm): int64(AlgES256),
+		int64(coseCurve):     int64(coseCurveP256),
+		int64(coseX):         x,
+		int64(coseY):         y,
+	})
+}
+
+// encodeCBOR encodes a value in canonical cbor, for the software authenticator.
+// It supports the types `decodeCBOR` returns, and int.
+func encodeCBOR(value interface{}) []byte {
+	buffer := new(bytes.Buffer)
+	writeCBOR(buffer, value)
+	return buffer.Bytes()
+}
+
+func writeCBOR(buffer *bytes.Buffer, value interface{}) {
+	switch typed := value.(type) {
+	case nil:
+		buffer.WriteByte(cborSimple<<5 | cborNull)
+	case bool:
+		if typed {
+			buffer.WriteByte(cborSimple<<5 | cborTrue)
+		} else {
+			buffer.WriteByte(cborSimple<<5 | cborFalse)
+		}
+	case int:
+		writeCBOR(buffer, int64(typed))
+	case int64:
+		if typed < 0 {
+			writeCBORHead(buffer, cborNegative, uint64(-1-typed))
+		} else {
+			writeCBORHead(buffer, cborUnsigned, uint64(typed))
+		}
+	case []byte:
+		writeCBORHead(buffer, cborBytes, uint64(len(typed)))
+		buffer.Write(typed)
+	case string:
+		writeCBORHead(buffer, cborText, uint64(len(typed)))
+		buffer.WriteString(typed)
+	case []interface{}:
+		writeCBORHead(buffer, cborArray, uint64(len(typed)))
+		for _, item := range typed {
+			writeCBOR(buffer, item)
+		}
+	case map[interface{}]interface{}:
+		// canonical order is by the encoded keys, shortest first.
+		keys := make([][]byte, 0, len(typed))
+		encoded := map[string]interface{}{}
+		for key, item := range typed {
+			encodedKey := encodeCBOR(key)
+			keys = append(keys, encodedKey)
+			encoded[string(encodedKey)] = item
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if len(keys[i]) != len(keys[j]) {
+				return len(keys[i]) < len(keys[j])
+			}
+			return bytes.Compare(keys[i], keys[j]) < 0
+		})
+		writeCBORHead(buffer, cborMap, uint64(len(typed)))
+		for _, key := range keys {
+			buffer.Write(key)
+			writeCBOR(buffer, encoded[string(key)])
+		}
+	default:
+		panic("passkey: cannot encode a value of this type as cbor")
+	}
+}
+
+func writeCBORHead(buffer *bytes.Buffer, major byte, argument uint64) {
+	switch {
+	case argument < 24:
+		buffer.WriteByte(major<<5 | byte(argument))
+	case argument <= math.MaxUint8:
+		buffer.Write([]byte{major<<5 | 24, byte(argument)})
+	case argument <= math.MaxUint16:
+		buffer.WriteByte(major<<5 | 25)
+		binary.Write(buffer, binary.BigEndian, uint16(argument))
+	case argument <= math.MaxUint32:
+		buffer.WriteByte(major<<5 | 26)
+		binary.Write(buffer, binary.BigEndian, uint32(argument))
+	default:
+		buffer.WriteByte(major<<5 | 27)
+		binary.Write(buffer, binary.BigEndian, argument)
+	}
+}
@@ -0,0 +1,234 @@
+package passkey
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/blend/go-sdk/exception"
+)
+
+// The types below are the json the browser and the site exchange. Binary values are base64url
+// encoded without padding; the site's script converts them to and from the buffers the
+// browser's `navigator.credentials` api takes.
+
+// CreationOptions are the options for creating a passkey, ex. `navigator.credentials.create({ publicKey: options })`.
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     RelyingParty           `json:"rp"`
+	User                   User                   `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions are the options for logging in with a passkey, ex. `navigator.credentials.get({ publicKey: options })`.
+// Allowed credentials are left empty, so the browser offers any of the site's passkeys.
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	RPID             string                 `json:"rpId"`
+	Timeout          int64                  `json:"timeout"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// RelyingParty is the site passkeys are for.
+type RelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// User is the user a passkey is created for.
+type User struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// CredentialParameter is an algorithm a passkey can use.
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+// CredentialDescriptor identifies a passkey.
+type CredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// AuthenticatorSelection is what's asked of the authenticator that creates a passkey.
+type AuthenticatorSelection struct {
+	ResidentKey        string `json:"residentKey"`
+	RequireResidentKey bool   `json:"requireResidentKey"`
+	UserVerification   string `json:"userVerification"`
+}
+
+// RegistrationResponse is the browser's response to creating a passkey.
+type RegistrationResponse struct {
+	ID                string `json:"id"`
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AttestationObject string `json:"attestationObject"`
+}
+
+// AssertionResponse is the browser's response to logging in with a passkey.
+type AssertionResponse struct {
+	ID                string `json:"id"`
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AuthenticatorData string `json:"authenticatorData"`
+	Signature         string `json:"signature"`
+	UserHandle        string `json:"userHandle"`
+}
+
+// the ceremony types the browser puts in client data.
+const (
+	clientDataCreate = "webauthn.create"
+	clientDataGet    = "webauthn.get"
+)
+
+// clientData is what the browser says about the request it signed.
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// parseClientData decodes client data and checks it's for a ceremony at an origin.
+func parseClientData(raw []byte, ceremony, origin string) (*clientData, error) {
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, exception.New(ErrInvalidResponse).WithMessagef("client data isn't json")
+	}
+	if data.Type != ceremony {
+		return nil, exception.New(ErrInvalidResponse).WithMessagef("client data type: %s", data.Type)
+	}
+	if data.Origin != origin {
+		return nil, exception.New(ErrInvalidResponse).WithMessagef("client data origin: %s", data.Origin)
+	}
+	if len(data.Challenge) == 0 {
+		return nil, exception.New(ErrInvalidResponse).WithMessagef("client data challenge missing")
+	}
+	return &data, nil
+}
+
+// authenticator data flags.
+const (
+	flagUserPresent            = 0x01
+	flagUserVerified           = 0x04
+	flagAttestedCredentialData = 0x40
+	flagExtensionData          = 0x80
+
+	// authenticatorDataSize is the size of the rp id hash, flags and sign count.
+	authenticatorDataSize = 32 + 1 + 4
+	// aaguidSize is the size of the authenticator model id before the credential id.
+	aaguidSize = 16
+)
+
+// authenticatorData is what the authenticator says about a request it signed.
+type authenticatorData struct {
+	RPIDHash  []byte
+	Flags     byte
+	SignCount uint32
+
+	// CredentialID and PublicKey are set when a passkey is created.
+	CredentialID []byte
+	PublicKey    []byte
+}
+
+// parseAuthenticatorData decodes authenticator data and checks it's for a relying party and the
+// user was present and verified, ex. with a fingerprint or pin, so a passkey alone isn't enough.
+func parseAuthenticatorData(raw []byte, rpID string) (*authenticatorData, error) {
+	if len(raw) < authenticatorDataSize {
+		return nil, exception.New(ErrInvalidResponse).WithMessagef("authenticator data is too short")
+	}
+	data := authenticatorData{
+		RPIDHash:  raw[:32],
+		Flags:     raw[32],
+		SignCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+	expected := sha256.Sum256([]byte(rpID))
+	if subtle.ConstantTimeCompare(data.RPIDHash, expected[:]) != 1 {
+		return nil, exception.New(ErrInvalidResponse).WithMessagef("authenticator data is for another site")
+	}
+	if data.Flags&flagUserPresent == 0 {
+		return nil, exception.New(ErrInvalidResponse).WithMessagef("user wasn't present")
+	}
+	if data.Flags&flagUserVerified == 0 {
+		return nil, exception.New(ErrInvalidResponse).WithMessagef("user wasn't verified")
+	}
+
+	rest := raw[authenticatorDataSize:]
+	if data.Flags&flagAttestedCredentialData != 0 {
+		if len(rest) < aaguidSize+2 {
+			return nil, exception.New(ErrInvalidResponse).WithMessagef("attested credential data is too short")
+		}
+		rest = rest[aaguidSize:]
+		idLength := int(binary.BigEndian.Uint16(rest))
+		rest = rest[2:]
+		if idLength == 0 || len(rest) < idLength {
+			return nil, exception.New(ErrInvalidResponse).WithMessagef("invalid credential id")
+		}
+		data.CredentialID, rest = rest[:idLength], rest[idLength:]
+		// the key is the cbor item that follows; anything after it is extension data.
+		_, afterKey, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, err
+		}
+		data.PublicKey, rest = rest[:len(rest)-len(afterKey)], afterKey
+	}
+	if data.Flags&flagExtensionData != 0 {
+		var err error
+		if _, rest, err = decodeCBOR(rest); err != nil {
+			return nil, err
+		}
+	}
+	if len(rest) > 0 {
+		return nil, exception.New(ErrInvalidResponse).WithMessagef("authenticator data has trailing data")
+	}
+	return &data, nil
+}
+
+// parseAttestationObject returns the authenticator data of an attestation object.
+//
+// Attestation isn't verified: passkeys are created with `attestation: none`, since the site
+// doesn't restrict which authenticators admins use, and some authenticators attest anyway.
+func parseAttestationObject(raw []byte) ([]byte, error) {
+	value, rest, err := decodeCBOR(raw)
+	if err != nil {
+		return nil, err
+	}
+	object, ok := value.(map[interface{}]interface{})
+	if !ok || len(rest) > 0 {
+		return nil, exception.New(ErrInvalidResponse).WithMessagef("invalid attestation object")
+	}
+	authData, ok := object["authData"].([]byte)
+	if !ok {
+		return nil, exception.New(ErrInvalidResponse).WithMessagef("attestation object has no authenticator data")
+	}
+	return authData, nil
+}
+
+// decode decodes a base64url value from the browser; padding is accepted but not required.
+func decode(value string) ([]byte, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(trimPadding(value))
+	if err != nil {
+		return nil, exception.New(ErrInvalidResponse).WithMessagef("invalid base64url value")
+	}
+	return decoded, nil
+}
+
+// encode encodes a value for the browser.
+func encode(value []byte) string {
+	return base64.RawURLEncoding.EncodeToString(value)
+}
+
+func trimPadding(value string) string {
+	for len(value) > 0 && value[len(value)-1] == '=' {
+		value = value[:len(value)-1]
+	}
+	return value
+}
@@ -0,0 +1,50 @@
+package pii
+
+import (
+	"strings"
+
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/util"
+)
+
+// Config is the encryption key config.
+type Config struct {
+	// Keys are the encryption keys as comma separated `<key id>=<base64 key>` pairs; at least one is required to start.
+	// Keys must be 16, 24 or 32 bytes (AES-128, 192 or 256); generate one with `openssl rand -base64 32`.
+	// Old keys are kept in the list until `cmd/rotatekeys` has re-encrypted everything with the primary key.
+	Keys string `json:"keys,omitempty" yaml:"keys,omitempty" env:"PII_KEYS"`
+	// PrimaryKeyID is the key new values are encrypted with; it defaults to the last key listed.
+	PrimaryKeyID string `json:"primaryKeyID,omitempty" yaml:"primaryKeyID,omitempty" env:"PII_PRIMARY_KEY_ID"`
+}
+
+// IsZero returns if the config has no keys.
+func (c Config) IsZero() bool {
+	return len(strings.TrimSpace(c.Keys)) == 0
+}
+
+// GetKeys returns the keys by id, in the order they're listed.
+func (c Config) GetKeys() (ids []string, keys map[string][]byte, err error) {
+	keys = map[string][]byte{}
+	for _, pair := range strings.Split(c.Keys, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 {
+			return nil, nil, exception.New(ErrInvalidKey).WithMessagef("keys must be `<key id>=<base64 key>` pairs")
+		}
+		key, decodeErr := util.Base64.Decode(parts[1])
+		if decodeErr != nil {
+			return nil, nil, exception.New(ErrInvalidKey).WithMessagef("key id: %s", parts[0])
+		}
+		ids = append(ids, parts[0])
+		keys[parts[0]] = key
+	}
+	return ids, keys, nil
+}
+
+// GetPrimaryKeyID returns a property or a default.
+func (c Config) GetPrimaryKeyID(inherited ...string) string {
+	return util.Coalesce.String(c.PrimaryKeyID, "", inherited...)
+}
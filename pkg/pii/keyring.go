@@ -0,0 +1,168 @@
+package pii
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"strings"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/util"
+)
+
+const (
+	// ErrInvalidKey is returned for keys that aren't a valid AES key size, or have an invalid id.
+	ErrInvalidKey exception.Class = "pii: invalid encryption key"
+	// ErrNoPrimaryKey is returned when encrypting without a primary key set.
+	ErrNoPrimaryKey exception.Class = "pii: no primary encryption key"
+	// ErrUnknownKey is returned when decrypting a value that was encrypted with a key the keyring doesn't have.
+	ErrUnknownKey exception.Class = "pii: value was encrypted with an unknown key"
+	// ErrInvalidCipherText is returned when decrypting a value that isn't in the stored format.
+	ErrInvalidCipherText exception.Class = "pii: invalid ciphertext"
+)
+
+// separator separates the parts of a stored value.
+const separator = ":"
+
+// assert the keyring is a db encryptor.
+var _ db.Encryptor = (*Keyring)(nil)
+
+// NewKeyring returns an empty keyring.
+func NewKeyring() *Keyring {
+	return &Keyring{keys: map[string][]byte{}}
+}
+
+// NewKeyringFromConfig returns a keyring from a config.
+func NewKeyringFromConfig(cfg *Config) (*Keyring, error) {
+	ids, keys, err := cfg.GetKeys()
+	if err != nil {
+		return nil, err
+	}
+	keyring := NewKeyring()
+	for _, id := range ids {
+		if err := keyring.AddKey(id, keys[id]); err != nil {
+			return nil, err
+		}
+	}
+	primary := cfg.GetPrimaryKeyID()
+	if len(primary) == 0 && len(ids) > 0 {
+		primary = ids[len(ids)-1]
+	}
+	if len(primary) > 0 {
+		if err := keyring.SetPrimary(primary); err != nil {
+			return nil, err
+		}
+	}
+	return keyring, nil
+}
+
+// Keyring is a set of AES-GCM keys by id, one of which is the primary key new values are encrypted with.
+//
+// It is the `db.Encryptor` for columns tagged `encrypted`. Values are stored as
+// `<key id>:<base64 nonce>:<base64 ciphertext>`, so they can be decrypted after the primary key
+// changes, and rows still on an old key can be found and re-encrypted (see `Rotate`).
+// The encryption context (the table, column and primary key, see `db.EncryptionContext`) is the
+// additional data, so a value copied to another row or column doesn't decrypt.
+type Keyring struct {
+	primary string
+	keys    map[string][]byte
+}
+
+// AddKey adds a key.
+func (k *Keyring) AddKey(id string, key []byte) error {
+	if len(id) == 0 || strings.Contains(id, separator) {
+		return exception.New(ErrInvalidKey).WithMessagef("key id: %q", id)
+	}
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return exception.New(ErrInvalidKey).WithMessagef("key id: %s, length: %d", id, len(key))
+	}
+	k.keys[id] = key
+	return nil
+}
+
+// SetPrimary sets the key new values are encrypted with; it must have been added.
+func (k *Keyring) SetPrimary(id string) error {
+	if _, ok := k.keys[id]; !ok {
+		return exception.New(ErrUnknownKey).WithMessagef("key id: %s", id)
+	}
+	k.primary = id
+	return nil
+}
+
+// Primary returns the id of the key new values are encrypted with.
+func (k *Keyring) Primary() string {
+	return k.primary
+}
+
+// Encrypt encrypts a value with the primary key, authenticating the context it's stored in.
+func (k *Keyring) Encrypt(plainText string, context []byte) (string, error) {
+	key, ok := k.keys[k.primary]
+	if !ok {
+		return "", exception.New(ErrNoPrimaryKey)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", exception.New(err)
+	}
+	sealed := aead.Seal(nil, nonce, []byte(plainText), context)
+	return k.primary + separator + util.Base64.Encode(nonce) + separator + util.Base64.Encode(sealed), nil
+}
+
+// Decrypt decrypts a value with the key it was encrypted with; the context has to be the one it was encrypted with.
+func (k *Keyring) Decrypt(cipherText string, context []byte) (string, error) {
+	parts := strings.Split(cipherText, separator)
+	if len(parts) != 3 {
+		return "", exception.New(ErrInvalidCipherText)
+	}
+	key, ok := k.keys[parts[0]]
+	if !ok {
+		return "", exception.New(ErrUnknownKey).WithMessagef("key id: %s", parts[0])
+	}
+	nonce, err := util.Base64.Decode(parts[1])
+	if err != nil {
+		return "", exception.New(ErrInvalidCipherText)
+	}
+	sealed, err := util.Base64.Decode(parts[2])
+	if err != nil {
+		return "", exception.New(ErrInvalidCipherText)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return "", exception.New(ErrInvalidCipherText)
+	}
+	plainText, err := aead.Open(nil, nonce, sealed, context)
+	if err != nil {
+		return "", exception.New(ErrInvalidCipherText).WithMessagef("the value doesn't authenticate with its key and context")
+	}
+	return string(plainText), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, exception.New(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, exception.New(err)
+	}
+	return aead, nil
+}
+
+// KeyID returns the id of the key a stored value was encrypted with.
+func KeyID(cipherText string) string {
+	if index := strings.Index(cipherText, separator); index > 0 {
+		return cipherText[:index]
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package pii_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/pii"
+	"github.com/wcharczuk/katwillmarry.com/pkg/schema/schematest"
+)
+
+func newKeyring(t *testing.T) *pii.Keyring {
+	t.Helper()
+	keyring := pii.NewKeyring()
+	if err := keyring.AddKey("k1", bytes.Repeat([]byte{1}, 32)); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := keyring.SetPrimary("k1"); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return keyring
+}
+
+func TestKeyringContext(t *testing.T) {
+	keyring := newKeyring(t)
+	context := db.EncryptionContext("guest", "phone", 1)
+	cipherText, err := keyring.Encrypt("+15555550100", context)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !strings.HasPrefix(cipherText, "k1:") || strings.Contains(cipherText, "5555550100") {
+		t.Fatalf("unexpected ciphertext: %s", cipherText)
+	}
+	plainText, err := keyring.Decrypt(cipherText, context)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if plainText != "+15555550100" {
+		t.Fatalf("decrypt returned %q", plainText)
+	}
+	for _, other := range [][]byte{
+		db.EncryptionContext("guest", "phone", 2),
+		db.EncryptionContext("guest", "dietary_notes", 1),
+		db.EncryptionContext("household", "phone", 1),
+		nil,
+	} {
+		if _, err := keyring.Decrypt(cipherText, other); err == nil {
+			t.Fatalf("decrypting with the context %q should fail", other)
+		}
+	}
+}
+
+func TestEncryptedColumns(t *testing.T) {
+	conn := schematest.Open(t)
+	conn.WithEncryptor(newKeyring(t))
+	now := time.Now().UTC()
+
+	household := model.Household{Name: "The Smiths", Address: "1 Main St", CreatedUTC: now, UpdatedUTC: now}
+	if err := conn.Invoke().Create(&household); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	guests := []model.Guest{
+		{HouseholdID: household.ID, Name: "Alice", Phone: "+15555550100", CreatedUTC: now, UpdatedUTC: now},
+		{HouseholdID: household.ID, Name: "Bob", Phone: "+15555550101", CreatedUTC: now, UpdatedUTC: now},
+	}
+	for index := range guests {
+		if err := conn.Invoke().Create(&guests[index]); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+
+	var read model.Guest
+	if err := conn.Invoke().Get(&read, guests[0].ID); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if read.Phone != "+15555550100" {
+		t.Fatalf("get should decrypt the phone, got %q", read.Phone)
+	}
+
+	// a stored value copied to another row doesn't decrypt.
+	var stored string
+	if err := conn.Invoke().Query(`SELECT phone FROM guest WHERE id = ?1`, guests[0].ID).Scan(&stored); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := conn.Invoke().Exec(`UPDATE guest SET phone = ?1 WHERE id = ?2`, stored, guests[1].ID); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := conn.Invoke().Get(&read, guests[1].ID); err == nil {
+		t.Fatalf("a value copied from another row should fail to decrypt, got %q", read.Phone)
+	}
+}
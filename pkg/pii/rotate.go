@@ -0,0 +1,222 @@
+package pii
+
+import (
+	"database/sql"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+)
+
+const (
+	// DefaultBatchSize is the default number of rows re-encrypted per transaction.
+	DefaultBatchSize = 500
+
+	// ErrCompositeKey is returned when rotating a type that doesn't have exactly one primary key column.
+	ErrCompositeKey exception.Class = "pii: rotation needs a single primary key column"
+)
+
+var (
+	registryLock sync.Mutex
+	registry     []db.DatabaseMapped
+)
+
+// Register registers the types with encrypted columns, so `RotateAll` can find them.
+// Types without encrypted columns are ignored.
+func Register(objects ...db.DatabaseMapped) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	for _, object := range objects {
+		if len(db.Columns(object).Encrypted()) > 0 {
+			registry = append(registry, object)
+		}
+	}
+}
+
+// Registered returns the registered types.
+func Registered() []db.DatabaseMapped {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	return append([]db.DatabaseMapped(nil), registry...)
+}
+
+// RotateAll re-encrypts the registered types, see `Rotate`.
+// It returns the number of rows re-encrypted by table.
+func RotateAll(conn *db.Connection, keyring *Keyring, batchSize int) (map[string]int, error) {
+	rotated := map[string]int{}
+	for _, object := range Registered() {
+		count, err := Rotate(conn, keyring, object, batchSize)
+		rotated[db.TableName(object)] = count
+		if err != nil {
+			return rotated, err
+		}
+	}
+	return rotated, nil
+}
+
+// Rotate re-encrypts the encrypted columns of a type that aren't on the keyring's primary key,
+// walking the table in primary key order with a transaction per batch.
+//
+// It works on the stored values directly, so soft deleted rows are included and version
+// columns aren't changed. It returns the number of rows re-encrypted; it can be re-run safely.
+func Rotate(conn *db.Connection, keyring *Keyring, object db.DatabaseMapped, batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	cols := db.Columns(object)
+	pks := cols.PrimaryKeys().Columns()
+	if len(pks) != 1 {
+		return 0, exception.New(ErrCompositeKey).WithMessagef("table: %s", db.TableName(object))
+	}
+	r := rotation{
+		conn:      conn,
+		keyring:   keyring,
+		table:     db.TableName(object),
+		pk:        pks[0],
+		encrypted: cols.Encrypted(),
+		batchSize: batchSize,
+	}
+
+	var total int
+	var after interface{}
+	for {
+		count, last, err := r.batch(after)
+		total += count
+		if err != nil || last == nil {
+			return total, err
+		}
+		after = last
+	}
+}
+
+// rotation is the state for rotating a single table.
+type rotation struct {
+	conn      *db.Connection
+	keyring   *Keyring
+	table     string
+	pk        db.Column
+	encrypted []db.Column
+	batchSize int
+}
+
+// row is the primary key and stored encrypted values of a row.
+type row struct {
+	key    interface{}
+	values []sql.NullString
+}
+
+// batch re-encrypts the batch of rows after a primary key (or from the start if it's nil),
+// and returns the number re-encrypted and the last primary key, or nil if there are no more rows.
+func (r rotation) batch(after interface{}) (count int, last interface{}, err error) {
+	var tx *sql.Tx
+	tx, err = r.conn.Begin()
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = exception.New(tx.Commit())
+	}()
+
+	var rows []row
+	rows, err = r.read(tx, after)
+	if err != nil || len(rows) == 0 {
+		return
+	}
+	// a short batch is the end of the table.
+	if len(rows) == r.batchSize {
+		last = rows[len(rows)-1].key
+	}
+
+	for _, current := range rows {
+		var changed bool
+		changed, err = r.reencrypt(current)
+		if err != nil {
+			err = exception.New(err).WithMessagef("table: %s, key: %v", r.table, current.key)
+			return
+		}
+		if !changed {
+			continue
+		}
+		if err = r.write(tx, current); err != nil {
+			return
+		}
+		count++
+	}
+	return
+}
+
+func (r rotation) read(tx *sql.Tx, after interface{}) ([]row, error) {
+	dialect := r.conn.Dialect()
+	names := make([]string, len(r.encrypted))
+	for index, col := range r.encrypted {
+		names[index] = col.ColumnName
+	}
+	statement := "SELECT " + r.pk.ColumnName + "," + strings.Join(names, ",") + " FROM " + r.table
+	label := "pii_rotate_" + r.table + "_first"
+	var args []interface{}
+	if after != nil {
+		statement += " WHERE " + r.pk.ColumnName + " > " + dialect.Placeholder(1)
+		label = "pii_rotate_" + r.table + "_next"
+		args = append(args, after)
+	}
+	statement += " ORDER BY " + r.pk.ColumnName + " LIMIT " + strconv.Itoa(r.batchSize)
+
+	var rows []row
+	err := r.conn.Invoke(tx).WithLabel(label).Query(statement, args...).Each(func(scanner *sql.Rows) error {
+		current := row{
+			key:    reflect.New(r.pk.FieldType).Interface(),
+			values: make([]sql.NullString, len(r.encrypted)),
+		}
+		targets := []interface{}{current.key}
+		for index := range current.values {
+			targets = append(targets, &current.values[index])
+		}
+		if err := scanner.Scan(targets...); err != nil {
+			return exception.New(err)
+		}
+		current.key = reflect.ValueOf(current.key).Elem().Interface()
+		rows = append(rows, current)
+		return nil
+	})
+	return rows, err
+}
+
+// reencrypt re-encrypts the values of a row that aren't on the primary key in place, returning if any were.
+func (r rotation) reencrypt(current row) (changed bool, err error) {
+	for index, value := range current.values {
+		if !value.Valid || len(value.String) == 0 || KeyID(value.String) == r.keyring.Primary() {
+			continue
+		}
+		context := db.EncryptionContext(r.table, r.encrypted[index].ColumnName, current.key)
+		var plainText string
+		if plainText, err = r.keyring.Decrypt(value.String, context); err != nil {
+			return
+		}
+		if current.values[index].String, err = r.keyring.Encrypt(plainText, context); err != nil {
+			return
+		}
+		changed = true
+	}
+	return
+}
+
+func (r rotation) write(tx *sql.Tx, current row) error {
+	dialect := r.conn.Dialect()
+	assignments := make([]string, len(r.encrypted))
+	args := make([]interface{}, 0, len(r.encrypted)+1)
+	for index, col := range r.encrypted {
+		assignments[index] = col.ColumnName + " = " + dialect.Placeholder(index+1)
+		args = append(args, current.values[index])
+	}
+	args = append(args, current.key)
+	statement := "UPDATE " + r.table + " SET " + strings.Join(assignments, ",") +
+		" WHERE " + r.pk.ColumnName + " = " + dialect.Placeholder(len(args))
+	return r.conn.Invoke(tx).WithLabel("pii_rotate_"+r.table+"_update").Exec(statement, args...)
+}
@@ -0,0 +1,323 @@
+package query
+
+import (
+	"bytes"
+	"database/sql"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/softdelete"
+)
+
+const (
+	// ErrUnknownColumn is returned when a filter or sort names a column the type doesn't map.
+	ErrUnknownColumn exception.Class = "query: unknown column"
+	// ErrInvalidCursor is returned when the keyset cursor doesn't line up with the sort.
+	ErrInvalidCursor exception.Class = "query: cursor must have one value per order by column"
+)
+
+// Select returns a builder for the rows of a database mapped type.
+// Columns are taken from `db.Columns(object)`, and every column named in a filter or sort
+// must be one of them, so it is safe to pass user input (ex. a `?sort=` parameter) through.
+//
+// If the type has a soft delete column, deleted rows are left out unless `WithDeleted`
+// or `OnlyDeleted` is set.
+func Select(object db.DatabaseMapped) *Builder {
+	b := &Builder{
+		table:   db.TableName(object),
+		columns: db.Columns(object),
+	}
+	if col := softdelete.Column(object); col != nil {
+		b.softDelete = col.ColumnName
+	}
+	return b
+}
+
+// Builder composes a parameterized select statement.
+type Builder struct {
+	table   string
+	columns *db.ColumnCollection
+	label   string
+
+	softDelete string
+	deleted    deletedFilter
+
+	where   Condition
+	orderBy []Order
+	after   []interface{}
+	limit   int
+	offset  int
+}
+
+// WithLabel sets the statement label prefix used for query events.
+// It defaults to `<table>_query`.
+func (b *Builder) WithLabel(label string) *Builder {
+	b.label = label
+	return b
+}
+
+// Label returns the statement label prefix.
+func (b *Builder) Label() string {
+	if len(b.label) > 0 {
+		return b.label
+	}
+	return b.table + "_query"
+}
+
+// statementLabel returns the label a statement runs under.
+// The connection caches prepared statements by label, and the sql changes with the filters,
+// so each distinct statement gets its own label.
+func (b *Builder) statementLabel(suffix, statement string) string {
+	h := fnv.New32a()
+	h.Write([]byte(statement))
+	return b.Label() + suffix + "_" + strconv.FormatUint(uint64(h.Sum32()), 16)
+}
+
+// Where sets the filter, replacing any existing filter.
+func (b *Builder) Where(condition Condition) *Builder {
+	b.where = condition
+	return b
+}
+
+// And adds conditions that must all hold alongside the existing filter.
+func (b *Builder) And(conditions ...Condition) *Builder {
+	b.where = And(append([]Condition{b.where}, conditions...)...)
+	return b
+}
+
+// Or adds conditions any of which can hold instead of the existing filter.
+func (b *Builder) Or(conditions ...Condition) *Builder {
+	b.where = Or(append([]Condition{b.where}, conditions...)...)
+	return b
+}
+
+// WithDeleted includes soft deleted rows.
+func (b *Builder) WithDeleted() *Builder {
+	b.deleted = withDeleted
+	return b
+}
+
+// OnlyDeleted returns only soft deleted rows (ex. for a trash view).
+func (b *Builder) OnlyDeleted() *Builder {
+	b.deleted = onlyDeleted
+	return b
+}
+
+// OrderBy adds sort columns, see `Asc` and `Desc`.
+func (b *Builder) OrderBy(orders ...Order) *Builder {
+	b.orderBy = append(b.orderBy, orders...)
+	return b
+}
+
+// Limit sets the maximum number of rows returned; zero means no limit.
+func (b *Builder) Limit(limit int) *Builder {
+	b.limit = limit
+	return b
+}
+
+// Offset sets the number of rows to skip.
+func (b *Builder) Offset(offset int) *Builder {
+	b.offset = offset
+	return b
+}
+
+// After starts the results after the row with the given order by values (keyset pagination).
+// There must be one value per order by column; the last order by column should be unique
+// (typically the primary key) so rows aren't skipped or repeated between pages.
+// Use `Cursor` on the last row of a page to get the values for the next one.
+func (b *Builder) After(values ...interface{}) *Builder {
+	b.after = values
+	return b
+}
+
+// Cursor returns the order by values of a row, to be passed to `After` for the next page.
+func (b *Builder) Cursor(row db.DatabaseMapped) []interface{} {
+	lookup := b.columns.Lookup()
+	values := make([]interface{}, 0, len(b.orderBy))
+	for _, order := range b.orderBy {
+		if col, ok := lookup[order.Column]; ok {
+			values = append(values, col.GetValue(row))
+		}
+	}
+	return values
+}
+
+// SQL returns the statement and its arguments for a dialect.
+func (b *Builder) SQL(dialect db.Dialect) (string, []interface{}, error) {
+	w := b.writer(dialect)
+	w.WriteString("SELECT ")
+	w.WriteString(strings.Join(db.QuoteIdentifiers(dialect, b.columns.ColumnNames()), ","))
+	w.WriteString(" FROM ")
+	w.WriteString(dialect.QuoteIdentifier(b.table))
+
+	if err := b.writeWhere(w, true); err != nil {
+		return "", nil, err
+	}
+
+	if len(b.orderBy) > 0 {
+		w.WriteString(" ORDER BY ")
+		for index, order := range b.orderBy {
+			if index > 0 {
+				w.WriteString(",")
+			}
+			column, err := w.column(order.Column)
+			if err != nil {
+				return "", nil, err
+			}
+			w.WriteString(column)
+			if order.Descending {
+				w.WriteString(" DESC")
+			} else {
+				w.WriteString(" ASC")
+			}
+		}
+	}
+	if b.limit > 0 {
+		w.WriteString(" LIMIT " + strconv.Itoa(b.limit))
+	}
+	if b.offset > 0 {
+		if b.limit <= 0 && dialect.Name() == db.DialectSQLite {
+			// sqlite can't offset without a limit.
+			w.WriteString(" LIMIT -1")
+		}
+		w.WriteString(" OFFSET " + strconv.Itoa(b.offset))
+	}
+	return w.String(), w.args, nil
+}
+
+// CountSQL returns a statement counting the rows that match the filter, ignoring sorting and paging.
+func (b *Builder) CountSQL(dialect db.Dialect) (string, []interface{}, error) {
+	w := b.writer(dialect)
+	w.WriteString("SELECT count(*) FROM ")
+	w.WriteString(dialect.QuoteIdentifier(b.table))
+	if err := b.writeWhere(w, false); err != nil {
+		return "", nil, err
+	}
+	return w.String(), w.args, nil
+}
+
+// OutMany runs the query and reads the rows into a collection (ex. `*[]Guest`).
+func (b *Builder) OutMany(conn *db.Connection, collection interface{}, txs ...*sql.Tx) error {
+	statement, args, err := b.SQL(conn.Dialect())
+	if err != nil {
+		return err
+	}
+	return conn.Invoke(txs...).WithLabel(b.statementLabel("", statement)).Query(statement, args...).OutMany(collection)
+}
+
+// Out runs the query and reads the first row into an object.
+// The object is left as is if there are no rows.
+func (b *Builder) Out(conn *db.Connection, object interface{}, txs ...*sql.Tx) error {
+	statement, args, err := b.SQL(conn.Dialect())
+	if err != nil {
+		return err
+	}
+	return conn.Invoke(txs...).WithLabel(b.statementLabel("", statement)).Query(statement, args...).Out(object)
+}
+
+// Count returns the number of rows that match the filter.
+func (b *Builder) Count(conn *db.Connection, txs ...*sql.Tx) (count int, err error) {
+	statement, args, err := b.CountSQL(conn.Dialect())
+	if err != nil {
+		return
+	}
+	err = conn.Invoke(txs...).WithLabel(b.statementLabel("_count", statement)).Query(statement, args...).Scan(&count)
+	return
+}
+
+func (b *Builder) writer(dialect db.Dialect) *writer {
+	return &writer{dialect: dialect, columns: b.columns.Lookup()}
+}
+
+func (b *Builder) writeWhere(w *writer, withCursor bool) error {
+	where := b.where
+	if len(b.softDelete) > 0 {
+		switch b.deleted {
+		case excludeDeleted:
+			where = And(where, IsNull(b.softDelete))
+		case onlyDeleted:
+			where = And(where, IsNotNull(b.softDelete))
+		}
+	}
+	if withCursor && len(b.after) > 0 {
+		if len(b.after) != len(b.orderBy) {
+			return exception.New(ErrInvalidCursor).WithMessagef("order by: %d, cursor: %d", len(b.orderBy), len(b.after))
+		}
+		where = And(where, keyset(b.orderBy, b.after))
+	}
+	if isEmpty(where) {
+		return nil
+	}
+	w.WriteString(" WHERE ")
+	return where.write(w)
+}
+
+// keyset returns the condition for rows strictly after the cursor in the sort order, i.e. for `ORDER BY a, b`:
+// `a > $a OR (a = $a AND b > $b)`.
+func keyset(orderBy []Order, after []interface{}) Condition {
+	var alternatives []Condition
+	for index, order := range orderBy {
+		var all []Condition
+		for previous := 0; previous < index; previous++ {
+			all = append(all, Eq(orderBy[previous].Column, after[previous]))
+		}
+		if order.Descending {
+			all = append(all, Lt(order.Column, after[index]))
+		} else {
+			all = append(all, Gt(order.Column, after[index]))
+		}
+		alternatives = append(alternatives, And(all...))
+	}
+	return Or(alternatives...)
+}
+
+// deletedFilter is how soft deleted rows are treated.
+type deletedFilter int
+
+const (
+	excludeDeleted deletedFilter = iota
+	withDeleted
+	onlyDeleted
+)
+
+// Order is a sort column.
+type Order struct {
+	Column     string
+	Descending bool
+}
+
+// Asc sorts by a column ascending.
+func Asc(column string) Order {
+	return Order{Column: column}
+}
+
+// Desc sorts by a column descending.
+func Desc(column string) Order {
+	return Order{Column: column, Descending: true}
+}
+
+// writer accumulates a statement and its bind arguments.
+type writer struct {
+	bytes.Buffer
+	dialect db.Dialect
+	columns map[string]*db.Column
+	args    []interface{}
+}
+
+// column returns a quoted column name if the type maps it.
+func (w *writer) column(name string) (string, error) {
+	if _, ok := w.columns[name]; !ok {
+		return "", exception.New(ErrUnknownColumn).WithMessagef("column: %s", name)
+	}
+	return w.dialect.QuoteIdentifier(name), nil
+}
+
+// bind adds an argument and returns its placeholder.
+func (w *writer) bind(value interface{}) string {
+	w.args = append(w.args, value)
+	return w.dialect.Placeholder(len(w.args))
+}
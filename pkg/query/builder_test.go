@@ -0,0 +1,251 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+)
+
+type testGuest struct {
+	ID         int64      `db:"id,pk,serial"`
+	Name       string     `db:"name"`
+	Email      string     `db:"email"`
+	DeletedUTC *time.Time `db:"deleted_utc,nullable,softdelete"`
+}
+
+func (tg testGuest) TableName() string {
+	return "guest"
+}
+
+type testRSVP struct {
+	GuestID   int64 `db:"guest_id,pk"`
+	Attending bool  `db:"attending"`
+}
+
+func (tr testRSVP) TableName() string {
+	return "rsvp"
+}
+
+const (
+	selectGuest = `SELECT "id","name","email","deleted_utc" FROM "guest"`
+	selectRSVP  = `SELECT "guest_id","attending" FROM "rsvp"`
+)
+
+func TestBuilderSQL(t *testing.T) {
+	var unset *time.Time
+	testCases := []struct {
+		name    string
+		builder *Builder
+		sql     string
+		args    []interface{}
+	}{
+		{
+			name:    "soft deleted rows are excluded by default",
+			builder: Select(testGuest{}),
+			sql:     selectGuest + ` WHERE "deleted_utc" IS NULL`,
+		},
+		{
+			name:    "with deleted",
+			builder: Select(testGuest{}).WithDeleted(),
+			sql:     selectGuest,
+		},
+		{
+			name:    "only deleted",
+			builder: Select(testGuest{}).OnlyDeleted(),
+			sql:     selectGuest + ` WHERE "deleted_utc" IS NOT NULL`,
+		},
+		{
+			name:    "no soft delete column",
+			builder: Select(testRSVP{}),
+			sql:     selectRSVP,
+		},
+		{
+			name:    "eq",
+			builder: Select(testRSVP{}).Where(Eq("attending", true)),
+			sql:     selectRSVP + ` WHERE "attending" = $1`,
+			args:    []interface{}{true},
+		},
+		{
+			name:    "eq nil is null",
+			builder: Select(testRSVP{}).Where(Eq("attending", nil)),
+			sql:     selectRSVP + ` WHERE "attending" IS NULL`,
+		},
+		{
+			name:    "eq nil pointer is null",
+			builder: Select(testGuest{}).WithDeleted().Where(Eq("deleted_utc", unset)),
+			sql:     selectGuest + ` WHERE "deleted_utc" IS NULL`,
+		},
+		{
+			name:    "not eq nil is not null",
+			builder: Select(testRSVP{}).Where(NotEq("attending", nil)),
+			sql:     selectRSVP + ` WHERE "attending" IS NOT NULL`,
+		},
+		{
+			name:    "comparisons",
+			builder: Select(testRSVP{}).Where(And(Gt("guest_id", 1), Lte("guest_id", 9), NotEq("attending", false))),
+			sql:     selectRSVP + ` WHERE ("guest_id" > $1 AND "guest_id" <= $2 AND "attending" <> $3)`,
+			args:    []interface{}{1, 9, false},
+		},
+		{
+			name:    "nil conditions are skipped",
+			builder: Select(testRSVP{}).Where(And(nil, If(false, Eq("attending", true)), Or(nil))),
+			sql:     selectRSVP,
+		},
+		{
+			name:    "a single condition isn't grouped",
+			builder: Select(testRSVP{}).Where(And(nil, Gte("guest_id", 3))),
+			sql:     selectRSVP + ` WHERE "guest_id" >= $1`,
+			args:    []interface{}{3},
+		},
+		{
+			name:    "or with a nil condition matches everything",
+			builder: Select(testRSVP{}).Where(Or(nil, Gte("guest_id", 3))),
+			sql:     selectRSVP,
+		},
+		{
+			name:    "or with an empty group matches everything",
+			builder: Select(testRSVP{}).Where(And(Eq("attending", true), Or(Gte("guest_id", 3), And(nil)))),
+			sql:     selectRSVP + ` WHERE "attending" = $1`,
+			args:    []interface{}{true},
+		},
+		{
+			name:    "or of an or with a nil condition matches everything",
+			builder: Select(testRSVP{}).Where(Or(Eq("attending", true), Or(nil, Gte("guest_id", 3)))),
+			sql:     selectRSVP,
+		},
+		{
+			name:    "filters are added to the soft delete filter",
+			builder: Select(testGuest{}).Where(Or(Eq("name", "a"), Eq("name", "b"))),
+			sql:     selectGuest + ` WHERE (("name" = $1 OR "name" = $2) AND "deleted_utc" IS NULL)`,
+			args:    []interface{}{"a", "b"},
+		},
+		{
+			name:    "contains escapes wildcards",
+			builder: Select(testGuest{}).WithDeleted().Where(Contains("email", `50%_Off\`)),
+			sql:     selectGuest + ` WHERE lower("email") LIKE $1 ESCAPE '\'`,
+			args:    []interface{}{`%50\%\_off\\%`},
+		},
+		{
+			name:    "in",
+			builder: Select(testRSVP{}).Where(In("guest_id", 1, 2, 3)),
+			sql:     selectRSVP + ` WHERE "guest_id" IN ($1,$2,$3)`,
+			args:    []interface{}{1, 2, 3},
+		},
+		{
+			name:    "empty in matches nothing",
+			builder: Select(testRSVP{}).Where(In("guest_id")),
+			sql:     selectRSVP + ` WHERE 1 = 0`,
+		},
+		{
+			name:    "not",
+			builder: Select(testRSVP{}).Where(Not(Like("guest_id", "1%"))),
+			sql:     selectRSVP + ` WHERE NOT ("guest_id" LIKE $1)`,
+			args:    []interface{}{"1%"},
+		},
+		{
+			name:    "not nil matches nothing",
+			builder: Select(testRSVP{}).Where(Not(nil)),
+			sql:     selectRSVP + ` WHERE 1 = 0`,
+		},
+		{
+			name:    "not of an empty group matches nothing",
+			builder: Select(testRSVP{}).Where(Or(Not(And(nil, If(false, Eq("attending", true)))), Eq("guest_id", 2))),
+			sql:     selectRSVP + ` WHERE (1 = 0 OR "guest_id" = $1)`,
+			args:    []interface{}{2},
+		},
+		{
+			name:    "not of an or with a nil condition matches nothing",
+			builder: Select(testGuest{}).Where(Not(Or(nil, Eq("name", "a")))),
+			sql:     selectGuest + ` WHERE (1 = 0 AND "deleted_utc" IS NULL)`,
+		},
+		{
+			name:    "order, limit and offset",
+			builder: Select(testRSVP{}).OrderBy(Desc("attending"), Asc("guest_id")).Limit(10).Offset(20),
+			sql:     selectRSVP + ` ORDER BY "attending" DESC,"guest_id" ASC LIMIT 10 OFFSET 20`,
+		},
+		{
+			name:    "keyset after one column",
+			builder: Select(testRSVP{}).OrderBy(Asc("guest_id")).After(5).Limit(2),
+			sql:     selectRSVP + ` WHERE "guest_id" > $1 ORDER BY "guest_id" ASC LIMIT 2`,
+			args:    []interface{}{5},
+		},
+		{
+			name:    "keyset after two columns",
+			builder: Select(testGuest{}).OrderBy(Desc("name"), Asc("id")).After("m", 7),
+			sql: selectGuest + ` WHERE ("deleted_utc" IS NULL AND ("name" < $1 OR ("name" = $2 AND "id" > $3)))` +
+				` ORDER BY "name" DESC,"id" ASC`,
+			args: []interface{}{"m", "m", 7},
+		},
+	}
+
+	for _, tc := range testCases {
+		statement, args, err := tc.builder.SQL(db.Postgres{})
+		if err != nil {
+			t.Errorf("%s: %+v", tc.name, err)
+			continue
+		}
+		if statement != tc.sql {
+			t.Errorf("%s: sql\ngot:  %s\nwant: %s", tc.name, statement, tc.sql)
+		}
+		if len(args) != 0 || len(tc.args) != 0 {
+			if !reflect.DeepEqual(args, tc.args) {
+				t.Errorf("%s: args\ngot:  %#v\nwant: %#v", tc.name, args, tc.args)
+			}
+		}
+	}
+}
+
+func TestBuilderSQLite(t *testing.T) {
+	statement, args, err := Select(testRSVP{}).Where(Eq("attending", true)).Offset(5).SQL(db.SQLite{})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if want := selectRSVP + ` WHERE "attending" = ?1 LIMIT -1 OFFSET 5`; statement != want {
+		t.Fatalf("sql\ngot:  %s\nwant: %s", statement, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{true}) {
+		t.Fatalf("args: %#v", args)
+	}
+}
+
+func TestBuilderCountSQL(t *testing.T) {
+	statement, args, err := Select(testGuest{}).Where(Eq("name", "a")).OrderBy(Asc("id")).After(3).Limit(1).CountSQL(db.Postgres{})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if want := `SELECT count(*) FROM "guest" WHERE ("name" = $1 AND "deleted_utc" IS NULL)`; statement != want {
+		t.Fatalf("sql\ngot:  %s\nwant: %s", statement, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"a"}) {
+		t.Fatalf("args: %#v", args)
+	}
+}
+
+func TestBuilderSQLErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		builder *Builder
+		class   exception.Class
+	}{
+		{"unknown filter column", Select(testGuest{}).Where(Eq("password", "x")), ErrUnknownColumn},
+		{"unknown nested column", Select(testGuest{}).Where(Or(Eq("name", "x"), Not(IsNull("1=1 OR name")))), ErrUnknownColumn},
+		{"unknown sort column", Select(testGuest{}).OrderBy(Asc("name DESC; --")), ErrUnknownColumn},
+		{"cursor without a value per sort column", Select(testGuest{}).OrderBy(Asc("name"), Asc("id")).After("m"), ErrInvalidCursor},
+	}
+	for _, tc := range testCases {
+		if _, _, err := tc.builder.SQL(db.Postgres{}); !exception.Is(err, tc.class) {
+			t.Errorf("%s: want %v, got %v", tc.name, tc.class, err)
+		}
+	}
+}
+
+func TestBuilderCursor(t *testing.T) {
+	b := Select(testGuest{}).OrderBy(Desc("name"), Asc("id"), Asc("unknown"))
+	cursor := b.Cursor(testGuest{ID: 7, Name: "m"})
+	if !reflect.DeepEqual(cursor, []interface{}{"m", int64(7)}) {
+		t.Fatalf("cursor: %#v", cursor)
+	}
+}
@@ -0,0 +1,260 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Condition is a filter expression.
+// A nil condition matches everything, so optional filters can be passed straight through.
+type Condition interface {
+	write(w *writer) error
+}
+
+// Eq matches rows where a column equals a value; a nil value matches rows where the column is null.
+func Eq(column string, value interface{}) Condition {
+	if isNil(value) {
+		return IsNull(column)
+	}
+	return compare{column: column, op: "=", value: value}
+}
+
+// NotEq matches rows where a column doesn't equal a value; a nil value matches rows where the
+// column isn't null.
+func NotEq(column string, value interface{}) Condition {
+	if isNil(value) {
+		return IsNotNull(column)
+	}
+	return compare{column: column, op: "<>", value: value}
+}
+
+// Lt matches rows where a column is less than a value.
+func Lt(column string, value interface{}) Condition {
+	return compare{column: column, op: "<", value: value}
+}
+
+// Lte matches rows where a column is less than or equal to a value.
+func Lte(column string, value interface{}) Condition {
+	return compare{column: column, op: "<=", value: value}
+}
+
+// Gt matches rows where a column is greater than a value.
+func Gt(column string, value interface{}) Condition {
+	return compare{column: column, op: ">", value: value}
+}
+
+// Gte matches rows where a column is greater than or equal to a value.
+func Gte(column string, value interface{}) Condition {
+	return compare{column: column, op: ">=", value: value}
+}
+
+// Like matches rows where a column matches a sql `LIKE` pattern.
+func Like(column string, pattern string) Condition {
+	return compare{column: column, op: "LIKE", value: pattern}
+}
+
+// Contains matches rows where a text column contains a string, ignoring case.
+// Wildcards in the search text are matched literally.
+func Contains(column string, text string) Condition {
+	return contains{column: column, text: text}
+}
+
+// In matches rows where a column is one of a set of values.
+// An empty set matches nothing.
+func In(column string, values ...interface{}) Condition {
+	return in{column: column, values: values}
+}
+
+// IsNull matches rows where a column is null.
+func IsNull(column string) Condition {
+	return null{column: column}
+}
+
+// IsNotNull matches rows where a column isn't null.
+func IsNotNull(column string) Condition {
+	return null{column: column, not: true}
+}
+
+// And matches rows that match all of the conditions; nil conditions are skipped.
+func And(conditions ...Condition) Condition {
+	return group{op: opAnd, conditions: conditions}
+}
+
+// Or matches rows that match any of the conditions; a nil condition matches everything, so
+// the group does too.
+func Or(conditions ...Condition) Condition {
+	return group{op: opOr, conditions: conditions}
+}
+
+// Not matches rows that don't match a condition; the negation of a nil condition matches nothing.
+func Not(condition Condition) Condition {
+	return not{condition: condition}
+}
+
+// If returns the condition if ok is true, and nil (match everything) otherwise.
+// It's for optional filters, ex. `query.If(len(search) > 0, query.Contains("name", search))`.
+func If(ok bool, condition Condition) Condition {
+	if ok {
+		return condition
+	}
+	return nil
+}
+
+// isEmpty returns if a condition doesn't filter anything.
+func isEmpty(condition Condition) bool {
+	switch typed := condition.(type) {
+	case nil:
+		return true
+	case group:
+		if typed.op == opOr {
+			for _, child := range typed.conditions {
+				if isEmpty(child) {
+					return true
+				}
+			}
+			return len(typed.conditions) == 0
+		}
+		for _, child := range typed.conditions {
+			if !isEmpty(child) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// isNil returns if a value is nil or a nil pointer (ex. an unset `*time.Time`), which sql
+// compares as null, so `= NULL` would never match.
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	reflected := reflect.ValueOf(value)
+	return reflected.Kind() == reflect.Ptr && reflected.IsNil()
+}
+
+type compare struct {
+	column string
+	op     string
+	value  interface{}
+}
+
+func (c compare) write(w *writer) error {
+	column, err := w.column(c.column)
+	if err != nil {
+		return err
+	}
+	w.WriteString(column + " " + c.op + " " + w.bind(c.value))
+	return nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+type contains struct {
+	column string
+	text   string
+}
+
+func (c contains) write(w *writer) error {
+	column, err := w.column(c.column)
+	if err != nil {
+		return err
+	}
+	pattern := "%" + likeEscaper.Replace(strings.ToLower(c.text)) + "%"
+	w.WriteString("lower(" + column + ") LIKE " + w.bind(pattern) + ` ESCAPE '\'`)
+	return nil
+}
+
+type in struct {
+	column string
+	values []interface{}
+}
+
+func (i in) write(w *writer) error {
+	column, err := w.column(i.column)
+	if err != nil {
+		return err
+	}
+	if len(i.values) == 0 {
+		w.WriteString("1 = 0")
+		return nil
+	}
+	w.WriteString(column + " IN (")
+	for index, value := range i.values {
+		if index > 0 {
+			w.WriteString(",")
+		}
+		w.WriteString(w.bind(value))
+	}
+	w.WriteString(")")
+	return nil
+}
+
+type null struct {
+	column string
+	not    bool
+}
+
+func (n null) write(w *writer) error {
+	column, err := w.column(n.column)
+	if err != nil {
+		return err
+	}
+	if n.not {
+		w.WriteString(column + " IS NOT NULL")
+	} else {
+		w.WriteString(column + " IS NULL")
+	}
+	return nil
+}
+
+const (
+	opAnd = " AND "
+	opOr  = " OR "
+)
+
+type group struct {
+	op         string
+	conditions []Condition
+}
+
+func (g group) write(w *writer) error {
+	var children []Condition
+	for _, condition := range g.conditions {
+		if !isEmpty(condition) {
+			children = append(children, condition)
+		}
+	}
+	if len(children) == 1 {
+		return children[0].write(w)
+	}
+	w.WriteString("(")
+	for index, child := range children {
+		if index > 0 {
+			w.WriteString(g.op)
+		}
+		if err := child.write(w); err != nil {
+			return err
+		}
+	}
+	w.WriteString(")")
+	return nil
+}
+
+type not struct {
+	condition Condition
+}
+
+func (n not) write(w *writer) error {
+	if isEmpty(n.condition) {
+		w.WriteString("1 = 0")
+		return nil
+	}
+	w.WriteString("NOT (")
+	if err := n.condition.write(w); err != nil {
+		return err
+	}
+	w.WriteString(")")
+	return nil
+}
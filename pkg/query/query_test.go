@@ -0,0 +1,132 @@
+package query_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/query"
+	"github.com/wcharczuk/katwillmarry.com/pkg/schema/schematest"
+)
+
+// seedGuests creates a household with guests named in order, and soft deletes the last one.
+func seedGuests(t *testing.T, conn *db.Connection, names ...string) []model.Guest {
+	t.Helper()
+	now := time.Now().UTC()
+	household := model.Household{Name: "The Smiths", CreatedUTC: now, UpdatedUTC: now}
+	if err := conn.Invoke().Create(&household); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	guests := make([]model.Guest, len(names))
+	for index, name := range names {
+		guests[index] = model.Guest{HouseholdID: household.ID, Name: name, CreatedUTC: now, UpdatedUTC: now}
+		if index == len(names)-1 {
+			guests[index].DeletedUTC = &now
+		}
+		if err := conn.Invoke().Create(&guests[index]); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+	return guests
+}
+
+func names(guests []model.Guest) (output []string) {
+	for _, guest := range guests {
+		output = append(output, guest.Name)
+	}
+	return
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for index := range a {
+		if a[index] != b[index] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBuilderOutMany(t *testing.T) {
+	conn := schematest.Open(t)
+	seedGuests(t, conn, "Alice", "Bob", "Carol", "Dave", "Erin")
+
+	testCases := []struct {
+		name    string
+		builder *query.Builder
+		want    []string
+	}{
+		{"all", query.Select(model.Guest{}).OrderBy(query.Asc("id")), []string{"Alice", "Bob", "Carol", "Dave"}},
+		{"with deleted", query.Select(model.Guest{}).WithDeleted().OrderBy(query.Asc("id")), []string{"Alice", "Bob", "Carol", "Dave", "Erin"}},
+		{"only deleted", query.Select(model.Guest{}).OnlyDeleted(), []string{"Erin"}},
+		{"contains", query.Select(model.Guest{}).Where(query.Contains("name", "A")).OrderBy(query.Asc("name")), []string{"Alice", "Carol", "Dave"}},
+		{"in", query.Select(model.Guest{}).Where(query.In("name", "Bob", "Dave", "Erin")).OrderBy(query.Desc("name")), []string{"Dave", "Bob"}},
+		{"empty in", query.Select(model.Guest{}).Where(query.In("name")), nil},
+		{"or", query.Select(model.Guest{}).Where(query.Or(query.Eq("name", "Alice"), query.Eq("name", "Carol"))).OrderBy(query.Asc("id")), []string{"Alice", "Carol"}},
+		{"not", query.Select(model.Guest{}).Where(query.Not(query.Like("name", "%o%"))).OrderBy(query.Asc("id")), []string{"Alice", "Dave"}},
+		{"eq nil", query.Select(model.Guest{}).Where(query.Eq("sms_opt_out_utc", nil)).OrderBy(query.Asc("id")).Limit(2), []string{"Alice", "Bob"}},
+		{"offset", query.Select(model.Guest{}).OrderBy(query.Asc("id")).Offset(3), []string{"Dave"}},
+	}
+	for _, tc := range testCases {
+		var guests []model.Guest
+		if err := tc.builder.OutMany(conn, &guests); err != nil {
+			t.Fatalf("%s: %+v", tc.name, err)
+		}
+		if got := names(guests); !equal(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestBuilderKeysetPages(t *testing.T) {
+	conn := schematest.Open(t)
+	seedGuests(t, conn, "Carol", "Alice", "Bob", "Alice", "Erin")
+
+	var pages [][]string
+	var after []interface{}
+	for len(pages) < 10 {
+		b := query.Select(model.Guest{}).OrderBy(query.Asc("name"), query.Asc("id")).Limit(2)
+		if after != nil {
+			b = b.After(after...)
+		}
+		var guests []model.Guest
+		if err := b.OutMany(conn, &guests); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if len(guests) == 0 {
+			break
+		}
+		pages = append(pages, names(guests))
+		after = b.Cursor(guests[len(guests)-1])
+	}
+	if len(pages) != 2 || !equal(pages[0], []string{"Alice", "Alice"}) || !equal(pages[1], []string{"Bob", "Carol"}) {
+		t.Fatalf("pages: %v", pages)
+	}
+}
+
+func TestBuilderCount(t *testing.T) {
+	conn := schematest.Open(t)
+	seedGuests(t, conn, "Alice", "Bob", "Carol")
+
+	count, err := query.Select(model.Guest{}).Where(query.Gt("id", 1)).OrderBy(query.Asc("id")).Limit(1).Count(conn)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if count != 1 {
+		t.Fatalf("count should ignore paging and deleted rows, got %d", count)
+	}
+}
+
+func TestBuilderUnknownColumn(t *testing.T) {
+	conn := schematest.Open(t)
+	var guests []model.Guest
+	err := query.Select(model.Guest{}).OrderBy(query.Asc("name; DROP TABLE guest")).OutMany(conn, &guests)
+	if !exception.Is(err, query.ErrUnknownColumn) {
+		t.Fatalf("want an unknown column error, got %v", err)
+	}
+}
@@ -0,0 +1,8 @@
+package rbac
+
+// Config is the admin roles config.
+type Config struct {
+	// Owners are user ids that always have the owner role, so there's someone who can give
+	// out the other roles.
+	Owners []string `json:"owners,omitempty" yaml:"owners,omitempty" env:"ADMIN_OWNERS,csv"`
+}
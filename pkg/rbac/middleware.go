@@ -0,0 +1,17 @@
+package rbac
+
+import "github.com/blend/go-sdk/web"
+
+// RequireRole returns middleware that requires a session with any of the roles.
+// Someone logged in without one of them is shown not authorized; someone logged out is sent to
+// log in.
+func RequireRole(roles ...string) web.Middleware {
+	return func(action web.Action) web.Action {
+		return web.SessionRequired(func(ctx *web.Ctx) web.Result {
+			if !ctx.Session().HasRole(roles...) {
+				return ctx.View().NotAuthorized()
+			}
+			return action(ctx)
+		})
+	}
+}
@@ -0,0 +1,179 @@
+// Package rbac has the roles admin users are given, and what each role is allowed to do.
+//
+// Roles are stored in the database by user id and attached to the session each time it's
+// verified, so a change to someone's roles applies to their next request. User ids are emails,
+// and are compared and stored lowercased (see `NormalizeUserID`).
+package rbac
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/audit"
+	"github.com/wcharczuk/katwillmarry.com/pkg/query"
+)
+
+const (
+	// ErrUnknownRole is returned when assigning a role that isn't one of `Roles`.
+	ErrUnknownRole exception.Class = "rbac: unknown role"
+	// ErrAssignmentNotFound is returned when removing a role a user doesn't have.
+	ErrAssignmentNotFound exception.Class = "rbac: not found"
+
+	// Owner can do everything, including giving out roles, api tokens and revoking sessions.
+	Owner = "owner"
+	// Planner runs the guest list: jobs, reminders and every report.
+	Planner = "planner"
+	// Caterer can read the meal counts and dietary notes, and nothing else.
+	Caterer = "caterer"
+	// Photographer can read the guest list, without contact details.
+	Photographer = "photographer"
+)
+
+// Roles are the roles, most to least access.
+var Roles = []string{Owner, Planner, Caterer, Photographer}
+
+// IsRole returns if a value is one of `Roles`.
+func IsRole(value string) bool {
+	for _, role := range Roles {
+		if role == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Assignment is a role given to a user.
+type Assignment struct {
+	UserID     string    `db:"user_id,pk" json:"userID"`
+	Role       string    `db:"role,pk" json:"role"`
+	CreatedBy  string    `db:"created_by" json:"createdBy"`
+	CreatedUTC time.Time `db:"created_utc" json:"createdUTC"`
+}
+
+// TableName returns the mapped table name.
+func (a Assignment) TableName() string {
+	return "admin_role"
+}
+
+func init() {
+	audit.Register(Assignment{})
+}
+
+// NewStore returns a new role store.
+func NewStore(conn *db.Connection) *Store {
+	return &Store{conn: conn}
+}
+
+// NewStoreFromConfig returns a new role store from a config.
+func NewStoreFromConfig(conn *db.Connection, cfg *Config) *Store {
+	return NewStore(conn).WithOwners(cfg.Owners...)
+}
+
+// Store reads and changes the roles given to users.
+type Store struct {
+	conn   *db.Connection
+	owners []string
+}
+
+// WithOwners sets the user ids that always have the owner role.
+func (s *Store) WithOwners(userIDs ...string) *Store {
+	s.owners = userIDs
+	return s
+}
+
+// Attach sets the store as the auth manager's validate handler, which sets the roles of each
+// session as it's verified.
+func (s *Store) Attach(am *web.AuthManager) *web.AuthManager {
+	return am.WithValidateHandler(s.Validate)
+}
+
+// Validate sets a session's roles; it is an auth manager validate handler.
+func (s *Store) Validate(session *web.Session, state web.State) error {
+	roles, err := s.RolesOf(session.UserID, web.TxFromState(state))
+	if err != nil {
+		return err
+	}
+	session.Roles = roles
+	return nil
+}
+
+// NormalizeUserID returns a user id as it's stored: trimmed and lowercased, since the email an
+// identity provider returns may not be cased the way it was typed.
+func NormalizeUserID(userID string) string {
+	return strings.ToLower(strings.TrimSpace(userID))
+}
+
+// RolesOf returns the roles of a user, in the order of `Roles`.
+func (s *Store) RolesOf(userID string, txs ...*sql.Tx) ([]string, error) {
+	userID = NormalizeUserID(userID)
+	var assignments []Assignment
+	if err := query.Select(Assignment{}).Where(query.Eq("user_id", userID)).OutMany(s.conn, &assignments, txs...); err != nil {
+		return nil, err
+	}
+	has := map[string]bool{}
+	for _, assignment := range assignments {
+		has[assignment.Role] = true
+	}
+	if s.isOwner(userID) {
+		has[Owner] = true
+	}
+	var roles []string
+	for _, role := range Roles {
+		if has[role] {
+			roles = append(roles, role)
+		}
+	}
+	return roles, nil
+}
+
+// All returns the roles given to users, by user id.
+func (s *Store) All(txs ...*sql.Tx) ([]Assignment, error) {
+	var assignments []Assignment
+	err := query.Select(Assignment{}).OrderBy(query.Asc("user_id"), query.Asc("role")).OutMany(s.conn, &assignments, txs...)
+	return assignments, err
+}
+
+// Assign gives a user a role on behalf of a principal.
+func (s *Store) Assign(principal, userID, role string, txs ...*sql.Tx) error {
+	if !IsRole(role) {
+		return exception.New(ErrUnknownRole).WithMessagef("role: %s", role)
+	}
+	return audit.Invoke(s.conn, principal, txs...).Upsert(&Assignment{
+		UserID:     NormalizeUserID(userID),
+		Role:       role,
+		CreatedBy:  principal,
+		CreatedUTC: time.Now().UTC(),
+	})
+}
+
+// Unassign takes a role from a user on behalf of a principal.
+// Owners from the config keep the owner role; they're not stored.
+func (s *Store) Unassign(principal, userID, role string, txs ...*sql.Tx) error {
+	userID = NormalizeUserID(userID)
+	var assignments []Assignment
+	err := query.Select(Assignment{}).
+		Where(query.Eq("user_id", userID)).
+		And(query.Eq("role", role)).
+		OutMany(s.conn, &assignments, txs...)
+	if err != nil {
+		return err
+	}
+	if len(assignments) == 0 {
+		return exception.New(ErrAssignmentNotFound).WithMessagef("user: %s, role: %s", userID, role)
+	}
+	return audit.Invoke(s.conn, principal, txs...).Delete(&assignments[0])
+}
+
+func (s *Store) isOwner(userID string) bool {
+	for _, owner := range s.owners {
+		if NormalizeUserID(owner) == userID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package rbac_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/rbac"
+	"github.com/wcharczuk/katwillmarry.com/pkg/schema/schematest"
+)
+
+func TestUserIDsIgnoreCase(t *testing.T) {
+	conn := schematest.Open(t)
+	store := rbac.NewStore(conn).WithOwners(" Owner@Example.com")
+
+	if err := store.Assign("owner@example.com", " Alice@Example.com ", rbac.Planner); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := store.Assign("owner@example.com", "alice@example.com", rbac.Caterer); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	roles, err := store.RolesOf("ALICE@example.com")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if fmt.Sprint(roles) != "[planner caterer]" {
+		t.Fatalf("roles should be found whatever the case of the user id, got %v", roles)
+	}
+	assignments, err := store.All()
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	for _, assignment := range assignments {
+		if assignment.UserID != "alice@example.com" {
+			t.Fatalf("user ids should be stored lowercased, got %q", assignment.UserID)
+		}
+	}
+
+	if err := store.Unassign("owner@example.com", "Alice@example.com", rbac.Planner); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if roles, err = store.RolesOf("alice@example.com"); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if fmt.Sprint(roles) != "[caterer]" {
+		t.Fatalf("unassigning should ignore the case of the user id, got %v", roles)
+	}
+
+	if roles, err = store.RolesOf("owner@EXAMPLE.com"); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if fmt.Sprint(roles) != "[owner]" {
+		t.Fatalf("configured owners should ignore case, got %v", roles)
+	}
+}
@@ -0,0 +1,60 @@
+package reminder
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/util"
+)
+
+const (
+	// DefaultDays are the default days before the deadline that reminders are sent.
+	DefaultDays = "21,7,2"
+	// DefaultInterval is the default time between checking for due reminders.
+	DefaultInterval = time.Hour
+
+	// DeadlineFormat is the format of the rsvp deadline.
+	DeadlineFormat = "2006-01-02"
+)
+
+// Config is the rsvp reminder config.
+type Config struct {
+	// Deadline is the last day to rsvp, as `2006-01-02` (utc). Reminders are off if it's unset.
+	Deadline string `json:"deadline,omitempty" yaml:"deadline,omitempty" env:"RSVP_DEADLINE"`
+	// Days are the comma separated days before the deadline to remind households that haven't responded.
+	Days string `json:"days,omitempty" yaml:"days,omitempty" env:"REMINDER_DAYS"`
+	// Interval is the time between checking for due reminders.
+	Interval time.Duration `json:"interval,omitempty" yaml:"interval,omitempty" env:"REMINDER_INTERVAL"`
+}
+
+// GetDeadline returns the deadline, or a zero time if it's unset.
+func (c Config) GetDeadline() (time.Time, error) {
+	if len(c.Deadline) == 0 {
+		return time.Time{}, nil
+	}
+	deadline, err := time.Parse(DeadlineFormat, c.Deadline)
+	if err != nil {
+		return time.Time{}, exception.New(err).WithMessagef("rsvp deadline: %s", c.Deadline)
+	}
+	return deadline, nil
+}
+
+// GetRules returns the reminder rules, furthest from the deadline first.
+func (c Config) GetRules() ([]Rule, error) {
+	var rules []Rule
+	for _, value := range strings.Split(util.Coalesce.String(c.Days, DefaultDays), ",") {
+		days, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || days < 0 {
+			return nil, exception.New("invalid reminder days").WithMessagef("days: %s", c.Days)
+		}
+		rules = append(rules, Rule{Days: days})
+	}
+	return sortRules(rules), nil
+}
+
+// GetInterval returns a property or a default.
+func (c Config) GetInterval(inherited ...time.Duration) time.Duration {
+	return util.Coalesce.Duration(c.Interval, DefaultInterval, inherited...)
+}
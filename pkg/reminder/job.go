@@ -0,0 +1,107 @@
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/job"
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/notify"
+	"github.com/wcharczuk/katwillmarry.com/pkg/query"
+)
+
+const (
+	// Kind is the job kind for rsvp reminders.
+	Kind = "rsvp_reminder"
+)
+
+// Args are the rsvp reminder job args.
+type Args struct {
+	HouseholdID int64 `json:"householdID"`
+	Days        int   `json:"days"`
+}
+
+// Kind implements job.Args.
+func (a Args) Kind() string {
+	return Kind
+}
+
+// Handler returns the rsvp reminder job handler, which sends the reminder to each guest in the
+// household over a channel (usually a `notify.Router`, so guests get it the way they asked to).
+// Households that responded after the reminder was queued are skipped.
+//
+// Each guest is sent the reminder one channel at a time and every delivery is recorded, so when
+// the job is retried only the guests and channels that failed are sent it again. Channels the
+// router doesn't have configured (ex. `sms` without twilio) are skipped rather than failing the job.
+func Handler(conn *db.Connection, channel notify.Channel) job.Handler {
+	return job.HandlerFunc(func(ctx context.Context, j *job.Job) error {
+		var args Args
+		if err := j.Decode(&args); err != nil {
+			return err
+		}
+		var household model.Household
+		if err := conn.Invoke().Get(&household, args.HouseholdID); err != nil {
+			return err
+		}
+		if household.ID == 0 {
+			return nil
+		}
+		responded, err := query.Select(model.RSVP{}).Where(query.Eq("household_id", household.ID)).Count(conn)
+		if err != nil {
+			return err
+		}
+		if responded > 0 {
+			return nil
+		}
+		guests, err := household.Guests(conn)
+		if err != nil {
+			return err
+		}
+		rule := Rule{Days: args.Days}
+		delivered, err := deliveredFor(conn, household.ID, rule)
+		if err != nil {
+			return err
+		}
+
+		message := Message(household, rule)
+		var errs []error
+		for _, guest := range guests {
+			recipient := guest.Recipient()
+			for _, name := range recipient.Channels {
+				delivery := Delivery{HouseholdID: household.ID, Days: rule.Days, GuestID: guest.ID, Channel: name}
+				if delivered[delivery] {
+					continue
+				}
+				to := recipient
+				to.Channels = []string{name}
+				if err := channel.Send(ctx, to, message); exception.Is(err, notify.ErrNoChannel) {
+					continue
+				} else if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				delivery.DeliveredUTC = time.Now().UTC()
+				if err := conn.Invoke().Create(&delivery); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+		return exception.Nest(errs...)
+	})
+}
+
+// Message returns the reminder message for a household.
+func Message(household model.Household, rule Rule) notify.Message {
+	body := fmt.Sprintf("Hi %s,\n\nWe haven't heard back from you yet! Please rsvp in the next %s.\n\nKat & Will", household.Name, rule)
+	if rule.Days == 0 {
+		body = fmt.Sprintf("Hi %s,\n\nToday is the last day to rsvp, we'd love to hear from you!\n\nKat & Will", household.Name)
+	}
+	return notify.Message{
+		Subject: "Please RSVP",
+		Body:    body,
+	}
+}
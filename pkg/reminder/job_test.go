@@ -0,0 +1,90 @@
+package reminder_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/exception"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/job"
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/notify"
+	"github.com/wcharczuk/katwillmarry.com/pkg/pii"
+	"github.com/wcharczuk/katwillmarry.com/pkg/reminder"
+	"github.com/wcharczuk/katwillmarry.com/pkg/schema/schematest"
+)
+
+// flakyEmail records who it sent to, and fails for the addresses in `fail`.
+type flakyEmail struct {
+	sent []string
+	fail map[string]bool
+}
+
+func (f *flakyEmail) Name() string { return notify.ChannelEmail }
+
+func (f *flakyEmail) Send(ctx context.Context, to notify.Recipient, message notify.Message) error {
+	if f.fail[to.Email] {
+		return exception.New("email: send failed").WithMessagef("to: %s", to.Email)
+	}
+	f.sent = append(f.sent, to.Email)
+	return nil
+}
+
+func TestHandlerRetriesOnlyFailedDeliveries(t *testing.T) {
+	conn := schematest.Open(t)
+	keyring := pii.NewKeyring()
+	if err := keyring.AddKey("k1", bytes.Repeat([]byte{1}, 32)); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := keyring.SetPrimary("k1"); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	conn.WithEncryptor(keyring)
+	now := time.Now().UTC()
+
+	household := model.Household{Name: "The Smiths", CreatedUTC: now, UpdatedUTC: now}
+	if err := conn.Invoke().Create(&household); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	for _, guest := range []model.Guest{
+		{HouseholdID: household.ID, Name: "Alice", Email: "alice@example.com", CreatedUTC: now, UpdatedUTC: now},
+		{HouseholdID: household.ID, Name: "Bob", Email: "bob@example.com", CreatedUTC: now, UpdatedUTC: now},
+		// twilio isn't configured, so carol's text is skipped rather than failing the job.
+		{HouseholdID: household.ID, Name: "Carol", Phone: "+15555550100", NotifyBy: model.NotifyBySMS, CreatedUTC: now, UpdatedUTC: now},
+	} {
+		if err := conn.Invoke().Create(&guest); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+
+	email := &flakyEmail{fail: map[string]bool{"bob@example.com": true}}
+	handler := reminder.Handler(conn, notify.NewRouter().WithChannel(notify.ChannelEmail, email))
+	j, err := job.New(reminder.Args{HouseholdID: household.ID, Days: 7})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if err := handler.Handle(context.Background(), j); err == nil {
+		t.Fatal("the job should fail while a guest's delivery fails")
+	}
+	if len(email.sent) != 1 || email.sent[0] != "alice@example.com" {
+		t.Fatalf("first attempt sent to %v", email.sent)
+	}
+
+	email.fail = nil
+	if err := handler.Handle(context.Background(), j); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(email.sent) != 2 || email.sent[1] != "bob@example.com" {
+		t.Fatalf("the retry should only send to bob, sent to %v", email.sent)
+	}
+
+	if err := handler.Handle(context.Background(), j); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(email.sent) != 2 {
+		t.Fatalf("a finished reminder shouldn't be sent again, sent to %v", email.sent)
+	}
+}
@@ -0,0 +1,55 @@
+package reminder
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Rule reminds households that haven't responded a number of days before the deadline.
+type Rule struct {
+	Days int
+}
+
+// String returns the rule name, ex. `7 days`.
+func (r Rule) String() string {
+	if r.Days == 1 {
+		return "1 day"
+	}
+	return strconv.Itoa(r.Days) + " days"
+}
+
+// SendAfter returns when the rule's reminders start going out.
+func (r Rule) SendAfter(deadline time.Time) time.Time {
+	return deadline.AddDate(0, 0, -r.Days)
+}
+
+// Due returns the rule whose reminders should go out at a time, or nil if there isn't one.
+//
+// Only the latest rule that has started is due: a household added five days out
+// gets the 7 day reminder now and the 2 day reminder when it comes, not a late 21 day reminder too.
+// Nothing is due once the deadline day is over.
+func Due(rules []Rule, deadline, now time.Time) *Rule {
+	if deadline.IsZero() || !now.Before(deadline.AddDate(0, 0, 1)) {
+		return nil
+	}
+	var due *Rule
+	for index := range rules {
+		if !now.Before(rules[index].SendAfter(deadline)) && (due == nil || rules[index].Days < due.Days) {
+			due = &rules[index]
+		}
+	}
+	return due
+}
+
+// sortRules sorts rules furthest from the deadline first, dropping duplicates.
+func sortRules(rules []Rule) []Rule {
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Days > rules[j].Days })
+	var output []Rule
+	for _, rule := range rules {
+		if len(output) == 0 || output[len(output)-1].Days != rule.Days {
+			output = append(output, rule)
+		}
+	}
+	return output
+}
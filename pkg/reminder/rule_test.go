@@ -0,0 +1,47 @@
+package reminder_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/reminder"
+)
+
+func TestDue(t *testing.T) {
+	deadline := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	rules := []reminder.Rule{{Days: 21}, {Days: 7}, {Days: 2}}
+	day := 24 * time.Hour
+
+	testCases := []struct {
+		name     string
+		rules    []reminder.Rule
+		deadline time.Time
+		now      time.Time
+		expected int
+	}{
+		{name: "before the first rule", rules: rules, deadline: deadline, now: deadline.Add(-21*day - time.Nanosecond)},
+		{name: "the first rule starts", rules: rules, deadline: deadline, now: deadline.Add(-21 * day), expected: 21},
+		{name: "between rules", rules: rules, deadline: deadline, now: deadline.Add(-8 * day), expected: 21},
+		{name: "late added household gets only the latest rule", rules: rules, deadline: deadline, now: deadline.Add(-5 * day), expected: 7},
+		{name: "the last rule starts", rules: rules, deadline: deadline, now: deadline.Add(-2 * day), expected: 2},
+		{name: "the deadline day", rules: rules, deadline: deadline, now: deadline, expected: 2},
+		{name: "the end of the deadline day", rules: rules, deadline: deadline, now: deadline.Add(day - time.Nanosecond), expected: 2},
+		{name: "the day after the deadline", rules: rules, deadline: deadline, now: deadline.Add(day)},
+		{name: "unsorted rules", rules: []reminder.Rule{{Days: 2}, {Days: 7}, {Days: 21}}, deadline: deadline, now: deadline.Add(-3 * day), expected: 7},
+		{name: "duplicate rules", rules: []reminder.Rule{{Days: 7}, {Days: 7}, {Days: 21}, {Days: 21}}, deadline: deadline, now: deadline.Add(-day), expected: 7},
+		{name: "no rules", deadline: deadline, now: deadline},
+		{name: "no deadline", rules: rules, now: deadline},
+	}
+	for _, tc := range testCases {
+		due := reminder.Due(tc.rules, tc.deadline, tc.now)
+		if tc.expected == 0 {
+			if due != nil {
+				t.Errorf("%s: want nothing due, got %v", tc.name, *due)
+			}
+			continue
+		}
+		if due == nil || due.Days != tc.expected {
+			t.Errorf("%s: want the %d day rule due, got %v", tc.name, tc.expected, due)
+		}
+	}
+}
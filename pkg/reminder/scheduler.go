@@ -0,0 +1,200 @@
+package reminder
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/logger"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/job"
+	"github.com/wcharczuk/katwillmarry.com/pkg/lifecycle"
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+)
+
+// NewScheduler returns a new scheduler.
+func NewScheduler(conn *db.Connection, deadline time.Time, rules ...Rule) *Scheduler {
+	return &Scheduler{
+		conn:     conn,
+		deadline: deadline,
+		rules:    sortRules(rules),
+		interval: DefaultInterval,
+	}
+}
+
+// NewSchedulerFromConfig returns a new scheduler from a config.
+func NewSchedulerFromConfig(conn *db.Connection, cfg *Config) (*Scheduler, error) {
+	deadline, err := cfg.GetDeadline()
+	if err != nil {
+		return nil, err
+	}
+	rules, err := cfg.GetRules()
+	if err != nil {
+		return nil, err
+	}
+	return NewScheduler(conn, deadline, rules...).WithInterval(cfg.GetInterval()), nil
+}
+
+// Scheduler queues rsvp reminders for households that haven't responded as the deadline approaches.
+//
+// Each run queues a reminder job for every household that hasn't responded and hasn't had
+// the due rule's reminder yet. The send and the job are written in one transaction, and the
+// send is keyed by household and rule, so a reminder is queued once however many replicas run.
+type Scheduler struct {
+	conn     *db.Connection
+	log      *logger.Logger
+	deadline time.Time
+	rules    []Rule
+	interval time.Duration
+	loop     *lifecycle.Interval
+}
+
+// WithLogger sets the logger.
+func (s *Scheduler) WithLogger(log *logger.Logger) *Scheduler {
+	s.log = log
+	return s
+}
+
+// WithInterval sets the time between checking for due reminders.
+func (s *Scheduler) WithInterval(interval time.Duration) *Scheduler {
+	s.interval = interval
+	return s
+}
+
+// Deadline returns the rsvp deadline; it is zero if reminders are off.
+func (s *Scheduler) Deadline() time.Time {
+	return s.deadline
+}
+
+// Rules returns the rules, furthest from the deadline first.
+func (s *Scheduler) Rules() []Rule {
+	return s.rules
+}
+
+// Planned is who a rule's reminder goes to.
+type Planned struct {
+	Rule      Rule
+	SendAfter time.Time
+	// Due is if the rule is the one currently being sent.
+	Due bool
+	// Passed is if a later rule has started (or the deadline is over), so the rule won't be sent again.
+	Passed bool
+	// Households are the households that haven't responded and haven't been sent the rule's reminder;
+	// it's empty for a rule that has passed.
+	Households []model.Household
+}
+
+// Preview returns who each rule's reminder would go to, as of a time.
+func (s *Scheduler) Preview(now time.Time, txs ...*sql.Tx) ([]Planned, error) {
+	households, err := model.HouseholdsWithoutRSVP(s.conn, txs...)
+	if err != nil {
+		return nil, err
+	}
+	due := Due(s.rules, s.deadline, now)
+
+	planned := make([]Planned, len(s.rules))
+	for index, rule := range s.rules {
+		sent, err := sentFor(s.conn, rule, txs...)
+		if err != nil {
+			return nil, err
+		}
+		planned[index] = Planned{
+			Rule:      rule,
+			SendAfter: rule.SendAfter(s.deadline),
+			Due:       due != nil && due.Days == rule.Days,
+			Passed:    !now.Before(rule.SendAfter(s.deadline)) && (due == nil || due.Days != rule.Days),
+		}
+		if planned[index].Passed {
+			continue
+		}
+		for _, household := range households {
+			if !sent[household.ID] {
+				planned[index].Households = append(planned[index].Households, household)
+			}
+		}
+	}
+	return planned, nil
+}
+
+// Run queues the due rule's reminders as of a time, returning the number queued.
+func (s *Scheduler) Run(now time.Time) (int, error) {
+	due := Due(s.rules, s.deadline, now)
+	if due == nil {
+		return 0, nil
+	}
+	planned, err := s.Preview(now)
+	if err != nil {
+		return 0, err
+	}
+
+	var queued int
+	var errs []error
+	for _, plan := range planned {
+		if !plan.Due {
+			continue
+		}
+		for _, household := range plan.Households {
+			ok, err := s.queue(household.ID, plan.Rule, now)
+			if err != nil {
+				errs = append(errs, exception.New(err).WithMessagef("household: %d", household.ID))
+				continue
+			}
+			if ok {
+				queued++
+			}
+		}
+	}
+	return queued, exception.Nest(errs...)
+}
+
+// queue queues a household's reminder for a rule, unless another run got there first.
+func (s *Scheduler) queue(householdID int64, rule Rule, now time.Time) (ok bool, err error) {
+	var tx *sql.Tx
+	tx, err = s.conn.Begin()
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil || !ok {
+			tx.Rollback()
+			return
+		}
+		err = exception.New(tx.Commit())
+	}()
+
+	var queued *job.Job
+	queued, err = job.Enqueue(s.conn, Args{HouseholdID: householdID, Days: rule.Days}, tx)
+	if err != nil {
+		return
+	}
+	ok, err = record(s.conn, tx, Send{HouseholdID: householdID, Days: rule.Days, JobID: queued.ID, QueuedUTC: now.UTC()})
+	return
+}
+
+// Start starts queueing due reminders on the interval in the background.
+// It does nothing if there's no deadline.
+func (s *Scheduler) Start() {
+	if s.deadline.IsZero() {
+		return
+	}
+	s.loop = lifecycle.NewInterval(s.interval, s.run).WithLogger(s.log)
+	s.loop.Start()
+}
+
+func (s *Scheduler) run() error {
+	queued, err := s.Run(time.Now().UTC())
+	if queued > 0 && s.log != nil {
+		s.log.Infof("queued %d rsvp reminders", queued)
+	}
+	return err
+}
+
+// Stop stops the scheduler, waiting for a run in progress to finish or the context to expire.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if s.loop == nil {
+		return nil
+	}
+	return s.loop.Stop(ctx)
+}
@@ -0,0 +1,96 @@
+package reminder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/job"
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/schema/schematest"
+)
+
+func createHousehold(t *testing.T, conn *db.Connection, name string) model.Household {
+	t.Helper()
+	now := time.Now().UTC()
+	household := model.Household{Name: name, CreatedUTC: now, UpdatedUTC: now}
+	if err := conn.Invoke().Create(&household); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return household
+}
+
+func queuedJobs(t *testing.T, conn *db.Connection) int {
+	t.Helper()
+	counts, err := job.Counts(conn)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return counts[job.StatusQueued]
+}
+
+func TestSchedulerRun(t *testing.T) {
+	conn := schematest.Open(t)
+	deadline := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	s := NewScheduler(conn, deadline, Rule{Days: 2}, Rule{Days: 21}, Rule{Days: 7})
+	early := createHousehold(t, conn, "The Smiths")
+
+	queued, err := s.Run(deadline.AddDate(0, 0, -21))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if queued != 1 {
+		t.Fatalf("want the 21 day reminder queued, queued %d", queued)
+	}
+	// running again, ex. on another replica, doesn't queue it again.
+	if queued, err = s.Run(deadline.AddDate(0, 0, -20)); err != nil || queued != 0 {
+		t.Fatalf("a reminder should only be queued once, queued %d %v", queued, err)
+	}
+
+	late := createHousehold(t, conn, "The Joneses")
+	if queued, err = s.Run(deadline.AddDate(0, 0, -5)); err != nil || queued != 2 {
+		t.Fatalf("want the 7 day reminder queued for both households, queued %d %v", queued, err)
+	}
+	if queued, err = s.Run(deadline.AddDate(0, 0, -5)); err != nil || queued != 0 {
+		t.Fatalf("a reminder should only be queued once, queued %d %v", queued, err)
+	}
+	if queued, err = s.Run(deadline.Add(36 * time.Hour)); err != nil || queued != 0 {
+		t.Fatalf("nothing should be queued after the deadline day, queued %d %v", queued, err)
+	}
+
+	sent21, err := sentFor(conn, Rule{Days: 21})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	sent7, err := sentFor(conn, Rule{Days: 7})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !sent21[early.ID] || sent21[late.ID] || !sent7[early.ID] || !sent7[late.ID] {
+		t.Fatalf("the late household should only get the latest rule, got 21: %v, 7: %v", sent21, sent7)
+	}
+	if jobs := queuedJobs(t, conn); jobs != 3 {
+		t.Fatalf("want a job per reminder, got %d", jobs)
+	}
+}
+
+func TestSchedulerQueuesOnce(t *testing.T) {
+	conn := schematest.Open(t)
+	household := createHousehold(t, conn, "The Smiths")
+	s := NewScheduler(conn, time.Now().UTC().AddDate(0, 0, 1), Rule{Days: 7})
+
+	// two runs that both previewed the household before either queued it.
+	now := time.Now().UTC()
+	ok, err := s.queue(household.ID, Rule{Days: 7}, now)
+	if err != nil || !ok {
+		t.Fatalf("the first run should queue the reminder, got %v %v", ok, err)
+	}
+	ok, err = s.queue(household.ID, Rule{Days: 7}, now)
+	if err != nil || ok {
+		t.Fatalf("the second run shouldn't queue the reminder again, got %v %v", ok, err)
+	}
+	if jobs := queuedJobs(t, conn); jobs != 1 {
+		t.Fatalf("the second run's job should be rolled back with its send, got %d jobs", jobs)
+	}
+}
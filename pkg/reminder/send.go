@@ -0,0 +1,92 @@
+package reminder
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/query"
+)
+
+// Send records that a household's reminder for a rule was queued, so it's only queued once.
+type Send struct {
+	HouseholdID int64     `db:"household_id,pk" json:"householdID"`
+	Days        int       `db:"days,pk" json:"days"`
+	JobID       int64     `db:"job_id" json:"jobID"`
+	QueuedUTC   time.Time `db:"queued_utc" json:"queuedUTC"`
+}
+
+// TableName returns the mapped table name.
+func (s Send) TableName() string {
+	return "reminder_send"
+}
+
+// Rule returns the rule the reminder was sent for.
+func (s Send) Rule() Rule {
+	return Rule{Days: s.Days}
+}
+
+// Sends returns the most recently queued reminders, newest first.
+func Sends(conn *db.Connection, limit int, txs ...*sql.Tx) ([]Send, error) {
+	var sends []Send
+	err := query.Select(Send{}).OrderBy(query.Desc("queued_utc"), query.Asc("household_id")).Limit(limit).OutMany(conn, &sends, txs...)
+	return sends, err
+}
+
+// sentFor returns the ids of the households that have been sent a rule's reminder.
+func sentFor(conn *db.Connection, rule Rule, txs ...*sql.Tx) (map[int64]bool, error) {
+	var sends []Send
+	if err := query.Select(Send{}).Where(query.Eq("days", rule.Days)).OutMany(conn, &sends, txs...); err != nil {
+		return nil, err
+	}
+	sent := map[int64]bool{}
+	for _, send := range sends {
+		sent[send.HouseholdID] = true
+	}
+	return sent, nil
+}
+
+// record inserts a send, returning false if the household already has one for the rule.
+func record(conn *db.Connection, tx *sql.Tx, send Send) (bool, error) {
+	cols := db.Columns(send)
+	dialect := conn.Dialect()
+	statement := "INSERT INTO " + send.TableName() + " (" + cols.ColumnNamesCSV() + ") VALUES (" + db.ParamTokensFor(dialect, 1, cols.Len()) + ")" +
+		dialect.OnConflictDoNothing(cols.PrimaryKeys().ColumnNames())
+	inserted, err := conn.Invoke(tx).WithLabel("reminder_send_record").ExecAffected(statement, cols.ColumnValues(send)...)
+	return inserted == 1, err
+}
+
+// Delivery records that a reminder reached a guest on a channel, so a retried job only sends
+// to the guests and channels that failed.
+type Delivery struct {
+	HouseholdID  int64     `db:"household_id,pk" json:"householdID"`
+	Days         int       `db:"days,pk" json:"days"`
+	GuestID      int64     `db:"guest_id,pk" json:"guestID"`
+	Channel      string    `db:"channel,pk" json:"channel"`
+	DeliveredUTC time.Time `db:"delivered_utc" json:"deliveredUTC"`
+}
+
+// TableName returns the mapped table name.
+func (d Delivery) TableName() string {
+	return "reminder_delivery"
+}
+
+// deliveredFor returns the guest and channel pairs a household's reminder for a rule reached.
+func deliveredFor(conn *db.Connection, householdID int64, rule Rule, txs ...*sql.Tx) (map[Delivery]bool, error) {
+	var deliveries []Delivery
+	if err := query.Select(Delivery{}).Where(query.And(query.Eq("household_id", householdID), query.Eq("days", rule.Days))).OutMany(conn, &deliveries, txs...); err != nil {
+		return nil, err
+	}
+	delivered := map[Delivery]bool{}
+	for _, delivery := range deliveries {
+		delivered[delivery.key()] = true
+	}
+	return delivered, nil
+}
+
+// key returns the delivery without its timestamp, for lookups.
+func (d Delivery) key() Delivery {
+	d.DeliveredUTC = time.Time{}
+	return d
+}
@@ -0,0 +1,204 @@
+// Package report has the read-only guest list reports on the admin site.
+//
+// Each report lists the roles that can run it, so someone can be given the numbers they need
+// without the rest of the guest list; the caterer sees meal counts and dietary notes, but not
+// addresses or contact details.
+package report
+
+import (
+	"database/sql"
+	"strconv"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/query"
+	"github.com/wcharczuk/katwillmarry.com/pkg/rbac"
+)
+
+// Report is a read-only view of the guest list.
+type Report struct {
+	Name        string
+	Title       string
+	Description string
+	// Roles are the roles that can run the report.
+	Roles []string
+
+	run func(conn *db.Connection, txs ...*sql.Tx) (*Table, error)
+}
+
+// Table is the result of running a report.
+type Table struct {
+	Columns []string
+	Rows    [][]string
+}
+
+// Allowed returns if a session can run the report.
+func (r Report) Allowed(session *web.Session) bool {
+	return session.HasRole(r.Roles...)
+}
+
+// Run runs the report.
+func (r Report) Run(conn *db.Connection, txs ...*sql.Tx) (*Table, error) {
+	return r.run(conn, txs...)
+}
+
+// All returns the reports.
+func All() []Report {
+	return []Report{
+		{
+			Name:        "meal-counts",
+			Title:       "Meal Counts",
+			Description: "How many guests are coming, and how many have dietary notes.",
+			Roles:       []string{rbac.Owner, rbac.Planner, rbac.Caterer},
+			run:         mealCounts,
+		},
+		{
+			Name:        "dietary-notes",
+			Title:       "Dietary Notes",
+			Description: "Allergies, dietary and medical notes of the guests who are coming.",
+			Roles:       []string{rbac.Owner, rbac.Planner, rbac.Caterer},
+			run:         dietaryNotes,
+		},
+		{
+			Name:        "guest-list",
+			Title:       "Guest List",
+			Description: "Guests by household, and whether they're coming.",
+			Roles:       []string{rbac.Owner, rbac.Planner, rbac.Photographer},
+			run:         guestList,
+		},
+		{
+			Name:        "addresses",
+			Title:       "Addresses",
+			Description: "Mailing addresses of the households.",
+			Roles:       []string{rbac.Owner, rbac.Planner},
+			run:         addresses,
+		},
+	}
+}
+
+// Get returns a report by name.
+func Get(name string) (Report, bool) {
+	for _, report := range All() {
+		if report.Name == name {
+			return report, true
+		}
+	}
+	return Report{}, false
+}
+
+// Allowed returns the reports a session can run.
+func Allowed(session *web.Session) []Report {
+	var allowed []Report
+	for _, report := range All() {
+		if report.Allowed(session) {
+			allowed = append(allowed, report)
+		}
+	}
+	return allowed
+}
+
+func mealCounts(conn *db.Connection, txs ...*sql.Tx) (*Table, error) {
+	guests, rsvps, err := guestsAndRSVPs(conn, txs...)
+	if err != nil {
+		return nil, err
+	}
+	var attending, declined, waiting, notes int
+	for _, guest := range guests {
+		rsvp, ok := rsvps[guest.ID]
+		switch {
+		case !ok:
+			waiting++
+		case rsvp.Attending:
+			attending++
+			if len(guest.DietaryNotes) > 0 {
+				notes++
+			}
+		default:
+			declined++
+		}
+	}
+	return &Table{
+		Columns: []string{"Attending", "Declined", "No Response", "Attending With Dietary Notes"},
+		Rows:    [][]string{{strconv.Itoa(attending), strconv.Itoa(declined), strconv.Itoa(waiting), strconv.Itoa(notes)}},
+	}, nil
+}
+
+func dietaryNotes(conn *db.Connection, txs ...*sql.Tx) (*Table, error) {
+	guests, rsvps, err := guestsAndRSVPs(conn, txs...)
+	if err != nil {
+		return nil, err
+	}
+	table := &Table{Columns: []string{"Guest", "Notes"}}
+	for _, guest := range guests {
+		if rsvp, ok := rsvps[guest.ID]; ok && rsvp.Attending && len(guest.DietaryNotes) > 0 {
+			table.Rows = append(table.Rows, []string{guest.Name, guest.DietaryNotes})
+		}
+	}
+	return table, nil
+}
+
+func guestList(conn *db.Connection, txs ...*sql.Tx) (*Table, error) {
+	guests, rsvps, err := guestsAndRSVPs(conn, txs...)
+	if err != nil {
+		return nil, err
+	}
+	households, err := householdsByID(conn, txs...)
+	if err != nil {
+		return nil, err
+	}
+	table := &Table{Columns: []string{"Household", "Guest", "Coming"}}
+	for _, guest := range guests {
+		coming := "no response"
+		if rsvp, ok := rsvps[guest.ID]; ok {
+			coming = "no"
+			if rsvp.Attending {
+				coming = "yes"
+			}
+		}
+		table.Rows = append(table.Rows, []string{households[guest.HouseholdID].Name, guest.Name, coming})
+	}
+	return table, nil
+}
+
+func addresses(conn *db.Connection, txs ...*sql.Tx) (*Table, error) {
+	var households []model.Household
+	if err := query.Select(model.Household{}).OrderBy(query.Asc("name"), query.Asc("id")).OutMany(conn, &households, txs...); err != nil {
+		return nil, err
+	}
+	table := &Table{Columns: []string{"Household", "Address"}}
+	for _, household := range households {
+		table.Rows = append(table.Rows, []string{household.Name, household.Address})
+	}
+	return table, nil
+}
+
+// guestsAndRSVPs returns the guests, ordered by household, and their rsvps by guest id.
+func guestsAndRSVPs(conn *db.Connection, txs ...*sql.Tx) ([]model.Guest, map[int64]model.RSVP, error) {
+	var guests []model.Guest
+	if err := query.Select(model.Guest{}).OrderBy(query.Asc("household_id"), query.Asc("id")).OutMany(conn, &guests, txs...); err != nil {
+		return nil, nil, err
+	}
+	var rsvps []model.RSVP
+	if err := query.Select(model.RSVP{}).OutMany(conn, &rsvps, txs...); err != nil {
+		return nil, nil, err
+	}
+	byGuest := map[int64]model.RSVP{}
+	for _, rsvp := range rsvps {
+		byGuest[rsvp.GuestID] = rsvp
+	}
+	return guests, byGuest, nil
+}
+
+func householdsByID(conn *db.Connection, txs ...*sql.Tx) (map[int64]model.Household, error) {
+	var households []model.Household
+	if err := query.Select(model.Household{}).OutMany(conn, &households, txs...); err != nil {
+		return nil, err
+	}
+	byID := map[int64]model.Household{}
+	for _, household := range households {
+		byID[household.ID] = household
+	}
+	return byID, nil
+}
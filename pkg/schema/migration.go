@@ -0,0 +1,147 @@
+package schema
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+)
+
+const (
+	// lockID is the advisory lock key held while applying migrations,
+	// so replicas starting at the same time don't race each other.
+	lockID = 20180712
+
+	createMigrationTable = `CREATE TABLE IF NOT EXISTS schema_migration (
+		version int not null primary key,
+		name varchar(255) not null,
+		applied_utc timestamp not null
+	)`
+)
+
+// Migration is a single versioned schema change.
+// Each statement is executed on its own, in order, inside one transaction.
+type Migration struct {
+	Version    int
+	Name       string
+	Statements []string
+	// SQLite are the statements run instead of `Statements` on sqlite.
+	// Leave it empty when the statements are portable.
+	SQLite []string
+}
+
+// StatementsFor returns the statements to run for a given dialect.
+func (m Migration) StatementsFor(dialect db.Dialect) []string {
+	if dialect.Name() == db.DialectSQLite && len(m.SQLite) > 0 {
+		return m.SQLite
+	}
+	return m.Statements
+}
+
+// appliedMigration is the record of a migration having run.
+type appliedMigration struct {
+	Version    int       `db:"version,pk"`
+	Name       string    `db:"name"`
+	AppliedUTC time.Time `db:"applied_utc"`
+}
+
+// TableName returns the mapped table name.
+func (am appliedMigration) TableName() string {
+	return "schema_migration"
+}
+
+// Sorted returns the migrations ordered by version.
+func Sorted() []Migration {
+	sorted := make([]Migration, len(Migrations))
+	copy(sorted, Migrations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+	return sorted
+}
+
+// Applied returns the versions that have been applied to the database.
+func Applied(conn *db.Connection, txs ...*sql.Tx) (map[int]bool, error) {
+	applied := map[int]bool{}
+	err := conn.Invoke(txs...).Query("SELECT version FROM schema_migration").Each(func(r *sql.Rows) error {
+		var version int
+		if err := r.Scan(&version); err != nil {
+			return exception.New(err)
+		}
+		applied[version] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// Pending returns the migrations that haven't been applied yet.
+func Pending(conn *db.Connection, txs ...*sql.Tx) ([]Migration, error) {
+	applied, err := Applied(conn, txs...)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range Sorted() {
+		if !applied[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Apply runs any pending migrations.
+func Apply(conn *db.Connection) (err error) {
+	var tx *sql.Tx
+	tx, err = conn.Begin()
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = exception.New(tx.Commit())
+	}()
+
+	// sqlite only has the one writer, so there is nothing to lock against.
+	if conn.Dialect().Name() == db.DialectPostgres {
+		if err = conn.Invoke(tx).Exec("SELECT pg_advisory_xact_lock($1)", lockID); err != nil {
+			return
+		}
+	}
+	if err = conn.Invoke(tx).Exec(createMigrationTable); err != nil {
+		return
+	}
+
+	var pending []Migration
+	pending, err = Pending(conn, tx)
+	if err != nil {
+		return
+	}
+	for _, migration := range pending {
+		for _, statement := range migration.StatementsFor(conn.Dialect()) {
+			if err = conn.Invoke(tx).Exec(statement); err != nil {
+				err = exception.New(err).WithMessagef("migration: %d %s", migration.Version, migration.Name)
+				return
+			}
+		}
+		err = conn.Invoke(tx).Create(&appliedMigration{
+			Version:    migration.Version,
+			Name:       migration.Name,
+			AppliedUTC: time.Now().UTC(),
+		})
+		if err != nil {
+			return
+		}
+		if log := conn.Logger(); log != nil {
+			log.Infof("applied migration %d %s", migration.Version, migration.Name)
+		}
+	}
+	return
+}
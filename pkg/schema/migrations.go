@@ -0,0 +1,373 @@
+package schema
+
+// Migrations are the schema changes for the site.
+// New migrations go at the end with the next version; never edit one that has shipped.
+var Migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "audit_entry",
+		Statements: []string{
+			`CREATE TABLE audit_entry (
+				id bigserial not null primary key,
+				created_utc timestamp not null,
+				principal varchar(255) not null,
+				verb varchar(32) not null,
+				entity varchar(255) not null,
+				entity_key varchar(255) not null,
+				scope varchar(255) not null default '',
+				changes jsonb,
+				before_state jsonb
+			)`,
+			`CREATE INDEX ix_audit_entry_entity ON audit_entry (entity, entity_key)`,
+			`CREATE INDEX ix_audit_entry_scope ON audit_entry (scope)`,
+		},
+		SQLite: []string{
+			`CREATE TABLE audit_entry (
+				id integer not null primary key autoincrement,
+				created_utc timestamp not null,
+				principal varchar(255) not null,
+				verb varchar(32) not null,
+				entity varchar(255) not null,
+				entity_key varchar(255) not null,
+				scope varchar(255) not null default '',
+				changes text,
+				before_state text
+			)`,
+			`CREATE INDEX ix_audit_entry_entity ON audit_entry (entity, entity_key)`,
+			`CREATE INDEX ix_audit_entry_scope ON audit_entry (scope)`,
+		},
+	},
+	{
+		Version: 2,
+		Name:    "web_session",
+		Statements: []string{
+			`CREATE TABLE web_session (
+				session_id varchar(255) not null primary key,
+				user_id varchar(255) not null,
+				created_utc timestamp not null,
+				expires_utc timestamp,
+				last_seen_utc timestamp not null,
+				remote_addr varchar(255) not null default '',
+				user_agent text not null default '',
+				state jsonb
+			)`,
+			`CREATE INDEX ix_web_session_user_id ON web_session (user_id)`,
+			`CREATE INDEX ix_web_session_expires_utc ON web_session (expires_utc)`,
+		},
+		SQLite: []string{
+			`CREATE TABLE web_session (
+				session_id varchar(255) not null primary key,
+				user_id varchar(255) not null,
+				created_utc timestamp not null,
+				expires_utc timestamp,
+				last_seen_utc timestamp not null,
+				remote_addr varchar(255) not null default '',
+				user_agent text not null default '',
+				state text
+			)`,
+			`CREATE INDEX ix_web_session_user_id ON web_session (user_id)`,
+			`CREATE INDEX ix_web_session_expires_utc ON web_session (expires_utc)`,
+		},
+	},
+	{
+		Version: 3,
+		Name:    "job",
+		Statements: []string{
+			`CREATE TABLE job (
+				id bigserial not null primary key,
+				kind varchar(255) not null,
+				payload jsonb,
+				status varchar(32) not null,
+				attempts int not null default 0,
+				max_attempts int not null default 0,
+				run_at timestamp not null,
+				locked_by varchar(255) not null default '',
+				locked_until timestamp,
+				last_error text not null default '',
+				created_utc timestamp not null,
+				updated_utc timestamp not null,
+				finished_utc timestamp
+			)`,
+			`CREATE INDEX ix_job_status_run_at ON job (status, run_at)`,
+		},
+		SQLite: []string{
+			`CREATE TABLE job (
+				id integer not null primary key autoincrement,
+				kind varchar(255) not null,
+				payload text,
+				status varchar(32) not null,
+				attempts int not null default 0,
+				max_attempts int not null default 0,
+				run_at timestamp not null,
+				locked_by varchar(255) not null default '',
+				locked_until timestamp,
+				last_error text not null default '',
+				created_utc timestamp not null,
+				updated_utc timestamp not null,
+				finished_utc timestamp
+			)`,
+			`CREATE INDEX ix_job_status_run_at ON job (status, run_at)`,
+		},
+	},
+	{
+		Version: 4,
+		Name:    "household_guest_rsvp",
+		Statements: []string{
+			`CREATE TABLE household (
+				id bigserial not null primary key,
+				name varchar(255) not null,
+				address text not null default '',
+				created_utc timestamp not null,
+				updated_utc timestamp not null,
+				deleted_utc timestamp,
+				version bigint not null default 0
+			)`,
+			`CREATE TABLE guest (
+				id bigserial not null primary key,
+				household_id bigint not null references household(id) on delete cascade,
+				name varchar(255) not null,
+				email varchar(255) not null default '',
+				phone text not null default '',
+				dietary_notes text not null default '',
+				created_utc timestamp not null,
+				updated_utc timestamp not null,
+				deleted_utc timestamp,
+				version bigint not null default 0
+			)`,
+			`CREATE INDEX ix_guest_household_id ON guest (household_id)`,
+			`CREATE TABLE rsvp (
+				guest_id bigint not null primary key references guest(id) on delete cascade,
+				household_id bigint not null references household(id) on delete cascade,
+				attending boolean not null,
+				message text not null default '',
+				responded_utc timestamp not null,
+				version bigint not null default 0
+			)`,
+			`CREATE INDEX ix_rsvp_household_id ON rsvp (household_id)`,
+		},
+		SQLite: []string{
+			`CREATE TABLE household (
+				id integer not null primary key autoincrement,
+				name varchar(255) not null,
+				address text not null default '',
+				created_utc timestamp not null,
+				updated_utc timestamp not null,
+				deleted_utc timestamp,
+				version bigint not null default 0
+			)`,
+			`CREATE TABLE guest (
+				id integer not null primary key autoincrement,
+				household_id bigint not null references household(id) on delete cascade,
+				name varchar(255) not null,
+				email varchar(255) not null default '',
+				phone text not null default '',
+				dietary_notes text not null default '',
+				created_utc timestamp not null,
+				updated_utc timestamp not null,
+				deleted_utc timestamp,
+				version bigint not null default 0
+			)`,
+			`CREATE INDEX ix_guest_household_id ON guest (household_id)`,
+			`CREATE TABLE rsvp (
+				guest_id bigint not null primary key references guest(id) on delete cascade,
+				household_id bigint not null references household(id) on delete cascade,
+				attending boolean not null,
+				message text not null default '',
+				responded_utc timestamp not null,
+				version bigint not null default 0
+			)`,
+			`CREATE INDEX ix_rsvp_household_id ON rsvp (household_id)`,
+		},
+	},
+	{
+		Version: 5,
+		Name:    "reminder_send",
+		Statements: []string{
+			`CREATE TABLE reminder_send (
+				household_id bigint not null references household(id) on delete cascade,
+				days int not null,
+				job_id bigint not null,
+				queued_utc timestamp not null,
+				primary key (household_id, days)
+			)`,
+		},
+	},
+	{
+		Version: 6,
+		Name:    "guest_notify_by",
+		Statements: []string{
+			`ALTER TABLE guest ADD COLUMN notify_by varchar(16) not null default ''`,
+			`ALTER TABLE guest ADD COLUMN sms_opt_out_utc timestamp`,
+		},
+	},
+	{
+		Version: 7,
+		Name:    "event_api_token",
+		Statements: []string{
+			`CREATE TABLE event (
+				id bigserial not null primary key,
+				name varchar(255) not null,
+				description text not null default '',
+				location text not null default '',
+				starts_utc timestamp not null,
+				ends_utc timestamp,
+				created_utc timestamp not null,
+				updated_utc timestamp not null,
+				deleted_utc timestamp,
+				version bigint not null default 0
+			)`,
+			`CREATE TABLE api_token (
+				id bigserial not null primary key,
+				name varchar(255) not null,
+				hash varchar(64) not null,
+				created_by varchar(255) not null,
+				created_utc timestamp not null,
+				last_used_utc timestamp
+			)`,
+			`CREATE UNIQUE INDEX uk_api_token_hash ON api_token (hash)`,
+		},
+		SQLite: []string{
+			`CREATE TABLE event (
+				id integer not null primary key autoincrement,
+				name varchar(255) not null,
+				description text not null default '',
+				location text not null default '',
+				starts_utc timestamp not null,
+				ends_utc timestamp,
+				created_utc timestamp not null,
+				updated_utc timestamp not null,
+				deleted_utc timestamp,
+				version bigint not null default 0
+			)`,
+			`CREATE TABLE api_token (
+				id integer not null primary key autoincrement,
+				name varchar(255) not null,
+				hash varchar(64) not null,
+				created_by varchar(255) not null,
+				created_utc timestamp not null,
+				last_used_utc timestamp
+			)`,
+			`CREATE UNIQUE INDEX uk_api_token_hash ON api_token (hash)`,
+		},
+	},
+	{
+		Version: 8,
+		Name:    "magic_link",
+		Statements: []string{
+			`CREATE TABLE magic_link (
+				id bigserial not null primary key,
+				guest_id bigint not null references guest(id) on delete cascade,
+				household_id bigint not null references household(id) on delete cascade,
+				created_utc timestamp not null,
+				expires_utc timestamp not null,
+				used_utc timestamp
+			)`,
+			`CREATE INDEX ix_magic_link_guest_id ON magic_link (guest_id)`,
+		},
+		SQLite: []string{
+			`CREATE TABLE magic_link (
+				id integer not null primary key autoincrement,
+				guest_id bigint not null references guest(id) on delete cascade,
+				household_id bigint not null references household(id) on delete cascade,
+				created_utc timestamp not null,
+				expires_utc timestamp not null,
+				used_utc timestamp
+			)`,
+			`CREATE INDEX ix_magic_link_guest_id ON magic_link (guest_id)`,
+		},
+	},
+	{
+		Version: 9,
+		Name:    "admin_role",
+		Statements: []string{
+			`CREATE TABLE admin_role (
+				user_id varchar(255) not null,
+				role varchar(32) not null,
+				created_by varchar(255) not null,
+				created_utc timestamp not null,
+				primary key (user_id, role)
+			)`,
+		},
+	},
+	{
+		Version: 10,
+		Name:    "passkey",
+		Statements: []string{
+			`CREATE TABLE passkey (
+				id bigserial not null primary key,
+				credential_id varchar(1400) not null,
+				user_id varchar(255) not null,
+				name varchar(255) not null,
+				public_key text not null,
+				sign_count bigint not null default 0,
+				created_utc timestamp not null,
+				last_used_utc timestamp
+			)`,
+			`CREATE UNIQUE INDEX uk_passkey_credential_id ON passkey (credential_id)`,
+			`CREATE INDEX ix_passkey_user_id ON passkey (user_id)`,
+			`CREATE TABLE passkey_challenge (
+				challenge varchar(64) not null primary key,
+				ceremony varchar(16) not null,
+				user_id varchar(255) not null,
+				expires_utc timestamp not null,
+				used_utc timestamp
+			)`,
+		},
+		SQLite: []string{
+			`CREATE TABLE passkey (
+				id integer not null primary key autoincrement,
+				credential_id varchar(1400) not null,
+				user_id varchar(255) not null,
+				name varchar(255) not null,
+				public_key text not null,
+				sign_count bigint not null default 0,
+				created_utc timestamp not null,
+				last_used_utc timestamp
+			)`,
+			`CREATE UNIQUE INDEX uk_passkey_credential_id ON passkey (credential_id)`,
+			`CREATE INDEX ix_passkey_user_id ON passkey (user_id)`,
+			`CREATE TABLE passkey_challenge (
+				challenge varchar(64) not null primary key,
+				ceremony varchar(16) not null,
+				user_id varchar(255) not null,
+				expires_utc timestamp not null,
+				used_utc timestamp
+			)`,
+		},
+	},
+	{
+		Version: 11,
+		Name:    "reminder_delivery",
+		Statements: []string{
+			`CREATE TABLE reminder_delivery (
+				household_id bigint not null references household(id) on delete cascade,
+				days int not null,
+				guest_id bigint not null references guest(id) on delete cascade,
+				channel varchar(16) not null,
+				delivered_utc timestamp not null,
+				primary key (household_id, days, guest_id, channel)
+			)`,
+		},
+	},
+	{
+		Version: 12,
+		Name:    "api_token_scope",
+		Statements: []string{
+			// tokens made before scopes could do everything, so they keep write access.
+			`ALTER TABLE api_token ADD COLUMN scope varchar(16) not null default 'write'`,
+		},
+	},
+	{
+		Version: 13,
+		Name:    "admin_role_lower_user_id",
+		Statements: []string{
+			// roles given to the same user under differently cased ids are merged.
+			`DELETE FROM admin_role WHERE user_id <> lower(user_id) AND EXISTS (
+				SELECT 1 FROM admin_role other
+				WHERE lower(other.user_id) = lower(admin_role.user_id) AND other.role = admin_role.role AND other.user_id <> admin_role.user_id
+				AND (other.user_id = lower(other.user_id) OR other.user_id < admin_role.user_id)
+			)`,
+			`UPDATE admin_role SET user_id = lower(user_id) WHERE user_id <> lower(user_id)`,
+		},
+	},
+}
@@ -0,0 +1,34 @@
+// Package schematest opens sqlite databases with the schema applied, so packages can test
+// against a real database without postgres.
+package schematest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/blend/go-sdk/db"
+
+	// the sqlite driver.
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/audit"
+	"github.com/wcharczuk/katwillmarry.com/pkg/schema"
+)
+
+// Open returns a connection to a new sqlite database in the test's temp directory, with
+// the migrations applied and changes audited as they are in the app. It's closed when the test finishes.
+func Open(t testing.TB) *db.Connection {
+	t.Helper()
+	conn, err := db.NewFromConfig(&db.Config{
+		Dialect:  db.DialectSQLite,
+		Database: filepath.Join(t.TempDir(), "test.db"),
+	}).WithTracker(audit.Tracker{}).Open()
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if err := schema.Apply(conn); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return conn
+}
@@ -0,0 +1,68 @@
+package security
+
+import "github.com/blend/go-sdk/util"
+
+const (
+	// DefaultContentSecurityPolicy only allows resources served from our own origin.
+	// Third party assets have to be fetched into `_static/vendor` for pages to load under it.
+	DefaultContentSecurityPolicy = "default-src 'self'; script-src 'self'; style-src 'self'; img-src 'self' data:; font-src 'self'; connect-src 'self'; object-src 'none'; base-uri 'self'; form-action 'self'; frame-ancestors 'none'"
+	// DefaultFrameOptions is the default `X-Frame-Options` value.
+	DefaultFrameOptions = "DENY"
+	// DefaultContentTypeOptions is the default `X-Content-Type-Options` value.
+	DefaultContentTypeOptions = "nosniff"
+	// DefaultPermissionsPolicy is the default `Permissions-Policy` value.
+	DefaultPermissionsPolicy = "camera=(), microphone=(), geolocation=(), payment=(), usb=()"
+	// DefaultReferrerPolicy is the default `Referrer-Policy` value. Urls aren't sent to other sites,
+	// but our own form posts keep their `Origin`, which `SameOrigin` checks; under `no-referrer`
+	// browsers send it as `null`.
+	DefaultReferrerPolicy = "same-origin"
+	// DefaultUseNonce is the default for if we add a per-request nonce to the content security policy.
+	DefaultUseNonce = true
+)
+
+// Config is the security headers config.
+// Setting any header value to "-" disables that header.
+type Config struct {
+	// ContentSecurityPolicy is the base `Content-Security-Policy`.
+	ContentSecurityPolicy string `json:"contentSecurityPolicy,omitempty" yaml:"contentSecurityPolicy,omitempty" env:"CONTENT_SECURITY_POLICY"`
+	// UseNonce determines if a per-request nonce is added to the `script-src` and `style-src` directives.
+	UseNonce *bool `json:"useNonce,omitempty" yaml:"useNonce,omitempty" env:"CONTENT_SECURITY_POLICY_NONCE"`
+	// FrameOptions is the `X-Frame-Options` value.
+	FrameOptions string `json:"frameOptions,omitempty" yaml:"frameOptions,omitempty" env:"FRAME_OPTIONS"`
+	// ContentTypeOptions is the `X-Content-Type-Options` value.
+	ContentTypeOptions string `json:"contentTypeOptions,omitempty" yaml:"contentTypeOptions,omitempty" env:"CONTENT_TYPE_OPTIONS"`
+	// PermissionsPolicy is the `Permissions-Policy` value.
+	PermissionsPolicy string `json:"permissionsPolicy,omitempty" yaml:"permissionsPolicy,omitempty" env:"PERMISSIONS_POLICY"`
+	// ReferrerPolicy is the `Referrer-Policy` value.
+	ReferrerPolicy string `json:"referrerPolicy,omitempty" yaml:"referrerPolicy,omitempty" env:"REFERRER_POLICY"`
+}
+
+// GetContentSecurityPolicy returns a property or a default.
+func (c Config) GetContentSecurityPolicy(inherited ...string) string {
+	return util.Coalesce.String(c.ContentSecurityPolicy, DefaultContentSecurityPolicy, inherited...)
+}
+
+// GetUseNonce returns a property or a default.
+func (c Config) GetUseNonce(inherited ...bool) bool {
+	return util.Coalesce.Bool(c.UseNonce, DefaultUseNonce, inherited...)
+}
+
+// GetFrameOptions returns a property or a default.
+func (c Config) GetFrameOptions(inherited ...string) string {
+	return util.Coalesce.String(c.FrameOptions, DefaultFrameOptions, inherited...)
+}
+
+// GetContentTypeOptions returns a property or a default.
+func (c Config) GetContentTypeOptions(inherited ...string) string {
+	return util.Coalesce.String(c.ContentTypeOptions, DefaultContentTypeOptions, inherited...)
+}
+
+// GetPermissionsPolicy returns a property or a default.
+func (c Config) GetPermissionsPolicy(inherited ...string) string {
+	return util.Coalesce.String(c.PermissionsPolicy, DefaultPermissionsPolicy, inherited...)
+}
+
+// GetReferrerPolicy returns a property or a default.
+func (c Config) GetReferrerPolicy(inherited ...string) string {
+	return util.Coalesce.String(c.ReferrerPolicy, DefaultReferrerPolicy, inherited...)
+}
@@ -0,0 +1,104 @@
+package security
+
+import (
+	"strings"
+
+	"github.com/blend/go-sdk/util"
+	"github.com/blend/go-sdk/web"
+)
+
+const (
+	// HeaderContentSecurityPolicy is the content security policy header.
+	HeaderContentSecurityPolicy = "Content-Security-Policy"
+	// HeaderPermissionsPolicy is the permissions policy header.
+	HeaderPermissionsPolicy = "Permissions-Policy"
+	// HeaderReferrerPolicy is the referrer policy header.
+	HeaderReferrerPolicy = "Referrer-Policy"
+
+	// StateKeyNonce is the request state key for the content security policy nonce.
+	// Templates can read it with `{{ .Ctx.StateValue "csp_nonce" }}`.
+	StateKeyNonce = "csp_nonce"
+
+	// Disabled is the config value that turns a header off.
+	Disabled = "-"
+)
+
+// nonceDirectives are the directives that get the per-request nonce source.
+var nonceDirectives = []string{"script-src", "style-src"}
+
+// Headers returns a middleware that adds the configured security headers to every response.
+func Headers(cfg *Config) web.Middleware {
+	policy := cfg.GetContentSecurityPolicy()
+	useNonce := cfg.GetUseNonce()
+
+	static := map[string]string{
+		web.HeaderXFrameOptions:       cfg.GetFrameOptions(),
+		web.HeaderXContentTypeOptions: cfg.GetContentTypeOptions(),
+		HeaderPermissionsPolicy:       cfg.GetPermissionsPolicy(),
+		HeaderReferrerPolicy:          cfg.GetReferrerPolicy(),
+	}
+
+	return func(action web.Action) web.Action {
+		return func(ctx *web.Ctx) web.Result {
+			headers := ctx.Response().Header()
+			for key, value := range static {
+				if value != Disabled {
+					headers.Set(key, value)
+				}
+			}
+			if policy != Disabled {
+				if useNonce {
+					nonce := util.String.MustSecureRandom(16)
+					ctx.WithStateValue(StateKeyNonce, nonce)
+					headers.Set(HeaderContentSecurityPolicy, AddNonce(policy, nonce))
+				} else {
+					headers.Set(HeaderContentSecurityPolicy, policy)
+				}
+			}
+			return action(ctx)
+		}
+	}
+}
+
+// Nonce returns the content security policy nonce for the request, if one was issued.
+func Nonce(ctx *web.Ctx) string {
+	if value, ok := ctx.StateValue(StateKeyNonce).(string); ok {
+		return value
+	}
+	return ""
+}
+
+// AddNonce adds a `'nonce-...'` source to the script and style directives of a policy.
+// If the policy doesn't have a given directive, it is created from the `default-src` sources
+// so the nonce doesn't narrow what the fallback already allowed; with no `default-src` the
+// directive is left unrestricted.
+func AddNonce(policy, nonce string) string {
+	source := "'nonce-" + nonce + "'"
+
+	var directives []string
+	var defaultSources string
+	for _, directive := range strings.Split(policy, ";") {
+		directive = strings.TrimSpace(directive)
+		if len(directive) == 0 {
+			continue
+		}
+		if strings.HasPrefix(directive, "default-src ") {
+			defaultSources = strings.TrimPrefix(directive, "default-src ")
+		}
+		directives = append(directives, directive)
+	}
+
+	for _, name := range nonceDirectives {
+		var found bool
+		for index, directive := range directives {
+			if directive == name || strings.HasPrefix(directive, name+" ") {
+				directives[index] = directive + " " + source
+				found = true
+			}
+		}
+		if !found && len(defaultSources) > 0 {
+			directives = append(directives, name+" "+defaultSources+" "+source)
+		}
+	}
+	return strings.Join(directives, "; ")
+}
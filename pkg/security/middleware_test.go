@@ -0,0 +1,146 @@
+package security_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/security"
+)
+
+func TestHeaders(t *testing.T) {
+	useNonce := true
+	noNonce := false
+	testCases := []struct {
+		name     string
+		cfg      security.Config
+		expected map[string]string
+	}{
+		{
+			name: "defaults",
+			expected: map[string]string{
+				web.HeaderXFrameOptions:          security.DefaultFrameOptions,
+				web.HeaderXContentTypeOptions:    security.DefaultContentTypeOptions,
+				security.HeaderPermissionsPolicy: security.DefaultPermissionsPolicy,
+				security.HeaderReferrerPolicy:    security.DefaultReferrerPolicy,
+			},
+		},
+		{
+			name: "disabled",
+			cfg: security.Config{
+				ContentSecurityPolicy: security.Disabled,
+				UseNonce:              &useNonce,
+				FrameOptions:          security.Disabled,
+				ContentTypeOptions:    security.Disabled,
+				PermissionsPolicy:     security.Disabled,
+				ReferrerPolicy:        security.Disabled,
+			},
+			expected: map[string]string{
+				security.HeaderContentSecurityPolicy: "",
+				web.HeaderXFrameOptions:              "",
+				web.HeaderXContentTypeOptions:        "",
+				security.HeaderPermissionsPolicy:     "",
+				security.HeaderReferrerPolicy:        "",
+			},
+		},
+		{
+			name: "without a nonce",
+			cfg:  security.Config{ContentSecurityPolicy: "default-src 'self'", UseNonce: &noNonce, FrameOptions: "SAMEORIGIN"},
+			expected: map[string]string{
+				security.HeaderContentSecurityPolicy: "default-src 'self'",
+				web.HeaderXFrameOptions:              "SAMEORIGIN",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		cfg := tc.cfg
+		app := web.New()
+		app.WithDefaultMiddleware(security.Headers(&cfg))
+		var nonce string
+		app.GET("/", func(ctx *web.Ctx) web.Result {
+			nonce = security.Nonce(ctx)
+			return ctx.Text().Result("ok")
+		})
+		res, err := web.NewMockRequestBuilder(app).Get("/").Response()
+		if err != nil {
+			t.Fatalf("%s: %+v", tc.name, err)
+		}
+		res.Body.Close()
+		for key, value := range tc.expected {
+			if actual := res.Header.Get(key); actual != value {
+				t.Fatalf("%s: expected %s: %q, got %q", tc.name, key, value, actual)
+			}
+		}
+		if cfg.GetContentSecurityPolicy() == security.Disabled || !cfg.GetUseNonce() {
+			if len(nonce) > 0 {
+				t.Fatalf("%s: a nonce shouldn't be issued, got %q", tc.name, nonce)
+			}
+		}
+	}
+}
+
+func TestHeadersNonce(t *testing.T) {
+	app := web.New()
+	app.WithDefaultMiddleware(security.Headers(&security.Config{}))
+	var nonce, stateNonce string
+	app.GET("/", func(ctx *web.Ctx) web.Result {
+		nonce = security.Nonce(ctx)
+		stateNonce, _ = ctx.StateValue("csp_nonce").(string)
+		return ctx.Text().Result("ok")
+	})
+
+	var nonces []string
+	for i := 0; i < 2; i++ {
+		res, err := web.NewMockRequestBuilder(app).Get("/").Response()
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		res.Body.Close()
+		if len(nonce) == 0 || stateNonce != nonce {
+			t.Fatalf("the nonce should be in the request state as csp_nonce, got %q and %q", nonce, stateNonce)
+		}
+		policy := res.Header.Get(security.HeaderContentSecurityPolicy)
+		expected := security.AddNonce(security.DefaultContentSecurityPolicy, nonce)
+		if policy != expected {
+			t.Fatalf("expected policy %q, got %q", expected, policy)
+		}
+		nonces = append(nonces, nonce)
+	}
+	if nonces[0] == nonces[1] {
+		t.Fatal("each request should get its own nonce")
+	}
+}
+
+func TestAddNonce(t *testing.T) {
+	testCases := []struct {
+		policy   string
+		expected string
+	}{
+		{
+			policy:   "default-src 'self'; script-src 'self'; style-src 'self' 'unsafe-inline'",
+			expected: "default-src 'self'; script-src 'self' 'nonce-abc'; style-src 'self' 'unsafe-inline' 'nonce-abc'",
+		},
+		{
+			policy:   "default-src 'self' https://cdn.test; img-src 'self'",
+			expected: "default-src 'self' https://cdn.test; img-src 'self'; script-src 'self' https://cdn.test 'nonce-abc'; style-src 'self' https://cdn.test 'nonce-abc'",
+		},
+		{
+			policy:   "img-src 'self';",
+			expected: "img-src 'self'",
+		},
+		{
+			policy:   "script-src-elem 'self'; script-src; style-src 'self'",
+			expected: "script-src-elem 'self'; script-src 'nonce-abc'; style-src 'self' 'nonce-abc'",
+		},
+	}
+	for _, tc := range testCases {
+		if actual := security.AddNonce(tc.policy, "abc"); actual != tc.expected {
+			t.Fatalf("%q: expected %q, got %q", tc.policy, tc.expected, actual)
+		}
+	}
+	policy := security.AddNonce(security.DefaultContentSecurityPolicy, "abc")
+	if strings.Count(policy, "'nonce-abc'") != 2 {
+		t.Fatalf("the default policy should get the nonce in script-src and style-src, got %q", policy)
+	}
+}
@@ -0,0 +1,69 @@
+package security
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/blend/go-sdk/web"
+)
+
+const (
+	// HeaderOrigin is the header browsers send with the origin of the page that made a request.
+	HeaderOrigin = "Origin"
+	// HeaderSecFetchSite is the header browsers send with how the page that made a request relates
+	// to the site, ex. `same-origin` or `cross-site`.
+	HeaderSecFetchSite = "Sec-Fetch-Site"
+)
+
+// SameOrigin returns a middleware that refuses unsafe requests (ex. form posts) made by pages on
+// other sites, so they can't use the admin's cookies to submit our forms.
+//
+// Browsers say where a request came from with `Sec-Fetch-Site` or, in older browsers, `Origin`;
+// requests with neither aren't from a browser and are let through, as are routes described as
+// secured (`web.RouteMeta.Secured`), which authenticate with a token rather than cookies.
+// The origin is the base url if it's set, or the request's host if it isn't.
+func SameOrigin(baseURL string) web.Middleware {
+	var host string
+	if parsed, err := url.Parse(baseURL); err == nil {
+		host = parsed.Host
+	}
+	return func(action web.Action) web.Action {
+		return func(ctx *web.Ctx) web.Result {
+			switch ctx.Request().Method {
+			case "GET", "HEAD", "OPTIONS":
+				return action(ctx)
+			}
+			if route := ctx.Route(); route != nil && route.Meta != nil && route.Meta.Secured {
+				return action(ctx)
+			}
+			expected := host
+			if len(expected) == 0 {
+				expected = ctx.Request().Host
+			}
+			if !isSameOrigin(ctx.Request().Header.Get(HeaderSecFetchSite), ctx.Request().Header.Get(HeaderOrigin), expected) {
+				return ctx.Text().NotAuthorized()
+			}
+			return action(ctx)
+		}
+	}
+}
+
+// isSameOrigin returns if a request with the `Sec-Fetch-Site` and `Origin` headers came from the site's host.
+func isSameOrigin(fetchSite, origin, host string) bool {
+	switch fetchSite {
+	case "same-origin", "none":
+		return true
+	case "":
+	default:
+		return false
+	}
+	if len(origin) == 0 {
+		return true
+	}
+	// pages that don't share their origin, ex. sandboxed frames, send `null`.
+	parsed, err := url.Parse(origin)
+	if err != nil || origin == "null" {
+		return false
+	}
+	return strings.EqualFold(parsed.Host, host)
+}
@@ -0,0 +1,49 @@
+package security_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/security"
+)
+
+func TestSameOrigin(t *testing.T) {
+	app := web.New()
+	app.WithDefaultMiddleware(security.SameOrigin("https://katwillmarry.test"))
+	ok := func(ctx *web.Ctx) web.Result { return ctx.Text().Result("ok") }
+	app.POST("/admin/roles", ok)
+	app.POST("/api/v1/guests", ok)
+	app.Describe("POST", "/api/v1/guests", web.RouteMeta{Summary: "Create a guest", Secured: true})
+
+	testCases := []struct {
+		name    string
+		path    string
+		headers map[string]string
+		allowed bool
+	}{
+		{name: "same origin fetch", path: "/admin/roles", headers: map[string]string{"Sec-Fetch-Site": "same-origin"}, allowed: true},
+		{name: "cross site fetch", path: "/admin/roles", headers: map[string]string{"Sec-Fetch-Site": "cross-site", "Origin": "https://katwillmarry.test"}, allowed: false},
+		{name: "same site fetch", path: "/admin/roles", headers: map[string]string{"Sec-Fetch-Site": "same-site"}, allowed: false},
+		{name: "same origin", path: "/admin/roles", headers: map[string]string{"Origin": "https://katwillmarry.test"}, allowed: true},
+		{name: "other origin", path: "/admin/roles", headers: map[string]string{"Origin": "https://evil.test"}, allowed: false},
+		{name: "null origin", path: "/admin/roles", headers: map[string]string{"Origin": "null"}, allowed: false},
+		{name: "not a browser", path: "/admin/roles", allowed: true},
+		{name: "token route", path: "/api/v1/guests", headers: map[string]string{"Origin": "https://evil.test"}, allowed: true},
+	}
+	for _, tc := range testCases {
+		req := web.NewMockRequestBuilder(app).Post("%s", tc.path)
+		for key, value := range tc.headers {
+			req = req.WithHeader(key, value)
+		}
+		res, err := req.Response()
+		if err != nil {
+			t.Fatalf("%s: %+v", tc.name, err)
+		}
+		res.Body.Close()
+		if allowed := res.StatusCode == http.StatusOK; allowed != tc.allowed {
+			t.Fatalf("%s: expected allowed: %t, got status %d", tc.name, tc.allowed, res.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package session
+
+import (
+	"time"
+
+	"github.com/blend/go-sdk/util"
+)
+
+const (
+	// DefaultCleanupInterval is the default time between deleting expired sessions.
+	DefaultCleanupInterval = 15 * time.Minute
+)
+
+// Config is the session store config.
+type Config struct {
+	// CleanupInterval is the time between deleting expired sessions.
+	CleanupInterval time.Duration `json:"cleanupInterval,omitempty" yaml:"cleanupInterval,omitempty" env:"SESSION_CLEANUP_INTERVAL"`
+}
+
+// GetCleanupInterval returns a property or a default.
+func (c Config) GetCleanupInterval(inherited ...time.Duration) time.Duration {
+	return util.Coalesce.Duration(c.CleanupInterval, DefaultCleanupInterval, inherited...)
+}
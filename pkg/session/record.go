@@ -0,0 +1,51 @@
+package session
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/blend/go-sdk/web"
+)
+
+// Record is a session as it's stored in the database.
+type Record struct {
+	SessionID  string     `db:"session_id,pk" json:"-"`
+	UserID     string     `db:"user_id" json:"userID"`
+	CreatedUTC time.Time  `db:"created_utc" json:"createdUTC"`
+	ExpiresUTC *time.Time `db:"expires_utc" json:"expiresUTC,omitempty"`
+	// LastSeenUTC is when the session was last persisted, i.e. at login or when a rolling expiry was extended.
+	LastSeenUTC time.Time `db:"last_seen_utc" json:"lastSeenUTC"`
+	// RemoteAddr and UserAgent are from the request that last persisted the session, to tell sessions apart.
+	RemoteAddr string                 `db:"remote_addr" json:"remoteAddr"`
+	UserAgent  string                 `db:"user_agent" json:"userAgent"`
+	State      map[string]interface{} `db:"state,json" json:"state,omitempty"`
+}
+
+// TableName returns the mapped table name.
+func (r Record) TableName() string {
+	return "web_session"
+}
+
+// IsExpired returns if the session is expired.
+func (r Record) IsExpired() bool {
+	return r.ExpiresUTC != nil && r.ExpiresUTC.Before(time.Now().UTC())
+}
+
+// Handle returns an identifier for the session that's safe to show, ex. in a revoke link;
+// the session id itself is the login cookie value.
+func (r Record) Handle() string {
+	sum := sha256.Sum256([]byte(r.SessionID))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Session returns the web session for the record.
+func (r Record) Session() *web.Session {
+	session := web.NewSession(r.UserID, r.SessionID)
+	session.CreatedUTC = r.CreatedUTC
+	session.ExpiresUTC = r.ExpiresUTC
+	if r.State != nil {
+		session.State = r.State
+	}
+	return session
+}
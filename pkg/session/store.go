@@ -0,0 +1,174 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/web"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/lifecycle"
+	"github.com/wcharczuk/katwillmarry.com/pkg/query"
+)
+
+const (
+	// ErrSessionNotFound is returned when revoking a session that doesn't exist or has expired.
+	ErrSessionNotFound exception.Class = "session: not found"
+
+	// VerbRevoke is the audit event verb for revoking a session.
+	VerbRevoke = "revoke"
+)
+
+// NewStore returns a new session store.
+func NewStore(conn *db.Connection) *Store {
+	return &Store{
+		conn:            conn,
+		cleanupInterval: DefaultCleanupInterval,
+	}
+}
+
+// NewStoreFromConfig returns a new session store from a config.
+func NewStoreFromConfig(conn *db.Connection, cfg *Config) *Store {
+	return NewStore(conn).WithCleanupInterval(cfg.GetCleanupInterval())
+}
+
+// Store keeps auth manager sessions in the database, so they survive restarts
+// and are shared between replicas.
+type Store struct {
+	conn            *db.Connection
+	log             *logger.Logger
+	cleanupInterval time.Duration
+	cleanup         *lifecycle.Interval
+}
+
+// WithLogger sets the logger.
+func (s *Store) WithLogger(log *logger.Logger) *Store {
+	s.log = log
+	return s
+}
+
+// WithCleanupInterval sets the time between deleting expired sessions.
+func (s *Store) WithCleanupInterval(interval time.Duration) *Store {
+	s.cleanupInterval = interval
+	return s
+}
+
+// CleanupInterval returns the time between deleting expired sessions.
+func (s *Store) CleanupInterval() time.Duration {
+	return s.cleanupInterval
+}
+
+// Attach sets the store as the auth manager's persist, fetch and remove handlers.
+//
+// It also turns off the auth manager's in-process session cache; otherwise a session revoked
+// on one replica would stay valid on the others until they restart.
+func (s *Store) Attach(am *web.AuthManager) *web.AuthManager {
+	return am.
+		WithPersistHandler(s.Persist).
+		WithFetchHandler(s.Fetch).
+		WithRemoveHandler(s.Remove).
+		WithUseSessionCache(false)
+}
+
+// Persist saves a session; it is an auth manager persist handler.
+func (s *Store) Persist(ctx *web.Ctx, session *web.Session, state web.State) error {
+	record := Record{
+		SessionID:   session.SessionID,
+		UserID:      session.UserID,
+		CreatedUTC:  session.CreatedUTC,
+		ExpiresUTC:  session.ExpiresUTC,
+		LastSeenUTC: time.Now().UTC(),
+		State:       session.State,
+	}
+	if ctx != nil && ctx.Request() != nil {
+		record.RemoteAddr = logger.GetIP(ctx.Request())
+		record.UserAgent = ctx.Request().UserAgent()
+	}
+	return s.conn.Invoke(web.TxFromState(state)).Upsert(&record)
+}
+
+// Fetch returns a session by id, or nil if it doesn't exist; it is an auth manager fetch handler.
+// Expired sessions are returned as is, the auth manager removes them.
+func (s *Store) Fetch(sessionID string, state web.State) (*web.Session, error) {
+	var record Record
+	if err := s.conn.Invoke(web.TxFromState(state)).Get(&record, sessionID); err != nil {
+		return nil, err
+	}
+	if len(record.SessionID) == 0 {
+		return nil, nil
+	}
+	return record.Session(), nil
+}
+
+// Remove deletes a session; it is an auth manager remove handler.
+func (s *Store) Remove(sessionID string, state web.State) error {
+	if len(sessionID) == 0 {
+		return nil
+	}
+	return s.conn.Invoke(web.TxFromState(state)).Delete(&Record{SessionID: sessionID})
+}
+
+// Active returns the sessions that haven't expired, most recently seen first.
+func (s *Store) Active(txs ...*sql.Tx) ([]Record, error) {
+	var records []Record
+	err := query.Select(Record{}).
+		Where(query.Or(query.IsNull("expires_utc"), query.Gt("expires_utc", time.Now().UTC()))).
+		OrderBy(query.Desc("last_seen_utc"), query.Asc("session_id")).
+		OutMany(s.conn, &records, txs...)
+	return records, err
+}
+
+// Revoke deletes an active session by its handle (see `Record.Handle`) on behalf of a principal,
+// and triggers an audit event with the handle. The session's next request is treated as logged out.
+//
+// Sessions aren't registered with the audit tracker; their key is the session id, which would leave
+// it in the history, and reverting the entry would bring the session back.
+func (s *Store) Revoke(principal, handle string, txs ...*sql.Tx) error {
+	active, err := s.Active(txs...)
+	if err != nil {
+		return err
+	}
+	for _, record := range active {
+		if record.Handle() == handle {
+			if err := s.conn.Invoke(txs...).Delete(&Record{SessionID: record.SessionID}); err != nil {
+				return err
+			}
+			if log := s.conn.Logger(); log != nil {
+				log.Trigger(logger.NewAuditEvent(principal, VerbRevoke).WithNoun(Record{}.TableName()).WithSubject(handle))
+			}
+			return nil
+		}
+	}
+	return exception.New(ErrSessionNotFound).WithMessagef("handle: %s", handle)
+}
+
+// DeleteExpired deletes the sessions that have expired, returning the number deleted.
+func (s *Store) DeleteExpired() (int64, error) {
+	statement := "DELETE FROM " + db.TableName(Record{}) + " WHERE expires_utc < " + s.conn.Dialect().Placeholder(1)
+	return s.conn.Invoke().WithLabel("session_delete_expired").ExecAffected(statement, time.Now().UTC())
+}
+
+// Start starts deleting expired sessions on the cleanup interval in the background.
+func (s *Store) Start() {
+	s.cleanup = lifecycle.NewInterval(s.cleanupInterval, s.deleteExpired).WithLogger(s.log)
+	s.cleanup.Start()
+}
+
+func (s *Store) deleteExpired() error {
+	deleted, err := s.DeleteExpired()
+	if deleted > 0 && s.log != nil {
+		s.log.Infof("deleted %d expired sessions", deleted)
+	}
+	return err
+}
+
+// Stop stops the cleanup loop, waiting for a cleanup in progress to finish or the context to expire.
+func (s *Store) Stop(ctx context.Context) error {
+	if s.cleanup == nil {
+		return nil
+	}
+	return s.cleanup.Stop(ctx)
+}
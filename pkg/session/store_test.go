@@ -0,0 +1,40 @@
+package session_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/audit"
+	"github.com/wcharczuk/katwillmarry.com/pkg/query"
+	"github.com/wcharczuk/katwillmarry.com/pkg/schema/schematest"
+	"github.com/wcharczuk/katwillmarry.com/pkg/session"
+)
+
+func TestRevoke(t *testing.T) {
+	conn := schematest.Open(t)
+	store := session.NewStore(conn)
+
+	record := session.Record{SessionID: "secret-session-id", UserID: "planner@example.com", CreatedUTC: time.Now().UTC()}
+	if err := store.Persist(nil, record.Session(), nil); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := store.Revoke("owner@example.com", record.Handle()); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	fetched, err := store.Fetch(record.SessionID, nil)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if fetched != nil {
+		t.Fatalf("revoked session should be gone, got %+v", fetched)
+	}
+
+	// the session id must not end up in the audit history, where a revert would bring it back.
+	count, err := query.Select(audit.Entry{}).Count(conn)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if count != 0 {
+		t.Fatalf("revoking a session shouldn't record an audit entry, got %d", count)
+	}
+}
@@ -0,0 +1,32 @@
+package softdelete
+
+import (
+	"time"
+
+	"github.com/blend/go-sdk/util"
+)
+
+const (
+	// DefaultRetention is the default time deleted rows are kept before they're purged.
+	DefaultRetention = 30 * 24 * time.Hour
+	// DefaultPurgeInterval is the default time between purges.
+	DefaultPurgeInterval = time.Hour
+)
+
+// Config is the soft delete config.
+type Config struct {
+	// Retention is how long deleted rows are kept (and can be restored) before they're purged.
+	Retention time.Duration `json:"retention,omitempty" yaml:"retention,omitempty" env:"SOFT_DELETE_RETENTION"`
+	// PurgeInterval is the time between purges.
+	PurgeInterval time.Duration `json:"purgeInterval,omitempty" yaml:"purgeInterval,omitempty" env:"SOFT_DELETE_PURGE_INTERVAL"`
+}
+
+// GetRetention returns a property or a default.
+func (c Config) GetRetention(inherited ...time.Duration) time.Duration {
+	return util.Coalesce.Duration(c.Retention, DefaultRetention, inherited...)
+}
+
+// GetPurgeInterval returns a property or a default.
+func (c Config) GetPurgeInterval(inherited ...time.Duration) time.Duration {
+	return util.Coalesce.Duration(c.PurgeInterval, DefaultPurgeInterval, inherited...)
+}
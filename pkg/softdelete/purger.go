@@ -0,0 +1,169 @@
+package softdelete
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/logger"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/lifecycle"
+)
+
+// NewPurger returns a new purger.
+func NewPurger(conn *db.Connection) *Purger {
+	return &Purger{
+		conn:      conn,
+		retention: DefaultRetention,
+		interval:  DefaultPurgeInterval,
+	}
+}
+
+// NewPurgerFromConfig returns a new purger from a config.
+func NewPurgerFromConfig(conn *db.Connection, cfg *Config) *Purger {
+	return NewPurger(conn).
+		WithRetention(cfg.GetRetention()).
+		WithInterval(cfg.GetPurgeInterval())
+}
+
+// Purger permanently deletes soft deleted rows once they're older than the retention window.
+type Purger struct {
+	conn      *db.Connection
+	log       *logger.Logger
+	retention time.Duration
+	interval  time.Duration
+
+	typesLock sync.Mutex
+	types     []db.DatabaseMapped
+
+	loop *lifecycle.Interval
+}
+
+// WithLogger sets the logger.
+func (p *Purger) WithLogger(log *logger.Logger) *Purger {
+	p.log = log
+	return p
+}
+
+// WithRetention sets the retention window.
+func (p *Purger) WithRetention(retention time.Duration) *Purger {
+	p.retention = retention
+	return p
+}
+
+// Retention returns the retention window.
+func (p *Purger) Retention() time.Duration {
+	return p.retention
+}
+
+// WithInterval sets the time between purges.
+func (p *Purger) WithInterval(interval time.Duration) *Purger {
+	p.interval = interval
+	return p
+}
+
+// Interval returns the time between purges.
+func (p *Purger) Interval() time.Duration {
+	return p.interval
+}
+
+// WithTypes adds the types to purge; types without a soft delete column are ignored.
+func (p *Purger) WithTypes(objects ...db.DatabaseMapped) *Purger {
+	p.typesLock.Lock()
+	defer p.typesLock.Unlock()
+	for _, object := range objects {
+		if Enabled(object) {
+			p.types = append(p.types, object)
+		}
+	}
+	return p
+}
+
+// Purge deletes the rows of each type that were soft deleted before the retention window.
+// It returns the number of rows deleted.
+//
+// Each row is deleted with `db.Invocation.Purge`, so the connection's tracker records it. Rows that
+// reference a purged row with an `on delete cascade` foreign key, ex. a household's guests and rsvps,
+// are deleted by the database along with it and aren't recorded themselves.
+func (p *Purger) Purge() (int64, error) {
+	p.typesLock.Lock()
+	types := make([]db.DatabaseMapped, len(p.types))
+	copy(types, p.types)
+	p.typesLock.Unlock()
+
+	cutoff := time.Now().UTC().Add(-p.retention)
+	var total int64
+	var errs []error
+	for _, object := range types {
+		rows, err := p.expired(object, cutoff)
+		if err != nil {
+			errs = append(errs, exception.New(err).WithMessagef("table: %s", db.TableName(object)))
+			continue
+		}
+		for _, row := range rows {
+			if err := p.conn.Invoke().Purge(row); err != nil {
+				errs = append(errs, exception.New(err).WithMessagef("table: %s", db.TableName(object)))
+				continue
+			}
+			total++
+		}
+	}
+	return total, exception.Nest(errs...)
+}
+
+// expired returns the primary keys of the rows of a type that were soft deleted before a cutoff,
+// as objects of the type.
+func (p *Purger) expired(object db.DatabaseMapped, cutoff time.Time) ([]db.DatabaseMapped, error) {
+	dialect := p.conn.Dialect()
+	pks := db.Columns(object).PrimaryKeys()
+	col := dialect.QuoteIdentifier(Column(object).ColumnName)
+	statement := "SELECT " + strings.Join(db.QuoteIdentifiers(dialect, pks.ColumnNames()), ",") +
+		" FROM " + dialect.QuoteIdentifier(db.TableName(object)) +
+		" WHERE " + col + " IS NOT NULL AND " + col + " < " + dialect.Placeholder(1)
+
+	t := reflect.TypeOf(object)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var rows []db.DatabaseMapped
+	err := p.conn.Invoke().Query(statement, cutoff).Each(func(r *sql.Rows) error {
+		row := reflect.New(t)
+		targets := make([]interface{}, pks.Len())
+		for index, pk := range pks.Columns() {
+			targets[index] = row.Elem().FieldByName(pk.FieldName).Addr().Interface()
+		}
+		if err := r.Scan(targets...); err != nil {
+			return exception.New(err)
+		}
+		rows = append(rows, row.Interface().(db.DatabaseMapped))
+		return nil
+	})
+	return rows, err
+}
+
+// Start starts purging on the interval in the background.
+func (p *Purger) Start() {
+	p.loop = lifecycle.NewInterval(p.interval, p.purge).WithLogger(p.log)
+	p.loop.Start()
+}
+
+func (p *Purger) purge() error {
+	deleted, err := p.Purge()
+	if deleted > 0 && p.log != nil {
+		p.log.Infof("purged %d soft deleted rows", deleted)
+	}
+	return err
+}
+
+// Stop stops the purge loop, waiting for a purge in progress to finish or the context to expire.
+func (p *Purger) Stop(ctx context.Context) error {
+	if p.loop == nil {
+		return nil
+	}
+	return p.loop.Stop(ctx)
+}
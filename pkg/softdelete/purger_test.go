@@ -0,0 +1,104 @@
+package softdelete_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wcharczuk/katwillmarry.com/pkg/audit"
+	"github.com/wcharczuk/katwillmarry.com/pkg/model"
+	"github.com/wcharczuk/katwillmarry.com/pkg/schema/schematest"
+	"github.com/wcharczuk/katwillmarry.com/pkg/softdelete"
+)
+
+func TestPurge(t *testing.T) {
+	conn := schematest.Open(t)
+	now := time.Now().UTC()
+
+	households := []model.Household{
+		{Name: "Expired", CreatedUTC: now, UpdatedUTC: now},
+		{Name: "Recent", CreatedUTC: now, UpdatedUTC: now},
+		{Name: "Kept", CreatedUTC: now, UpdatedUTC: now},
+	}
+	for index := range households {
+		if err := conn.Invoke().Create(&households[index]); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+	expired, recent, kept := households[0], households[1], households[2]
+	guest := model.Guest{HouseholdID: expired.ID, Name: "Alice", CreatedUTC: now, UpdatedUTC: now}
+	if err := conn.Invoke().Create(&guest); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	for _, household := range []model.Household{expired, recent} {
+		if err := conn.Invoke().Delete(&household); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+	// one household was deleted before the retention window, the other just inside it.
+	if err := conn.Invoke().Exec(`UPDATE household SET deleted_utc = ?1 WHERE id = ?2`, now.Add(-49*time.Hour), expired.ID); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := conn.Invoke().Exec(`UPDATE household SET deleted_utc = ?1 WHERE id = ?2`, now.Add(-47*time.Hour), recent.ID); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	purged, err := softdelete.NewPurger(conn).WithRetention(48 * time.Hour).WithTypes(model.Types()...).Purge()
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if purged != 1 {
+		t.Fatalf("only the household deleted before the retention window should be purged, purged %d", purged)
+	}
+
+	for _, household := range households {
+		var stored model.Household
+		if err := conn.Invoke().WithDeleted().Get(&stored, household.ID); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if (stored.ID != 0) != (household.ID != expired.ID) {
+			t.Fatalf("household %q: expected only the expired household to be gone, got %+v", household.Name, stored)
+		}
+	}
+	var storedGuest model.Guest
+	if err := conn.Invoke().WithDeleted().Get(&storedGuest, guest.ID); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if storedGuest.ID != 0 {
+		t.Fatalf("the purged household's guests should be deleted by the cascade, got %+v", storedGuest)
+	}
+
+	entries, err := audit.HistoryForScope(conn, model.HouseholdScope(expired.ID))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	var purges []audit.Entry
+	for _, entry := range entries {
+		if entry.Verb == audit.VerbPurge {
+			purges = append(purges, entry)
+		}
+	}
+	if len(purges) != 1 || purges[0].Entity != expired.TableName() || len(purges[0].Before) == 0 {
+		t.Fatalf("the purged household should be recorded once, got %+v", purges)
+	}
+	for _, household := range []model.Household{recent, kept} {
+		entries, err := audit.HistoryForScope(conn, model.HouseholdScope(household.ID))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if entries[0].Verb == audit.VerbPurge {
+			t.Fatalf("household %q shouldn't have been purged", household.Name)
+		}
+	}
+
+	// reverting the purge puts the household back, still deleted.
+	if err := audit.Revert(conn, "owner@example.com", purges[0].ID); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	var restored model.Household
+	if err := conn.Invoke().WithDeleted().Get(&restored, expired.ID); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if restored.ID != expired.ID || !softdelete.IsDeleted(&restored) {
+		t.Fatalf("reverting the purge should restore the deleted household, got %+v", restored)
+	}
+}
@@ -0,0 +1,36 @@
+// Package softdelete has the retention for types that opt in to being marked deleted instead of removed.
+//
+// A type opts in by tagging a nullable timestamp column with `softdelete`, ex:
+//
+//	DeletedUTC *time.Time `db:"deleted_utc,nullable,softdelete"`
+//
+// `db.Invocation.Delete` then sets the column rather than removing the row, `Get`, `GetAll` and the
+// `query` package leave marked rows out unless asked for them, `db.Invocation.Restore` clears it,
+// and the `Purger` removes them for good once they're older than the retention window.
+package softdelete
+
+import (
+	"time"
+
+	"github.com/blend/go-sdk/db"
+)
+
+// Column returns the soft delete column for a type, or nil if the type doesn't opt in.
+// The column has to be a `*time.Time`; a `softdelete` tag on any other type panics, see `db.Columns`.
+func Column(object db.DatabaseMapped) *db.Column {
+	return db.Columns(object).SoftDelete()
+}
+
+// Enabled returns if a type has a soft delete column.
+func Enabled(object db.DatabaseMapped) bool {
+	return Column(object) != nil
+}
+
+// IsDeleted returns if an object has been soft deleted.
+func IsDeleted(object db.DatabaseMapped) bool {
+	col := Column(object)
+	if col == nil {
+		return false
+	}
+	return col.GetValue(object).(*time.Time) != nil
+}